@@ -6,7 +6,9 @@ import (
 
 	"easy-stream/internal/config"
 	"easy-stream/internal/handler"
+	"easy-stream/internal/metrics"
 	"easy-stream/internal/middleware"
+	"easy-stream/internal/model"
 	"easy-stream/internal/repository"
 	"easy-stream/internal/service"
 	"easy-stream/internal/storage"
@@ -53,11 +55,14 @@ func main() {
 	streamRepo := repository.NewStreamRepository(db)
 	shareLinkRepo := repository.NewShareLinkRepository(db)
 	userRepo := repository.NewUserRepository(db)
+	operationLogRepo := repository.NewOperationLogRepository(db)
+	streamMetricRepo := repository.NewStreamMetricRepository(db)
+	dailyStatRepo := repository.NewDailyStatRepository(db)
+	viewerSessionRepo := repository.NewViewerSessionRepository(db)
+	recordingRepo := repository.NewRecordingRepository(db)
 
-	// 初始化 Service
-	streamSvc := service.NewStreamService(streamRepo, shareLinkRepo, rdb, cfg.ZLMediaKit)
-	shareLinkSvc := service.NewShareLinkService(shareLinkRepo, streamRepo, rdb)
-	authSvc := service.NewAuthService(userRepo, rdb, cfg.JWT)
+	// 初始化 ZLMediaKit 节点注册表
+	zlmNodes := zlm.NewRegistry(cfg.ZLMediaKit)
 
 	// 初始化存储管理器
 	var storageManager *storage.Manager
@@ -68,16 +73,46 @@ func main() {
 		}
 	}
 
+	// 初始化 Service
+	auditSvc := service.NewAuditService(operationLogRepo)
+	statsSvc := service.NewStatsService(dailyStatRepo, streamRepo, zlmNodes)
+	viewerSvc := service.NewViewerService(streamRepo, viewerSessionRepo, rdb, zlmNodes, statsSvc, cfg.Viewer)
+	uploadSvc := service.NewUploadService(recordingRepo, storageManager, auditSvc, cfg.Storage.Upload)
+	recordingSvc := service.NewRecordingService(recordingRepo, streamRepo, uploadSvc, auditSvc, statsSvc, storageManager, zlmNodes, cfg.Storage.Download)
+	streamSvc := service.NewStreamService(streamRepo, shareLinkRepo, rdb, zlmNodes, cfg.Push, cfg.Play, auditSvc, statsSvc, viewerSvc, recordingSvc)
+	shareLinkSvc := service.NewShareLinkService(shareLinkRepo, streamRepo, rdb, auditSvc, statsSvc)
+	authSvc := service.NewAuthService(userRepo, rdb, cfg.JWT, auditSvc)
+	streamMetricSvc := service.NewStreamMetricService(streamMetricRepo, streamRepo, cfg.Metrics)
+
 	// 初始化系统服务
-	systemSvc := service.NewSystemService(db, rdb, cfg.ZLMediaKit)
+	systemSvc := service.NewSystemService(db, rdb, zlmNodes)
+
+	// 初始化 Hook 回调认证
+	hookAuthSvc, err := service.NewHookAuthService(cfg.Hook, zlmNodes, rdb)
+	if err != nil {
+		log.Fatalf("Failed to init hook authentication: %v", err)
+	}
+	if cfg.Hook.Secret == "" && len(cfg.Hook.AllowedIPs) == 0 {
+		log.Printf("Warning: hook.secret and hook.allowedIPs not configured, hook endpoints accept calls from any source")
+	}
+	if cfg.Hook.Secret != "" && cfg.Hook.QueryToken && len(cfg.Hook.AllowedIPs) == 0 {
+		log.Printf("Warning: hook.queryToken enabled without hook.allowedIPs, the hook secret has no replay protection")
+	}
 
-	// 配置 ZLMediaKit Hook 回调
+	// 为每个 ZLMediaKit 节点配置 Hook 回调（节点重启后在 on_server_started 回调中重新配置）
+	// 只有启用 hook.queryToken 时才将共享密钥附加到回调地址
+	hookToken := ""
+	if cfg.Hook.QueryToken {
+		hookToken = cfg.Hook.Secret
+	}
+	nodeSvc := service.NewNodeService(zlmNodes, streamRepo, streamSvc, cfg.ZLMediaKit.HookBaseURL, hookToken)
 	if cfg.ZLMediaKit.HookBaseURL != "" {
-		zlmClient := zlm.NewClient(cfg.ZLMediaKit.Host, cfg.ZLMediaKit.Port, cfg.ZLMediaKit.Secret)
-		if err := zlmClient.ConfigureHooks(cfg.ZLMediaKit.HookBaseURL); err != nil {
-			log.Printf("Warning: Failed to configure ZLMediaKit hooks: %v", err)
-		} else {
-			log.Printf("ZLMediaKit hooks configured successfully: %s", cfg.ZLMediaKit.HookBaseURL)
+		for _, node := range zlmNodes.Nodes() {
+			if err := nodeSvc.ConfigureHooks(node); err != nil {
+				log.Printf("Warning: Failed to configure ZLMediaKit hooks on node %s: %v", node.ID, err)
+			} else {
+				log.Printf("ZLMediaKit hooks configured successfully on node %s: %s", node.ID, cfg.ZLMediaKit.HookBaseURL)
+			}
 		}
 	} else {
 		log.Printf("Warning: zlmediakit.hookBaseURL not configured, hooks will not work")
@@ -87,8 +122,12 @@ func main() {
 	streamHandler := handler.NewStreamHandler(streamSvc)
 	shareLinkHandler := handler.NewShareLinkHandler(shareLinkSvc)
 	authHandler := handler.NewAuthHandler(authSvc)
-	hookHandler := handler.NewHookHandler(streamSvc, storageManager)
-	systemHandler := handler.NewSystemHandler(systemSvc)
+	recordingHandler := handler.NewRecordingHandler(recordingSvc)
+	uploadHandler := handler.NewUploadHandler(uploadSvc)
+	hookHandler := handler.NewHookHandler(streamSvc, nodeSvc, recordingSvc, hookAuthSvc)
+	systemHandler := handler.NewSystemHandler(systemSvc, statsSvc)
+	auditHandler := handler.NewAuditHandler(auditSvc)
+	streamMetricHandler := handler.NewStreamMetricHandler(streamMetricSvc)
 
 	// 启动定时任务：检查超时直播
 	go func() {
@@ -101,17 +140,132 @@ func main() {
 		}
 	}()
 
+	// 启动定时任务：记录今日并发观看人数和推流数峰值
+	go func() {
+		ticker := time.NewTicker(1 * time.Minute)
+		defer ticker.Stop()
+		for range ticker.C {
+			if err := statsSvc.RecordPeak(); err != nil {
+				log.Printf("Failed to record daily peak stats: %v", err)
+			}
+		}
+	}()
+
+	// 启动定时任务：空流检测
+	if cfg.EmptyStream.Enabled && cfg.EmptyStream.CheckInterval > 0 {
+		detector := service.NewEmptyStreamDetector(streamSvc, cfg.EmptyStream)
+		go func() {
+			ticker := time.NewTicker(time.Duration(cfg.EmptyStream.CheckInterval) * time.Second)
+			defer ticker.Stop()
+			for range ticker.C {
+				if err := detector.Check(); err != nil {
+					log.Printf("Failed to check empty streams: %v", err)
+				}
+			}
+		}()
+	}
+
+	// 启动定时任务：直播质量采样
+	if cfg.Sampler.Enabled && cfg.Sampler.Interval > 0 {
+		sampler := service.NewStreamSampler(streamSvc, streamMetricRepo)
+		go func() {
+			ticker := time.NewTicker(time.Duration(cfg.Sampler.Interval) * time.Second)
+			defer ticker.Stop()
+			for range ticker.C {
+				if err := sampler.Sample(); err != nil {
+					log.Printf("Failed to sample stream quality: %v", err)
+				}
+			}
+		}()
+	}
+
+	// 启动定时任务：观看会话心跳刷新和观看人数写回
+	if cfg.Viewer.FlushInterval > 0 {
+		go func() {
+			ticker := time.NewTicker(time.Duration(cfg.Viewer.FlushInterval) * time.Second)
+			defer ticker.Stop()
+			for range ticker.C {
+				if err := viewerSvc.Flush(); err != nil {
+					log.Printf("Failed to flush viewer sessions: %v", err)
+				}
+			}
+		}()
+	}
+
+	// 启动定时任务：直播状态校正（启动时先执行一次）
+	if cfg.Reconcile.Enabled && cfg.Reconcile.Interval > 0 {
+		reconciler := service.NewStreamReconciler(streamSvc, cfg.Reconcile)
+		go func() {
+			if err := reconciler.Reconcile(); err != nil {
+				log.Printf("Failed to reconcile stream state: %v", err)
+			}
+			ticker := time.NewTicker(time.Duration(cfg.Reconcile.Interval) * time.Second)
+			defer ticker.Stop()
+			for range ticker.C {
+				if err := reconciler.Reconcile(); err != nil {
+					log.Printf("Failed to reconcile stream state: %v", err)
+				}
+			}
+		}()
+	}
+
+	// 启动定时任务：健康指标降采样和清理
+	if cfg.Metrics.CleanupInterval > 0 {
+		go func() {
+			ticker := time.NewTicker(time.Duration(cfg.Metrics.CleanupInterval) * time.Minute)
+			defer ticker.Stop()
+			for range ticker.C {
+				if err := streamMetricSvc.Cleanup(); err != nil {
+					log.Printf("Failed to clean up stream metrics: %v", err)
+				}
+			}
+		}()
+	}
+
+	// 启动录制文件上传 worker（继续处理重启前未完成的上传任务）
+	if storageManager != nil && storageManager.HasStorages() {
+		uploadSvc.Start()
+	}
+
+	// 启动定时任务：录制文件保留策略清理
+	if cfg.Storage.Retention.Enabled && cfg.Storage.Retention.Interval > 0 {
+		retentionSvc := service.NewRetentionService(recordingRepo, storageManager, zlmNodes, auditSvc, cfg.Storage)
+		go func() {
+			ticker := time.NewTicker(time.Duration(cfg.Storage.Retention.Interval) * time.Hour)
+			defer ticker.Stop()
+			for range ticker.C {
+				if err := retentionSvc.Run(); err != nil {
+					log.Printf("Failed to apply recording retention: %v", err)
+				}
+			}
+		}()
+	}
+
 	// 设置 Gin
 	if cfg.Server.Mode == "release" {
 		gin.SetMode(gin.ReleaseMode)
 	}
 
-	r := gin.Default()
+	// 不使用 gin 默认的访问日志，由 middleware.Logger 记录（查询参数中的密钥已脱敏）
+	r := gin.New()
 
 	// 中间件
+	r.Use(gin.Recovery())
 	r.Use(middleware.Cors())
 	r.Use(middleware.Logger())
 
+	// 权限校验中间件简写
+	perm := middleware.RequirePermission
+
+	// Prometheus 监控指标
+	if cfg.Prometheus.Enabled {
+		if cfg.Prometheus.Token == "" {
+			log.Printf("Warning: prometheus.token not configured, /metrics is accessible without authentication")
+		}
+		metrics.Default.OnScrape(streamSvc.CollectMetrics)
+		r.GET("/metrics", middleware.MetricsAuth(cfg.Prometheus.Token), gin.WrapH(metrics.Default.Handler()))
+	}
+
 	// 路由
 	api := r.Group("/api/v1")
 	{
@@ -131,6 +285,9 @@ func main() {
 			shares.GET("/link/:token", shareLinkHandler.Verify)        // 验证分享链接
 		}
 
+		// 录制文件下载（游客和管理员都可以使用，权限与观看直播相同）
+		api.GET("/recordings/:id/download", middleware.OptionalAuth(cfg.JWT.Secret), streamHandler.DownloadRecording)
+
 		// 推流管理接口
 		streams := api.Group("/streams")
 		{
@@ -141,30 +298,46 @@ func main() {
 			// WebRTC 播放接口（游客和管理员都可以使用）
 			streams.POST("/webrtc/:id", middleware.OptionalAuth(cfg.JWT.Secret), streamHandler.WebRTCPlay)
 			streams.GET("/webrtc/:id", middleware.OptionalAuth(cfg.JWT.Secret), streamHandler.GetWebRTCSDP)
+			// 各协议播放地址（私有直播携带播放签名）
+			streams.GET("/play-urls/:id", middleware.OptionalAuth(cfg.JWT.Secret), streamHandler.PlayURLs)
+			// 录制点播（已完成的 HLS 录制）
+			streams.GET("/vod/:id", middleware.OptionalAuth(cfg.JWT.Secret), streamHandler.VODList)
+			streams.GET("/vod/:id/:recordingId/index.m3u8", middleware.OptionalAuth(cfg.JWT.Secret), streamHandler.VODPlaylist)
 
-			// 管理员接口（需要认证）
+			// 管理员接口（需要认证，按角色权限控制）
 			admin := streams.Group("")
 			admin.Use(middleware.Auth(cfg.JWT.Secret))
 			{
-				admin.POST("", streamHandler.Create)
-				admin.GET("/id/:id", streamHandler.GetByID) // 管理员通过 ID 获取（含 key）
-				admin.GET("/:key", streamHandler.Get)       // 管理员通过 key 获取
-				admin.PUT("/:key", streamHandler.Update)
-				admin.DELETE("/:key", streamHandler.Delete)
-				admin.POST("/:key/kick", streamHandler.Kick)
-				admin.POST("/:key/end", streamHandler.End)
+				admin.POST("", perm(model.PermStreamCreate), streamHandler.Create)
+				admin.GET("/id/:id", perm(model.PermStreamView), streamHandler.GetByID)             // 管理员通过 ID 获取（有推流凭证权限时含 key）
+				admin.GET("/id/:id/metrics", perm(model.PermStreamView), streamMetricHandler.Query) // 健康指标时序数据
+				admin.GET("/:key", perm(model.PermStreamView), streamHandler.Get)                   // 管理员通过 key 获取
+				admin.PUT("/:key", perm(model.PermStreamUpdate), streamHandler.Update)
+				admin.DELETE("/:key", perm(model.PermStreamDelete), streamHandler.Delete)
+				admin.POST("/:key/kick", perm(model.PermStreamKick), streamHandler.Kick)
+				admin.POST("/:key/end", perm(model.PermStreamEnd), streamHandler.End)
+				admin.GET("/:key/push-urls", perm(model.PermStreamUpdate), streamHandler.GetPushURLs)         // 获取推流地址（含签名）
+				admin.POST("/:key/push-secret", perm(model.PermStreamUpdate), streamHandler.ResetPushSecret)  // 重置推流密钥
+				admin.POST("/:key/playback-id", perm(model.PermStreamUpdate), streamHandler.RotatePlaybackID) // 重新生成播放ID
+				admin.POST("/:key/rotate-key", perm(model.PermStreamUpdate), streamHandler.RotateStreamKey)   // 更换推流码
 
 				// 分享码管理
-				admin.POST("/:key/share-code", streamHandler.AddShareCode)            // 添加分享码
-				admin.PUT("/:key/share-code", streamHandler.RegenerateShareCode)      // 重新生成分享码
-				admin.PATCH("/:key/share-code", streamHandler.UpdateShareCodeMaxUses) // 更新分享码使用次数
-				admin.DELETE("/:key/share-code", streamHandler.DeleteShareCode)       // 删除分享码
+				admin.POST("/:key/share-code", perm(model.PermShareManage), streamHandler.AddShareCode)            // 添加分享码
+				admin.PUT("/:key/share-code", perm(model.PermShareManage), streamHandler.RegenerateShareCode)      // 重新生成分享码
+				admin.PATCH("/:key/share-code", perm(model.PermShareManage), streamHandler.UpdateShareCodeMaxUses) // 更新分享码使用次数
+				admin.DELETE("/:key/share-code", perm(model.PermShareManage), streamHandler.DeleteShareCode)       // 删除分享码
 
 				// 分享链接管理
-				admin.POST("/:key/share-links", shareLinkHandler.Create)        // 创建分享链接
-				admin.GET("/:key/share-links", shareLinkHandler.List)           // 获取分享链接列表
-				admin.PATCH("/share-links/:id", shareLinkHandler.UpdateMaxUses) // 更新分享链接使用次数
-				admin.DELETE("/share-links/:id", shareLinkHandler.Delete)       // 删除分享链接
+				admin.POST("/:key/share-links", perm(model.PermShareManage), shareLinkHandler.Create)        // 创建分享链接
+				admin.GET("/:key/share-links", perm(model.PermShareManage), shareLinkHandler.List)           // 获取分享链接列表
+				admin.PATCH("/share-links/:id", perm(model.PermShareManage), shareLinkHandler.UpdateMaxUses) // 更新分享链接使用次数
+				admin.DELETE("/share-links/:id", perm(model.PermShareManage), shareLinkHandler.Delete)       // 删除分享链接
+
+				// 录制文件管理
+				admin.GET("/:key/recordings", perm(model.PermStreamView), recordingHandler.List)            // 获取录制文件列表
+				admin.GET("/:key/recordings/:id", perm(model.PermStreamView), recordingHandler.Get)         // 获取录制文件详情
+				admin.PATCH("/:key/recordings/:id", perm(model.PermStreamUpdate), recordingHandler.Update)  // 收藏/取消收藏录制文件
+				admin.DELETE("/:key/recordings/:id", perm(model.PermStreamUpdate), recordingHandler.Delete) // 删除录制文件
 			}
 		}
 
@@ -172,11 +345,15 @@ func main() {
 		system := api.Group("/system")
 		{
 			system.GET("/health", systemHandler.Health)
-			system.GET("/stats", middleware.Auth(cfg.JWT.Secret), systemHandler.Stats)
+			system.GET("/stats", middleware.Auth(cfg.JWT.Secret), perm(model.PermSystemStats), systemHandler.Stats)
+			system.GET("/stats/daily", middleware.Auth(cfg.JWT.Secret), perm(model.PermSystemStats), systemHandler.DailyStats)
+			system.GET("/audit-logs", middleware.Auth(cfg.JWT.Secret), perm(model.PermAuditView), auditHandler.List)
+			system.GET("/uploads", middleware.Auth(cfg.JWT.Secret), perm(model.PermStorageManage), uploadHandler.List)
+			system.POST("/uploads/:id/retry", middleware.Auth(cfg.JWT.Secret), perm(model.PermStorageManage), uploadHandler.Retry)
 		}
 
 		// ZLMediaKit Hook 接口
-		hooks := api.Group("/hooks")
+		hooks := api.Group("/hooks", hookHandler.Authenticate)
 		{
 			hooks.POST("/on_publish", hookHandler.OnPublish)
 			hooks.POST("/on_unpublish", hookHandler.OnUnpublish)
@@ -185,6 +362,13 @@ func main() {
 			hooks.POST("/on_play", hookHandler.OnPlay)
 			hooks.POST("/on_player_disconnect", hookHandler.OnPlayerDisconnect)
 			hooks.POST("/on_record_mp4", hookHandler.OnRecordMP4)
+			hooks.POST("/on_record_ts", hookHandler.OnRecordTS)
+			hooks.POST("/on_http_access", hookHandler.OnHTTPAccess)
+			hooks.POST("/on_stream_changed", hookHandler.OnStreamChanged)
+			hooks.POST("/on_stream_not_found", hookHandler.OnStreamNotFound)
+			hooks.POST("/on_server_started", hookHandler.OnServerStarted)
+			hooks.POST("/on_server_keepalive", hookHandler.OnServerKeepalive)
+			hooks.POST("/on_rtp_server_timeout", hookHandler.OnRTPServerTimeout)
 		}
 	}
 
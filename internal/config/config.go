@@ -7,13 +7,22 @@ import (
 )
 
 type Config struct {
-	Server     ServerConfig
-	Database   DatabaseConfig
-	Redis      RedisConfig
-	JWT        JWTConfig
-	ZLMediaKit ZLMediaKitConfig
-	Log        LogConfig
-	Storage    StorageConfig
+	Server      ServerConfig
+	Database    DatabaseConfig
+	Redis       RedisConfig
+	JWT         JWTConfig
+	ZLMediaKit  ZLMediaKitConfig
+	Hook        HookConfig
+	Log         LogConfig
+	Storage     StorageConfig
+	EmptyStream EmptyStreamConfig
+	Push        PushConfig
+	Play        PlayConfig
+	Sampler     SamplerConfig
+	Reconcile   ReconcileConfig
+	Viewer      ViewerConfig
+	Metrics     MetricsConfig
+	Prometheus  PrometheusConfig
 }
 
 type ServerConfig struct {
@@ -47,16 +56,144 @@ type ZLMediaKitConfig struct {
 	Host        string
 	Port        string
 	Secret      string
-	HookBaseURL string // Hook 回调基础 URL，如 http://localhost:8080/api/v1/hooks
+	HookBaseURL string          // Hook 回调基础 URL，如 http://localhost:8080/api/v1/hooks
+	ServerID    string          `mapstructure:"serverId"` // 单节点模式下 ZLMediaKit 的 mediaServerId（未配置 nodes 时使用）
+	Nodes       []ZLMNodeConfig `mapstructure:"nodes"`    // 多节点集群配置，为空时使用上面的单节点配置
+}
+
+// ZLMNodeConfig ZLMediaKit 集群节点配置
+// 节点按 mediaServerId 区分，Hook 回调中的 mediaServerId 用于定位推流所在节点
+// 源站（origin）接收推流；边缘节点（edge）只用于播放，按需通过拉流代理从源站拉流
+type ZLMNodeConfig struct {
+	ID       string `mapstructure:"id"`       // 节点 mediaServerId（需与 ZLMediaKit 配置 general.mediaServerId 一致）
+	Role     string `mapstructure:"role"`     // 节点角色：origin / edge，默认 origin
+	Host     string `mapstructure:"host"`     // API 地址（源站同时作为边缘节点拉流的地址）
+	Port     string `mapstructure:"port"`     // API 端口，为空时使用 zlmediakit.port
+	Secret   string `mapstructure:"secret"`   // API 密钥，为空时使用 zlmediakit.secret
+	RTMPPort int    `mapstructure:"rtmpPort"` // 源站 RTMP 端口，边缘节点从该端口拉流，默认 1935
+	HTTPURL  string `mapstructure:"httpUrl"`  // 观众访问该节点 HTTP 文件服务器的地址（录制点播），为空时默认源站使用 play.host/httpPort，其他节点使用 http://{host}:{port}
+	Default  bool   `mapstructure:"default"`  // 是否为默认源站（未知 mediaServerId 的直播路由到默认源站），未指定时为第一个源站
+}
+
+// HookConfig ZLMediaKit Hook 回调认证配置
+// 以下校验项均开启时，请求需依次通过来源 IP、密钥（或签名）和 mediaServerId 校验
+type HookConfig struct {
+	Secret         string   `mapstructure:"secret"`         // 共享密钥，为空表示不校验；默认要求 HMAC 签名
+	QueryToken     bool     `mapstructure:"queryToken"`     // 是否允许以 ?token={secret} 校验（配置 Hook 时附加到回调地址），无防重放能力
+	AllowedIPs     []string `mapstructure:"allowedIPs"`     // 来源 IP 白名单（IP 或 CIDR），为空表示不限制
+	VerifyServerID bool     `mapstructure:"verifyServerId"` // 是否校验 mediaServerId 为已注册的 ZLMediaKit 节点
+	SignatureTTL   int      `mapstructure:"signatureTtl"`   // HMAC 签名有效期（秒），超出有效期或重复使用的签名被拒绝
 }
 
 type LogConfig struct {
 	Level string // debug / info / warn / error
 }
 
+// EmptyStreamConfig 空流检测配置
+// 满足任意一个条件且持续超过对应阈值即判定为空流，踢流并结束直播
+type EmptyStreamConfig struct {
+	Enabled            bool `mapstructure:"enabled"`            // 是否启用空流检测
+	CheckInterval      int  `mapstructure:"checkInterval"`      // 检测间隔（秒）
+	NoVideoTimeout     int  `mapstructure:"noVideoTimeout"`     // 无视频轨道或视频轨道未就绪的持续时间阈值（秒）
+	ZeroBitrateTimeout int  `mapstructure:"zeroBitrateTimeout"` // 码率低于 MinBytesSpeed 的持续时间阈值（秒）
+	NoFrameTimeout     int  `mapstructure:"noFrameTimeout"`     // 视频帧数不增长（画面冻结）的持续时间阈值（秒）
+	NoKeyFrameTimeout  int  `mapstructure:"noKeyFrameTimeout"`  // 关键帧数不增长的持续时间阈值（秒）
+	MinBytesSpeed      int  `mapstructure:"minBytesSpeed"`      // 判定为有效码率的最低速率（字节/秒）
+}
+
+// PushConfig 推流地址配置
+// 用于生成下发给推流端的 RTMP/SRT/RTSP 推流地址及签名
+type PushConfig struct {
+	Host           string `mapstructure:"host"`           // 推流端访问的 ZLMediaKit 地址（域名或 IP），为空时使用 zlmediakit.host
+	RTMPPort       int    `mapstructure:"rtmpPort"`       // RTMP 端口
+	SRTPort        int    `mapstructure:"srtPort"`        // SRT 端口
+	RTSPPort       int    `mapstructure:"rtspPort"`       // RTSP 端口
+	SignExpire     int    `mapstructure:"signExpire"`     // 推流签名默认有效期（秒）
+	KeyGracePeriod int    `mapstructure:"keyGracePeriod"` // 更换推流码后旧推流码仍可推流的宽限期（秒），0 表示立即失效
+}
+
+// PlayConfig 播放地址配置
+// 私有直播的播放地址携带 expire 和 sign 参数，on_play 回调时校验
+type PlayConfig struct {
+	Host       string `mapstructure:"host"`       // 观众访问的 ZLMediaKit 地址（域名或 IP），为空时使用 push.host
+	HTTPPort   int    `mapstructure:"httpPort"`   // HTTP 端口（HTTP-FLV/HLS/WS-FLV）
+	RTMPPort   int    `mapstructure:"rtmpPort"`   // RTMP 端口
+	RTSPPort   int    `mapstructure:"rtspPort"`   // RTSP 端口
+	SignSecret string `mapstructure:"signSecret"` // 播放签名密钥，为空时使用 jwt.secret
+	SignExpire int    `mapstructure:"signExpire"` // 播放签名有效期（秒）
+}
+
+// SamplerConfig 直播质量采样配置
+// 定时从 ZLMediaKit 媒体列表采集码率、帧率、分辨率和编码信息写入直播记录
+type SamplerConfig struct {
+	Enabled  bool `mapstructure:"enabled"`  // 是否启用采样
+	Interval int  `mapstructure:"interval"` // 采样间隔（秒）
+}
+
+// ReconcileConfig 直播状态校正配置
+// 启动时和定时对比 ZLMediaKit 媒体列表与数据库，校正错过 Hook 导致的状态偏差
+type ReconcileConfig struct {
+	Enabled      bool `mapstructure:"enabled"`      // 是否启用状态校正
+	Interval     int  `mapstructure:"interval"`     // 校正间隔（秒）
+	CloseOrphans bool `mapstructure:"closeOrphans"` // 是否关闭 ZLMediaKit 中没有对应有效直播的流
+}
+
+// ViewerConfig 观看会话统计配置
+// 每个播放会话记录在 Redis 中，定时从 ZLMediaKit 播放器列表刷新心跳，超过 SessionTTL 未刷新的会话视为已断开
+type ViewerConfig struct {
+	SessionTTL    int `mapstructure:"sessionTtl"`    // 会话心跳超时（秒）
+	FlushInterval int `mapstructure:"flushInterval"` // 心跳刷新和观看人数写回数据库的间隔（秒），应小于 SessionTTL
+	DedupeWindow  int `mapstructure:"dedupeWindow"`  // 累计观看人次去重窗口（秒），窗口内同一观众 IP 只计一次
+}
+
+// MetricsConfig 直播健康指标时序数据配置
+// 原始采样保留 RawRetention 小时，之后按 DownsampleStep 秒聚合，聚合数据保留 Retention 天
+type MetricsConfig struct {
+	RawRetention    int `mapstructure:"rawRetention"`    // 原始采样保留时长（小时）
+	DownsampleStep  int `mapstructure:"downsampleStep"`  // 降采样时间桶长度（秒）
+	Retention       int `mapstructure:"retention"`       // 指标数据保留时长（天）
+	CleanupInterval int `mapstructure:"cleanupInterval"` // 降采样和清理任务执行间隔（分钟）
+}
+
+// PrometheusConfig Prometheus 监控指标接口配置
+type PrometheusConfig struct {
+	Enabled bool   `mapstructure:"enabled"` // 是否开启 /metrics 接口（默认关闭）
+	Token   string `mapstructure:"token"`   // 抓取认证 Token（Authorization: Bearer），为空表示不校验
+}
+
 // StorageConfig 存储配置
 type StorageConfig struct {
-	Targets []StorageTarget `mapstructure:"targets"` // 多个存储目标
+	Targets   []StorageTarget `mapstructure:"targets"`   // 多个存储目标
+	Upload    UploadConfig    `mapstructure:"upload"`    // 上传队列
+	Retention RetentionConfig `mapstructure:"retention"` // 录制文件保留策略
+	Download  DownloadConfig  `mapstructure:"download"`  // 录制文件下载
+}
+
+// DownloadConfig 录制文件下载
+type DownloadConfig struct {
+	Expire int `mapstructure:"expire"` // S3 兼容存储临时下载地址的有效期（秒）
+}
+
+// RetentionConfig 录制文件保留策略（全局）
+// 清理任务按 Interval 定时执行，保留天数从录制结束时间开始计算；
+// 直播的 retention_days 和存储目标的 retentionDays 优先于全局设置，收藏的录制永久保留
+type RetentionConfig struct {
+	Enabled                bool `mapstructure:"enabled"`                // 是否启用清理任务
+	Interval               int  `mapstructure:"interval"`               // 清理任务执行间隔（小时）
+	LocalDays              int  `mapstructure:"localDays"`              // ZLMediaKit 节点上本地文件的保留天数，0 表示不按时间删除
+	DeleteLocalAfterUpload bool `mapstructure:"deleteLocalAfterUpload"` // 上传到所有存储目标成功后删除本地文件
+	RemoteDays             int  `mapstructure:"remoteDays"`             // 存储目标上副本的保留天数，0 表示永久保留
+}
+
+// UploadConfig 录制文件上传队列配置
+// 上传任务持久化在数据库中，失败后按指数退避重试：第 n 次重试等待 RetryDelay * 2^(n-1) 秒，最长 MaxRetryDelay 秒
+type UploadConfig struct {
+	Workers       int `mapstructure:"workers"`       // 并发上传数
+	MaxAttempts   int `mapstructure:"maxAttempts"`   // 最大尝试次数，达到后标记为失败，需手动重试
+	RetryDelay    int `mapstructure:"retryDelay"`    // 首次重试等待时间（秒）
+	MaxRetryDelay int `mapstructure:"maxRetryDelay"` // 重试等待时间上限（秒）
+	Timeout       int `mapstructure:"timeout"`       // 单次上传超时（秒），超时未完成的任务会被重新领取
+	PollInterval  int `mapstructure:"pollInterval"`  // 空闲时检查待上传任务的间隔（秒）
 }
 
 // StorageTarget 存储目标配置
@@ -74,6 +211,10 @@ type StorageTarget struct {
 	SecretAccessKey string `mapstructure:"secretAccessKey"` // 访问密钥
 	PathPrefix      string `mapstructure:"pathPrefix"`      // 存储路径前缀
 	CustomDomain    string `mapstructure:"customDomain"`    // 自定义域名（用于生成访问URL）
+	// 远程路径模板（相对于 localDir / pathPrefix），为空时使用 {stream_id}/{date}/{file_name}
+	PathTemplate string `mapstructure:"pathTemplate"`
+	// 保留策略
+	RetentionDays int `mapstructure:"retentionDays"` // 副本保留天数，0 使用 storage.retention.remoteDays，负数表示永久保留
 }
 
 func Load() (*Config, error) {
@@ -94,7 +235,52 @@ func Load() (*Config, error) {
 	viper.SetDefault("redis.db", 0)
 	viper.SetDefault("jwt.expireHour", 24)
 	viper.SetDefault("zlmediakit.port", "80")
+	viper.SetDefault("zlmediakit.serverId", "your_server_id")
+	viper.SetDefault("hook.verifyServerId", true)
+	viper.SetDefault("hook.signatureTtl", 300)
+	viper.SetDefault("hook.queryToken", false)
 	viper.SetDefault("log.level", "info")
+	viper.SetDefault("emptyStream.enabled", true)
+	viper.SetDefault("emptyStream.checkInterval", 10)
+	viper.SetDefault("emptyStream.noVideoTimeout", 30)
+	viper.SetDefault("emptyStream.zeroBitrateTimeout", 30)
+	viper.SetDefault("emptyStream.noFrameTimeout", 60)
+	viper.SetDefault("emptyStream.noKeyFrameTimeout", 120)
+	viper.SetDefault("emptyStream.minBytesSpeed", 1)
+	viper.SetDefault("push.rtmpPort", 1935)
+	viper.SetDefault("push.srtPort", 9000)
+	viper.SetDefault("push.rtspPort", 554)
+	viper.SetDefault("push.signExpire", 86400)
+	viper.SetDefault("push.keyGracePeriod", 300)
+	viper.SetDefault("play.httpPort", 80)
+	viper.SetDefault("play.rtmpPort", 1935)
+	viper.SetDefault("play.rtspPort", 554)
+	viper.SetDefault("play.signExpire", 3600)
+	viper.SetDefault("sampler.enabled", true)
+	viper.SetDefault("sampler.interval", 10)
+	viper.SetDefault("reconcile.enabled", true)
+	viper.SetDefault("reconcile.interval", 60)
+	viper.SetDefault("reconcile.closeOrphans", false)
+	viper.SetDefault("viewer.sessionTtl", 120)
+	viper.SetDefault("viewer.flushInterval", 30)
+	viper.SetDefault("viewer.dedupeWindow", 1800)
+	viper.SetDefault("metrics.rawRetention", 24)
+	viper.SetDefault("metrics.downsampleStep", 60)
+	viper.SetDefault("metrics.retention", 30)
+	viper.SetDefault("metrics.cleanupInterval", 60)
+	viper.SetDefault("prometheus.enabled", false)
+	viper.SetDefault("storage.upload.workers", 2)
+	viper.SetDefault("storage.upload.maxAttempts", 8)
+	viper.SetDefault("storage.upload.retryDelay", 30)
+	viper.SetDefault("storage.upload.maxRetryDelay", 3600)
+	viper.SetDefault("storage.upload.timeout", 1800)
+	viper.SetDefault("storage.upload.pollInterval", 5)
+	viper.SetDefault("storage.retention.enabled", true)
+	viper.SetDefault("storage.retention.interval", 24)
+	viper.SetDefault("storage.retention.localDays", 0)
+	viper.SetDefault("storage.retention.deleteLocalAfterUpload", false)
+	viper.SetDefault("storage.retention.remoteDays", 0)
+	viper.SetDefault("storage.download.expire", 300)
 
 	// 支持环境变量
 	viper.AutomaticEnv()
@@ -115,5 +301,10 @@ func Load() (*Config, error) {
 		return nil, err
 	}
 
+	// 未单独配置播放签名密钥时使用 JWT 密钥
+	if cfg.Play.SignSecret == "" {
+		cfg.Play.SignSecret = cfg.JWT.Secret
+	}
+
 	return &cfg, nil
 }
@@ -0,0 +1,67 @@
+package handler
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"easy-stream/internal/model"
+	"easy-stream/internal/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+type AuditHandler struct {
+	auditSvc *service.AuditService
+}
+
+func NewAuditHandler(auditSvc *service.AuditService) *AuditHandler {
+	return &AuditHandler{auditSvc: auditSvc}
+}
+
+// List 查询操作日志（管理员）
+// 支持按 user_id、action、target_type、target_id 和时间范围（from/to，RFC3339）筛选
+func (h *AuditHandler) List(c *gin.Context) {
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	pageSize, _ := strconv.Atoi(c.DefaultQuery("pageSize", "20"))
+
+	req := &model.OperationLogListRequest{
+		Action:     c.Query("action"),
+		TargetType: c.Query("target_type"),
+		TargetID:   c.Query("target_id"),
+		Page:       page,
+		PageSize:   pageSize,
+	}
+
+	if v := c.Query("user_id"); v != "" {
+		userID, err := strconv.ParseInt(v, 10, 64)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid user_id"})
+			return
+		}
+		req.UserID = userID
+	}
+	if v := c.Query("from"); v != "" {
+		from, err := time.Parse(time.RFC3339, v)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid from, expected RFC3339"})
+			return
+		}
+		req.From = &from
+	}
+	if v := c.Query("to"); v != "" {
+		to, err := time.Parse(time.RFC3339, v)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid to, expected RFC3339"})
+			return
+		}
+		req.To = &to
+	}
+
+	resp, err := h.auditSvc.List(req)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, resp)
+}
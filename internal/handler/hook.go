@@ -1,63 +1,133 @@
 package handler
 
 import (
+	"bytes"
+	"io"
+	"log"
 	"net/http"
+	"path"
 
+	"easy-stream/internal/metrics"
 	"easy-stream/internal/model"
 	"easy-stream/internal/service"
-	"easy-stream/internal/storage"
 
 	"github.com/gin-gonic/gin"
 )
 
 type HookHandler struct {
-	streamSvc      *service.StreamService
-	storageManager *storage.Manager
+	streamSvc    *service.StreamService
+	nodeSvc      *service.NodeService
+	recordingSvc *service.RecordingService
+	hookAuthSvc  *service.HookAuthService
 }
 
-func NewHookHandler(streamSvc *service.StreamService, storageManager *storage.Manager) *HookHandler {
+func NewHookHandler(streamSvc *service.StreamService, nodeSvc *service.NodeService, recordingSvc *service.RecordingService, hookAuthSvc *service.HookAuthService) *HookHandler {
 	return &HookHandler{
-		streamSvc:      streamSvc,
-		storageManager: storageManager,
+		streamSvc:    streamSvc,
+		nodeSvc:      nodeSvc,
+		recordingSvc: recordingSvc,
+		hookAuthSvc:  hookAuthSvc,
 	}
 }
 
+// Authenticate Hook 回调认证中间件
+// 校验来源 IP、共享密钥或 HMAC 签名、mediaServerId，失败时记录日志和指标并返回 401
+func (h *HookHandler) Authenticate(c *gin.Context) {
+	hook := path.Base(c.Request.URL.Path)
+
+	body, err := io.ReadAll(c.Request.Body)
+	if err != nil {
+		metrics.HookCalls.Inc(hook, metrics.ResultError)
+		c.AbortWithStatusJSON(http.StatusBadRequest, model.HookResponse{Code: -1, Msg: "failed to read body"})
+		return
+	}
+	c.Request.Body = io.NopCloser(bytes.NewReader(body))
+
+	err = h.hookAuthSvc.Verify(&service.HookAuthRequest{
+		Path:      c.Request.URL.Path,
+		RemoteIP:  c.RemoteIP(),
+		Token:     c.Query("token"),
+		Timestamp: c.GetHeader("X-Hook-Timestamp"),
+		Signature: c.GetHeader("X-Hook-Signature"),
+		Body:      body,
+	})
+	if err == nil {
+		c.Next()
+		return
+	}
+
+	reason := "error"
+	switch err {
+	case service.ErrHookIPNotAllowed:
+		reason = "ip"
+	case service.ErrHookSecretInvalid:
+		reason = "secret"
+	case service.ErrHookSignatureExpired:
+		reason = "expired"
+	case service.ErrHookReplayed:
+		reason = "replayed"
+	case service.ErrHookUnknownServer:
+		reason = "server"
+	}
+	metrics.HookAuthFailures.Inc(hook, reason)
+	log.Printf("Warning: rejected hook %s from %s: %v", hook, c.RemoteIP(), err)
+	c.AbortWithStatusJSON(http.StatusUnauthorized, model.HookResponse{Code: -1, Msg: "unauthorized"})
+}
+
 // OnPublish 推流开始回调
 func (h *HookHandler) OnPublish(c *gin.Context) {
 	var req model.OnPublishRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
+		metrics.HookCalls.Inc("on_publish", metrics.ResultError)
 		c.JSON(http.StatusOK, model.HookResponse{Code: -1, Msg: err.Error()})
 		return
 	}
 
-	if err := h.streamSvc.OnPublish(&req); err != nil {
+	stream, err := h.streamSvc.OnPublish(&req)
+	if err != nil {
 		// 根据错误类型返回不同的错误信息
 		msg := "unknown error"
+		result := metrics.ResultRejected
 		switch err {
 		case service.ErrStreamNotFound:
 			msg = "stream not found"
 		case service.ErrStreamExpired:
 			msg = "stream expired"
+		case service.ErrPushSignMissing:
+			msg = "push signature missing"
+		case service.ErrPushSignExpired:
+			msg = "push signature expired"
+		case service.ErrPushSignInvalid:
+			msg = "push signature invalid"
+		case service.ErrDeviceMismatch:
+			msg = "device id mismatch"
+		case service.ErrIPNotAllowed:
+			msg = "publisher ip not allowed"
 		default:
 			msg = err.Error()
+			result = metrics.ResultError
 		}
 		// 返回 code=-1 会拒绝推流，ZLMediaKit 会断开连接
+		metrics.HookCalls.Inc("on_publish", result)
 		c.JSON(http.StatusOK, model.HookResponse{Code: -1, Msg: msg})
 		return
 	}
 
-	c.JSON(http.StatusOK, model.HookResponse{Code: 0, Msg: "success"})
+	// 媒体以 playback_id 注册，观众无需知道推流密钥
+	metrics.HookCalls.Inc("on_publish", metrics.ResultOK)
+	c.JSON(http.StatusOK, model.OnPublishResponse{Code: 0, Msg: "success", StreamReplace: stream.PlaybackID})
 }
 
 // OnUnpublish 推流结束回调
 func (h *HookHandler) OnUnpublish(c *gin.Context) {
 	var req model.OnUnpublishRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
+		metrics.HookCalls.Inc("on_unpublish", metrics.ResultError)
 		c.JSON(http.StatusOK, model.HookResponse{Code: 0, Msg: err.Error()})
 		return
 	}
 
-	h.streamSvc.OnUnpublish(&req)
+	observeHook("on_unpublish", h.streamSvc.OnUnpublish(&req))
 	c.JSON(http.StatusOK, model.HookResponse{Code: 0, Msg: "success"})
 }
 
@@ -65,11 +135,12 @@ func (h *HookHandler) OnUnpublish(c *gin.Context) {
 func (h *HookHandler) OnFlowReport(c *gin.Context) {
 	var req model.OnFlowReportRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
+		metrics.HookCalls.Inc("on_flow_report", metrics.ResultError)
 		c.JSON(http.StatusOK, model.HookResponse{Code: 0, Msg: err.Error()})
 		return
 	}
 
-	h.streamSvc.OnFlowReport(&req)
+	observeHook("on_flow_report", h.streamSvc.OnFlowReport(&req))
 	c.JSON(http.StatusOK, model.HookResponse{Code: 0, Msg: "success"})
 }
 
@@ -77,11 +148,13 @@ func (h *HookHandler) OnFlowReport(c *gin.Context) {
 func (h *HookHandler) OnStreamNoneReader(c *gin.Context) {
 	var req model.OnStreamNoneReaderRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
+		metrics.HookCalls.Inc("on_stream_none_reader", metrics.ResultError)
 		c.JSON(http.StatusOK, model.HookResponse{Code: 0, Msg: err.Error()})
 		return
 	}
 
 	// 返回 close: true 会关闭流
+	metrics.HookCalls.Inc("on_stream_none_reader", metrics.ResultOK)
 	c.JSON(http.StatusOK, gin.H{"code": 0, "close": false})
 }
 
@@ -89,11 +162,38 @@ func (h *HookHandler) OnStreamNoneReader(c *gin.Context) {
 func (h *HookHandler) OnPlay(c *gin.Context) {
 	var req model.OnPlayRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
+		metrics.HookCalls.Inc("on_play", metrics.ResultError)
 		c.JSON(http.StatusOK, model.HookResponse{Code: 0, Msg: err.Error()})
 		return
 	}
 
-	h.streamSvc.OnPlay(&req)
+	if err := h.streamSvc.OnPlay(&req); err != nil {
+		msg := ""
+		switch err {
+		case service.ErrStreamNotFound:
+			msg = "stream not found"
+		case service.ErrInvalidToken:
+			msg = "invalid access token"
+		case service.ErrPlaySignMissing:
+			msg = "play signature missing"
+		case service.ErrPlaySignExpired:
+			msg = "play signature expired"
+		case service.ErrPlaySignInvalid:
+			msg = "play signature invalid"
+		}
+		if msg != "" {
+			// 返回 code=-1 会拒绝播放
+			metrics.HookCalls.Inc("on_play", metrics.ResultRejected)
+			c.JSON(http.StatusOK, model.HookResponse{Code: -1, Msg: msg})
+			return
+		}
+		// 观看统计失败不影响播放
+		metrics.HookCalls.Inc("on_play", metrics.ResultError)
+		c.JSON(http.StatusOK, model.HookResponse{Code: 0, Msg: err.Error()})
+		return
+	}
+
+	metrics.HookCalls.Inc("on_play", metrics.ResultOK)
 	c.JSON(http.StatusOK, model.HookResponse{Code: 0, Msg: "success"})
 }
 
@@ -101,11 +201,12 @@ func (h *HookHandler) OnPlay(c *gin.Context) {
 func (h *HookHandler) OnPlayerDisconnect(c *gin.Context) {
 	var req model.OnPlayerDisconnectRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
+		metrics.HookCalls.Inc("on_player_disconnect", metrics.ResultError)
 		c.JSON(http.StatusOK, model.HookResponse{Code: 0, Msg: err.Error()})
 		return
 	}
 
-	h.streamSvc.OnPlayerDisconnect(&req)
+	observeHook("on_player_disconnect", h.streamSvc.OnPlayerDisconnect(&req))
 	c.JSON(http.StatusOK, model.HookResponse{Code: 0, Msg: "success"})
 }
 
@@ -113,23 +214,139 @@ func (h *HookHandler) OnPlayerDisconnect(c *gin.Context) {
 func (h *HookHandler) OnRecordMP4(c *gin.Context) {
 	var req model.OnRecordMP4Request
 	if err := c.ShouldBindJSON(&req); err != nil {
+		metrics.HookCalls.Inc("on_record_mp4", metrics.ResultError)
+		c.JSON(http.StatusOK, model.HookResponse{Code: 0, Msg: err.Error()})
+		return
+	}
+
+	// 登记录制文件，校验和计算与上传在后台进行
+	if err := h.recordingSvc.OnRecordMP4(&req); err != nil {
+		metrics.HookCalls.Inc("on_record_mp4", metrics.ResultError)
 		c.JSON(http.StatusOK, model.HookResponse{Code: 0, Msg: err.Error()})
 		return
 	}
 
-	// 记录录制文件到数据库
-	if err := h.streamSvc.AddRecordFile(req.Stream, req.FilePath); err != nil {
+	metrics.HookCalls.Inc("on_record_mp4", metrics.ResultOK)
+	c.JSON(http.StatusOK, model.HookResponse{Code: 0, Msg: "success"})
+}
+
+// OnRecordTS TS 切片录制完成回调，登记 HLS 录制切片（录制完成后通过上传队列上传）
+func (h *HookHandler) OnRecordTS(c *gin.Context) {
+	var req model.OnRecordTSRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		metrics.HookCalls.Inc("on_record_ts", metrics.ResultError)
 		c.JSON(http.StatusOK, model.HookResponse{Code: 0, Msg: err.Error()})
 		return
 	}
 
-	// 上传到所有启用的存储
-	if h.storageManager != nil && h.storageManager.HasStorages() {
-		go func() {
-			remotePath := req.FileName
-			h.storageManager.UploadToAll(c.Request.Context(), req.FilePath, remotePath)
-		}()
+	if err := h.recordingSvc.OnRecordTS(&req); err != nil {
+		log.Printf("Failed to save hls segment %s: %v", req.FilePath, err)
 	}
 
+	metrics.HookCalls.Inc("on_record_ts", metrics.ResultOK)
 	c.JSON(http.StatusOK, model.HookResponse{Code: 0, Msg: "success"})
 }
+
+// OnHTTPAccess 访问 HTTP 文件服务器回调，私有直播的录制文件需要播放签名
+func (h *HookHandler) OnHTTPAccess(c *gin.Context) {
+	var req model.OnHTTPAccessRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		metrics.HookCalls.Inc("on_http_access", metrics.ResultError)
+		c.JSON(http.StatusOK, model.OnHTTPAccessResponse{Code: 0, Err: err.Error()})
+		return
+	}
+
+	if err := h.streamSvc.AuthorizeHTTPAccess(&req); err != nil {
+		msg := err.Error()
+		result := metrics.ResultError
+		switch err {
+		case service.ErrPlaySignMissing:
+			msg, result = "play signature missing", metrics.ResultRejected
+		case service.ErrPlaySignExpired:
+			msg, result = "play signature expired", metrics.ResultRejected
+		case service.ErrPlaySignInvalid:
+			msg, result = "play signature invalid", metrics.ResultRejected
+		}
+		// err 不为空会拒绝访问
+		metrics.HookCalls.Inc("on_http_access", result)
+		c.JSON(http.StatusOK, model.OnHTTPAccessResponse{Code: 0, Err: msg})
+		return
+	}
+
+	metrics.HookCalls.Inc("on_http_access", metrics.ResultOK)
+	c.JSON(http.StatusOK, model.OnHTTPAccessResponse{Code: 0})
+}
+
+// OnStreamChanged 流注册/注销回调
+func (h *HookHandler) OnStreamChanged(c *gin.Context) {
+	var req model.OnStreamChangedRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		metrics.HookCalls.Inc("on_stream_changed", metrics.ResultError)
+		c.JSON(http.StatusOK, model.HookResponse{Code: 0, Msg: err.Error()})
+		return
+	}
+
+	observeHook("on_stream_changed", h.streamSvc.OnStreamChanged(&req))
+	c.JSON(http.StatusOK, model.HookResponse{Code: 0, Msg: "success"})
+}
+
+// OnStreamNotFound 播放的流不存在回调
+func (h *HookHandler) OnStreamNotFound(c *gin.Context) {
+	var req model.OnStreamNotFoundRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		metrics.HookCalls.Inc("on_stream_not_found", metrics.ResultError)
+		c.JSON(http.StatusOK, model.HookResponse{Code: 0, Msg: err.Error()})
+		return
+	}
+
+	observeHook("on_stream_not_found", h.streamSvc.OnStreamNotFound(&req))
+	c.JSON(http.StatusOK, model.HookResponse{Code: 0, Msg: "success"})
+}
+
+// OnServerStarted 服务器启动回调
+func (h *HookHandler) OnServerStarted(c *gin.Context) {
+	var req model.OnServerStartedRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		metrics.HookCalls.Inc("on_server_started", metrics.ResultError)
+		c.JSON(http.StatusOK, model.HookResponse{Code: 0, Msg: err.Error()})
+		return
+	}
+
+	observeHook("on_server_started", h.nodeSvc.OnServerStarted(&req))
+	c.JSON(http.StatusOK, model.HookResponse{Code: 0, Msg: "success"})
+}
+
+// OnServerKeepalive 服务器心跳回调
+func (h *HookHandler) OnServerKeepalive(c *gin.Context) {
+	var req model.OnServerKeepaliveRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		metrics.HookCalls.Inc("on_server_keepalive", metrics.ResultError)
+		c.JSON(http.StatusOK, model.HookResponse{Code: 0, Msg: err.Error()})
+		return
+	}
+
+	observeHook("on_server_keepalive", h.nodeSvc.OnServerKeepalive(&req))
+	c.JSON(http.StatusOK, model.HookResponse{Code: 0, Msg: "success"})
+}
+
+// OnRTPServerTimeout RTP 服务器收流超时回调
+func (h *HookHandler) OnRTPServerTimeout(c *gin.Context) {
+	var req model.OnRTPServerTimeoutRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		metrics.HookCalls.Inc("on_rtp_server_timeout", metrics.ResultError)
+		c.JSON(http.StatusOK, model.HookResponse{Code: 0, Msg: err.Error()})
+		return
+	}
+
+	observeHook("on_rtp_server_timeout", h.nodeSvc.OnRTPServerTimeout(&req))
+	c.JSON(http.StatusOK, model.HookResponse{Code: 0, Msg: "success"})
+}
+
+// observeHook 按处理结果记录 Hook 回调次数
+func observeHook(hook string, err error) {
+	if err != nil {
+		metrics.HookCalls.Inc(hook, metrics.ResultError)
+		return
+	}
+	metrics.HookCalls.Inc(hook, metrics.ResultOK)
+}
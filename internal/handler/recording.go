@@ -0,0 +1,148 @@
+package handler
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"easy-stream/internal/model"
+	"easy-stream/internal/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+type RecordingHandler struct {
+	recordingSvc *service.RecordingService
+}
+
+func NewRecordingHandler(recordingSvc *service.RecordingService) *RecordingHandler {
+	return &RecordingHandler{recordingSvc: recordingSvc}
+}
+
+// List 获取直播的录制文件列表（管理员）
+// 支持按录制格式（format）、状态（status）和录制开始时间范围（from/to，RFC3339）筛选
+func (h *RecordingHandler) List(c *gin.Context) {
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	pageSize, _ := strconv.Atoi(c.DefaultQuery("pageSize", "20"))
+
+	req := &model.RecordingListRequest{
+		Format:   c.Query("format"),
+		Status:   c.Query("status"),
+		Page:     page,
+		PageSize: pageSize,
+	}
+	if v := c.Query("from"); v != "" {
+		from, err := time.Parse(time.RFC3339, v)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid from, expected RFC3339"})
+			return
+		}
+		req.From = &from
+	}
+	if v := c.Query("to"); v != "" {
+		to, err := time.Parse(time.RFC3339, v)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid to, expected RFC3339"})
+			return
+		}
+		req.To = &to
+	}
+
+	if err := h.recordingSvc.AuthorizeStream(c.Param("key"), c.GetInt64("user_id"), c.GetString("role")); err != nil {
+		h.respondError(c, err)
+		return
+	}
+
+	resp, err := h.recordingSvc.List(c.Param("key"), req)
+	if err != nil {
+		h.respondError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, resp)
+}
+
+// Get 获取录制文件详情（管理员）
+func (h *RecordingHandler) Get(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
+		return
+	}
+
+	if err := h.recordingSvc.AuthorizeStream(c.Param("key"), c.GetInt64("user_id"), c.GetString("role")); err != nil {
+		h.respondError(c, err)
+		return
+	}
+
+	rec, err := h.recordingSvc.Get(c.Param("key"), id)
+	if err != nil {
+		h.respondError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, rec)
+}
+
+// Update 更新录制文件（管理员），收藏的录制不受保留策略影响
+func (h *RecordingHandler) Update(c *gin.Context) {
+	key := c.Param("key")
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
+		return
+	}
+	var req model.UpdateRecordingRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err := h.recordingSvc.AuthorizeStream(key, c.GetInt64("user_id"), c.GetString("role")); err != nil {
+		h.respondError(c, err)
+		return
+	}
+
+	rec, err := h.recordingSvc.Update(key, id, &req, c.GetInt64("user_id"))
+	if err != nil {
+		h.respondError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, rec)
+}
+
+// Delete 删除录制文件（管理员）
+func (h *RecordingHandler) Delete(c *gin.Context) {
+	key := c.Param("key")
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
+		return
+	}
+	if err := h.recordingSvc.AuthorizeStream(key, c.GetInt64("user_id"), c.GetString("role")); err != nil {
+		h.respondError(c, err)
+		return
+	}
+
+	if err := h.recordingSvc.Delete(key, id, c.GetInt64("user_id")); err != nil {
+		h.respondError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "recording deleted"})
+}
+
+func (h *RecordingHandler) respondError(c *gin.Context, err error) {
+	switch err {
+	case service.ErrStreamNotFound:
+		c.JSON(http.StatusNotFound, gin.H{"error": "stream not found"})
+	case service.ErrRecordingNotFound:
+		c.JSON(http.StatusNotFound, gin.H{"error": "recording not found"})
+	case service.ErrForbidden:
+		c.JSON(http.StatusForbidden, gin.H{"error": "permission denied"})
+	case service.ErrInvalidTimeRange:
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid time range"})
+	case service.ErrRecordingInProgress:
+		c.JSON(http.StatusConflict, gin.H{"error": "recording is still in progress"})
+	case service.ErrRecordingUploading:
+		c.JSON(http.StatusConflict, gin.H{"error": "recording upload is in progress"})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
+}
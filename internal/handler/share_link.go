@@ -21,6 +21,9 @@ func NewShareLinkHandler(shareLinkSvc *service.ShareLinkService) *ShareLinkHandl
 // Create 创建分享链接（管理员）
 func (h *ShareLinkHandler) Create(c *gin.Context) {
 	key := c.Param("key")
+	if !h.authorizeStream(c, key) {
+		return
+	}
 	var req model.CreateShareLinkRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
@@ -46,6 +49,9 @@ func (h *ShareLinkHandler) Create(c *gin.Context) {
 // List 获取直播的所有分享链接（管理员）
 func (h *ShareLinkHandler) List(c *gin.Context) {
 	key := c.Param("key")
+	if !h.authorizeStream(c, key) {
+		return
+	}
 	resp, err := h.shareLinkSvc.List(key)
 	if err != nil {
 		if err == service.ErrStreamNotFound {
@@ -88,6 +94,9 @@ func (h *ShareLinkHandler) UpdateMaxUses(c *gin.Context) {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
 		return
 	}
+	if !h.authorize(c, id) {
+		return
+	}
 
 	var req struct {
 		MaxUses int `json:"max_uses"`
@@ -97,7 +106,7 @@ func (h *ShareLinkHandler) UpdateMaxUses(c *gin.Context) {
 		return
 	}
 
-	link, err := h.shareLinkSvc.UpdateMaxUses(id, req.MaxUses)
+	link, err := h.shareLinkSvc.UpdateMaxUses(id, req.MaxUses, c.GetInt64("user_id"))
 	if err != nil {
 		if err == service.ErrShareLinkNotFound {
 			c.JSON(http.StatusNotFound, gin.H{"error": "share link not found"})
@@ -117,8 +126,11 @@ func (h *ShareLinkHandler) Delete(c *gin.Context) {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
 		return
 	}
+	if !h.authorize(c, id) {
+		return
+	}
 
-	if err := h.shareLinkSvc.Delete(id); err != nil {
+	if err := h.shareLinkSvc.Delete(id, c.GetInt64("user_id")); err != nil {
 		if err == service.ErrShareLinkNotFound {
 			c.JSON(http.StatusNotFound, gin.H{"error": "share link not found"})
 			return
@@ -128,3 +140,29 @@ func (h *ShareLinkHandler) Delete(c *gin.Context) {
 	}
 	c.JSON(http.StatusOK, gin.H{"message": "share link deleted"})
 }
+
+// authorizeStream 校验当前用户能否管理该直播的分享链接，无权限时写入响应并返回 false
+func (h *ShareLinkHandler) authorizeStream(c *gin.Context, key string) bool {
+	return h.respondAuthorize(c, h.shareLinkSvc.AuthorizeStream(key, c.GetInt64("user_id"), c.GetString("role")))
+}
+
+// authorize 校验当前用户能否管理该分享链接，无权限时写入响应并返回 false
+func (h *ShareLinkHandler) authorize(c *gin.Context, linkID int64) bool {
+	return h.respondAuthorize(c, h.shareLinkSvc.Authorize(linkID, c.GetInt64("user_id"), c.GetString("role")))
+}
+
+func (h *ShareLinkHandler) respondAuthorize(c *gin.Context, err error) bool {
+	switch err {
+	case nil:
+		return true
+	case service.ErrStreamNotFound:
+		c.JSON(http.StatusNotFound, gin.H{"error": "stream not found"})
+	case service.ErrShareLinkNotFound:
+		c.JSON(http.StatusNotFound, gin.H{"error": "share link not found"})
+	case service.ErrForbidden:
+		c.JSON(http.StatusForbidden, gin.H{"error": "permission denied"})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
+	return false
+}
@@ -1,6 +1,7 @@
 package handler
 
 import (
+	"io"
 	"net/http"
 	"strconv"
 
@@ -27,8 +28,8 @@ func (h *StreamHandler) List(c *gin.Context) {
 	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
 	pageSize, _ := strconv.Atoi(c.DefaultQuery("pageSize", "20"))
 
-	// 检查用户是否已登录
-	_, isLoggedIn := c.Get("user_id")
+	// 有查看直播权限的用户可以看到所有直播，其他用户（含没有角色的旧 Token）与游客相同
+	canViewAll := model.HasPermission(c.GetString("role"), model.PermStreamView)
 
 	req := &model.StreamListRequest{
 		Status:     status,
@@ -38,26 +39,35 @@ func (h *StreamHandler) List(c *gin.Context) {
 		PageSize:   pageSize,
 	}
 
-	resp, err := h.streamSvc.List(req, isLoggedIn, accessToken)
+	resp, err := h.streamSvc.List(req, canViewAll, accessToken)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
 
-	// 游客返回不含 stream_key 的视图
-	if !isLoggedIn {
+	// 没有推流凭证权限的用户返回不含 stream_key 的视图
+	if !model.HasPermission(c.GetString("role"), model.PermStreamCreds) {
 		publicResp := &model.StreamPublicListResponse{
 			Total:   resp.Total,
 			Streams: make([]*model.StreamPublicView, len(resp.Streams)),
 		}
 		for i, stream := range resp.Streams {
-			publicResp.Streams[i] = stream.ToPublicView()
+			publicResp.Streams[i] = h.streamSvc.PublicView(stream)
 		}
 		c.JSON(http.StatusOK, publicResp)
 		return
 	}
 
-	c.JSON(http.StatusOK, resp)
+	// 操作员只返回自己创建的直播的完整信息，其他直播返回游客视图
+	streams := make([]interface{}, len(resp.Streams))
+	for i, stream := range resp.Streams {
+		if h.canViewFull(c, stream) {
+			streams[i] = stream
+		} else {
+			streams[i] = h.streamSvc.PublicView(stream)
+		}
+	}
+	c.JSON(http.StatusOK, gin.H{"total": resp.Total, "streams": streams})
 }
 
 // Create 创建推流码（管理员）
@@ -71,6 +81,10 @@ func (h *StreamHandler) Create(c *gin.Context) {
 	userID := c.GetInt64("user_id")
 	stream, err := h.streamSvc.Create(&req, userID)
 	if err != nil {
+		if err == service.ErrInvalidCIDR {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid allowed_cidrs entry, expected IP or CIDR"})
+			return
+		}
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
@@ -87,6 +101,9 @@ func (h *StreamHandler) Get(c *gin.Context) {
 		c.JSON(http.StatusForbidden, gin.H{"error": "admin access required"})
 		return
 	}
+	if !h.authorize(c, key) {
+		return
+	}
 
 	stream, err := h.streamSvc.Get(key, true, "")
 	if err != nil {
@@ -97,7 +114,7 @@ func (h *StreamHandler) Get(c *gin.Context) {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
-	c.JSON(http.StatusOK, stream)
+	h.respondStream(c, stream)
 }
 
 // GetByID 通过 ID 获取推流详情（管理员）
@@ -118,7 +135,11 @@ func (h *StreamHandler) GetByID(c *gin.Context) {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
-	c.JSON(http.StatusOK, stream)
+	if !model.CanManageStream(c.GetString("role"), c.GetInt64("user_id"), stream.CreatedBy) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "permission denied"})
+		return
+	}
+	h.respondStream(c, stream)
 }
 
 // GetByIDPublic 通过 ID 获取推流详情（游客，不含 stream_key）
@@ -130,11 +151,6 @@ func (h *StreamHandler) GetByIDPublic(c *gin.Context) {
 		return
 	}
 
-	accessToken := c.Query("access_token")
-
-	// 检查用户是否已登录
-	_, isLoggedIn := c.Get("user_id")
-
 	stream, err := h.streamSvc.GetByID(id)
 	if err != nil {
 		if err == service.ErrStreamNotFound {
@@ -145,28 +161,19 @@ func (h *StreamHandler) GetByIDPublic(c *gin.Context) {
 		return
 	}
 
-	// 管理员返回完整信息
-	if isLoggedIn {
+	// 有推流凭证权限且可以管理该直播的用户返回完整信息
+	if h.canViewFull(c, stream) {
 		c.JSON(http.StatusOK, stream)
 		return
 	}
 
-	// 游客访问：公开直播可以直接看，私有直播需要 access_token
-	if stream.Visibility == model.StreamVisibilityPrivate {
-		if accessToken == "" {
-			c.JSON(http.StatusForbidden, gin.H{"error": "private stream requires access token"})
-			return
-		}
-		// 验证 access_token
-		valid, err := h.streamSvc.VerifyAccessToken(stream.StreamKey, accessToken)
-		if err != nil || !valid {
-			c.JSON(http.StatusForbidden, gin.H{"error": "invalid access token"})
-			return
-		}
+	// 其他用户与观看直播的权限相同：公开直播可以直接看，私有直播游客需要 access_token
+	if !h.checkPlayAccess(c, stream) {
+		return
 	}
 
 	// 游客返回不含 stream_key 的视图
-	c.JSON(http.StatusOK, stream.ToPublicView())
+	c.JSON(http.StatusOK, h.streamSvc.PublicView(stream))
 }
 
 // VerifyShareCode 验证分享码（游客）
@@ -197,6 +204,9 @@ func (h *StreamHandler) VerifyShareCode(c *gin.Context) {
 // AddShareCode 为直播添加分享码（管理员）
 func (h *StreamHandler) AddShareCode(c *gin.Context) {
 	key := c.Param("key")
+	if !h.authorize(c, key) {
+		return
+	}
 	var req model.RegenerateShareCodeRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
@@ -208,7 +218,7 @@ func (h *StreamHandler) AddShareCode(c *gin.Context) {
 		maxUses = *req.MaxUses
 	}
 
-	stream, err := h.streamSvc.AddShareCode(key, maxUses)
+	stream, err := h.streamSvc.AddShareCode(key, maxUses, c.GetInt64("user_id"))
 	if err != nil {
 		switch err {
 		case service.ErrStreamNotFound:
@@ -226,13 +236,16 @@ func (h *StreamHandler) AddShareCode(c *gin.Context) {
 // RegenerateShareCode 重新生成分享码（管理员）
 func (h *StreamHandler) RegenerateShareCode(c *gin.Context) {
 	key := c.Param("key")
+	if !h.authorize(c, key) {
+		return
+	}
 	var req model.RegenerateShareCodeRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
 
-	stream, err := h.streamSvc.RegenerateShareCode(key, &req)
+	stream, err := h.streamSvc.RegenerateShareCode(key, &req, c.GetInt64("user_id"))
 	if err != nil {
 		switch err {
 		case service.ErrStreamNotFound:
@@ -250,6 +263,9 @@ func (h *StreamHandler) RegenerateShareCode(c *gin.Context) {
 // UpdateShareCodeMaxUses 更新分享码最大使用次数（管理员）
 func (h *StreamHandler) UpdateShareCodeMaxUses(c *gin.Context) {
 	key := c.Param("key")
+	if !h.authorize(c, key) {
+		return
+	}
 	var req struct {
 		MaxUses int `json:"max_uses"`
 	}
@@ -258,7 +274,7 @@ func (h *StreamHandler) UpdateShareCodeMaxUses(c *gin.Context) {
 		return
 	}
 
-	stream, err := h.streamSvc.UpdateShareCodeMaxUses(key, req.MaxUses)
+	stream, err := h.streamSvc.UpdateShareCodeMaxUses(key, req.MaxUses, c.GetInt64("user_id"))
 	if err != nil {
 		switch err {
 		case service.ErrStreamNotFound:
@@ -276,7 +292,10 @@ func (h *StreamHandler) UpdateShareCodeMaxUses(c *gin.Context) {
 // DeleteShareCode 删除分享码（管理员）
 func (h *StreamHandler) DeleteShareCode(c *gin.Context) {
 	key := c.Param("key")
-	if err := h.streamSvc.DeleteShareCode(key); err != nil {
+	if !h.authorize(c, key) {
+		return
+	}
+	if err := h.streamSvc.DeleteShareCode(key, c.GetInt64("user_id")); err != nil {
 		if err == service.ErrStreamNotFound {
 			c.JSON(http.StatusNotFound, gin.H{"error": "stream not found"})
 			return
@@ -290,17 +309,24 @@ func (h *StreamHandler) DeleteShareCode(c *gin.Context) {
 // Update 更新推流信息（管理员）
 func (h *StreamHandler) Update(c *gin.Context) {
 	key := c.Param("key")
+	if !h.authorize(c, key) {
+		return
+	}
 	var req model.UpdateStreamRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
 
-	stream, err := h.streamSvc.Update(key, &req)
+	stream, err := h.streamSvc.Update(key, &req, c.GetInt64("user_id"))
 	if err != nil {
-		if err == service.ErrStreamNotFound {
+		switch err {
+		case service.ErrStreamNotFound:
 			c.JSON(http.StatusNotFound, gin.H{"error": "stream not found"})
 			return
+		case service.ErrInvalidCIDR:
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid allowed_cidrs entry, expected IP or CIDR"})
+			return
 		}
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
@@ -311,7 +337,11 @@ func (h *StreamHandler) Update(c *gin.Context) {
 // Delete 删除推流码（管理员）
 func (h *StreamHandler) Delete(c *gin.Context) {
 	key := c.Param("key")
-	if err := h.streamSvc.Delete(key); err != nil {
+	if err := h.streamSvc.Delete(key, c.GetInt64("user_id")); err != nil {
+		if err == service.ErrStreamNotFound {
+			c.JSON(http.StatusNotFound, gin.H{"error": "stream not found"})
+			return
+		}
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
@@ -321,7 +351,10 @@ func (h *StreamHandler) Delete(c *gin.Context) {
 // Kick 强制断流（管理员）- 只断开推流，不结束直播
 func (h *StreamHandler) Kick(c *gin.Context) {
 	key := c.Param("key")
-	if err := h.streamSvc.Kick(key); err != nil {
+	if !h.authorize(c, key) {
+		return
+	}
+	if err := h.streamSvc.Kick(key, c.GetInt64("user_id")); err != nil {
 		if err == service.ErrStreamNotFound {
 			c.JSON(http.StatusNotFound, gin.H{"error": "stream not found"})
 			return
@@ -335,7 +368,10 @@ func (h *StreamHandler) Kick(c *gin.Context) {
 // End 手动结束直播（管理员）- 断流并标记为已结束
 func (h *StreamHandler) End(c *gin.Context) {
 	key := c.Param("key")
-	if err := h.streamSvc.End(key); err != nil {
+	if !h.authorize(c, key) {
+		return
+	}
+	if err := h.streamSvc.End(key, c.GetInt64("user_id")); err != nil {
 		if err == service.ErrStreamNotFound {
 			c.JSON(http.StatusNotFound, gin.H{"error": "stream not found"})
 			return
@@ -345,3 +381,125 @@ func (h *StreamHandler) End(c *gin.Context) {
 	}
 	c.JSON(http.StatusOK, gin.H{"message": "stream ended"})
 }
+
+// GetPushURLs 获取推流地址（管理员）- 开启推流鉴权时返回带签名的地址
+func (h *StreamHandler) GetPushURLs(c *gin.Context) {
+	key := c.Param("key")
+	if !h.authorize(c, key) {
+		return
+	}
+	ttl, _ := strconv.Atoi(c.Query("ttl"))
+
+	resp, err := h.streamSvc.GetPushURLs(key, ttl)
+	if err != nil {
+		if err == service.ErrStreamNotFound {
+			c.JSON(http.StatusNotFound, gin.H{"error": "stream not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, resp)
+}
+
+// ResetPushSecret 重新生成推流密钥（管理员）- 已签发的推流地址全部失效
+func (h *StreamHandler) ResetPushSecret(c *gin.Context) {
+	key := c.Param("key")
+	if !h.authorize(c, key) {
+		return
+	}
+
+	resp, err := h.streamSvc.ResetPushSecret(key, c.GetInt64("user_id"))
+	if err != nil {
+		if err == service.ErrStreamNotFound {
+			c.JSON(http.StatusNotFound, gin.H{"error": "stream not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, resp)
+}
+
+// RotateStreamKey 更换推流码（管理员）- 宽限期后旧推流码失效，可选断开当前推流
+func (h *StreamHandler) RotateStreamKey(c *gin.Context) {
+	key := c.Param("key")
+	if !h.authorize(c, key) {
+		return
+	}
+
+	// 请求体可选
+	var req model.RotateStreamKeyRequest
+	if err := c.ShouldBindJSON(&req); err != nil && err != io.EOF {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if req.GracePeriod != nil && *req.GracePeriod < 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "grace_period must not be negative"})
+		return
+	}
+
+	resp, err := h.streamSvc.RotateStreamKey(key, &req, c.GetInt64("user_id"))
+	if err != nil {
+		switch err {
+		case service.ErrStreamNotFound:
+			c.JSON(http.StatusNotFound, gin.H{"error": "stream not found"})
+		case service.ErrStreamEnded:
+			c.JSON(http.StatusBadRequest, gin.H{"error": "stream has ended"})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		}
+		return
+	}
+	c.JSON(http.StatusOK, resp)
+}
+
+// RotatePlaybackID 重新生成播放ID（管理员）- 已分发的播放地址全部失效
+func (h *StreamHandler) RotatePlaybackID(c *gin.Context) {
+	key := c.Param("key")
+	if !h.authorize(c, key) {
+		return
+	}
+
+	resp, err := h.streamSvc.RotatePlaybackID(key, c.GetInt64("user_id"))
+	if err != nil {
+		if err == service.ErrStreamNotFound {
+			c.JSON(http.StatusNotFound, gin.H{"error": "stream not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, resp)
+}
+
+// canViewFull 当前用户是否可以查看直播的完整信息（含 stream_key）：需要推流凭证权限，操作员只能查看自己创建的直播
+func (h *StreamHandler) canViewFull(c *gin.Context, stream *model.Stream) bool {
+	role := c.GetString("role")
+	return model.HasPermission(role, model.PermStreamCreds) && model.CanManageStream(role, c.GetInt64("user_id"), stream.CreatedBy)
+}
+
+// respondStream 返回直播详情：有推流凭证权限时返回完整信息，否则返回游客视图
+func (h *StreamHandler) respondStream(c *gin.Context, stream *model.Stream) {
+	if h.canViewFull(c, stream) {
+		c.JSON(http.StatusOK, stream)
+		return
+	}
+	c.JSON(http.StatusOK, h.streamSvc.PublicView(stream))
+}
+
+// authorize 校验当前用户对直播的操作权限（操作员只能操作自己创建的直播），无权限时写入响应并返回 false
+func (h *StreamHandler) authorize(c *gin.Context, key string) bool {
+	err := h.streamSvc.Authorize(key, c.GetInt64("user_id"), c.GetString("role"))
+	switch err {
+	case nil:
+		return true
+	case service.ErrStreamNotFound:
+		c.JSON(http.StatusNotFound, gin.H{"error": "stream not found"})
+	case service.ErrForbidden:
+		c.JSON(http.StatusForbidden, gin.H{"error": "permission denied"})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
+	return false
+}
@@ -0,0 +1,71 @@
+package handler
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"easy-stream/internal/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+type StreamMetricHandler struct {
+	streamMetricSvc *service.StreamMetricService
+}
+
+func NewStreamMetricHandler(streamMetricSvc *service.StreamMetricService) *StreamMetricHandler {
+	return &StreamMetricHandler{streamMetricSvc: streamMetricSvc}
+}
+
+// Query 查询直播健康指标时序数据（管理员）
+// from/to 为 RFC3339 时间，step 为时间桶长度（秒）
+func (h *StreamMetricHandler) Query(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
+		return
+	}
+
+	var from, to *time.Time
+	if v := c.Query("from"); v != "" {
+		t, err := time.Parse(time.RFC3339, v)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid from, expected RFC3339"})
+			return
+		}
+		from = &t
+	}
+	if v := c.Query("to"); v != "" {
+		t, err := time.Parse(time.RFC3339, v)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid to, expected RFC3339"})
+			return
+		}
+		to = &t
+	}
+	step := 0
+	if v := c.Query("step"); v != "" {
+		step, err = strconv.Atoi(v)
+		if err != nil || step <= 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid step"})
+			return
+		}
+	}
+
+	resp, err := h.streamMetricSvc.Query(id, c.GetInt64("user_id"), c.GetString("role"), from, to, step)
+	if err != nil {
+		switch err {
+		case service.ErrStreamNotFound:
+			c.JSON(http.StatusNotFound, gin.H{"error": "stream not found"})
+		case service.ErrForbidden:
+			c.JSON(http.StatusForbidden, gin.H{"error": "permission denied"})
+		case service.ErrInvalidTimeRange, service.ErrTooManyPoints:
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		}
+		return
+	}
+	c.JSON(http.StatusOK, resp)
+}
@@ -2,6 +2,7 @@ package handler
 
 import (
 	"net/http"
+	"time"
 
 	"easy-stream/internal/service"
 
@@ -10,11 +11,13 @@ import (
 
 type SystemHandler struct {
 	systemSvc *service.SystemService
+	statsSvc  *service.StatsService
 }
 
-func NewSystemHandler(systemSvc *service.SystemService) *SystemHandler {
+func NewSystemHandler(systemSvc *service.SystemService, statsSvc *service.StatsService) *SystemHandler {
 	return &SystemHandler{
 		systemSvc: systemSvc,
+		statsSvc:  statsSvc,
 	}
 }
 
@@ -37,10 +40,47 @@ func (h *SystemHandler) Health(c *gin.Context) {
 	c.JSON(statusCode, health)
 }
 
-// Stats 系统统计
+// Stats 系统实时统计（管理员）
+// 包括各状态直播数量、观看人数、今日峰值、录制和分享兑换累计、ZLMediaKit 侧统计
 func (h *SystemHandler) Stats(c *gin.Context) {
-	c.JSON(http.StatusOK, gin.H{
-		"online_streams": 0,
-		"total_streams":  0,
-	})
+	stats, err := h.statsSvc.Snapshot()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, stats)
+}
+
+// DailyStats 每日统计（管理员）
+// from/to 为日期（YYYY-MM-DD，包含两端），默认最近 7 天
+func (h *SystemHandler) DailyStats(c *gin.Context) {
+	var from, to *time.Time
+	if v := c.Query("from"); v != "" {
+		t, err := time.ParseInLocation("2006-01-02", v, time.Local)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid from, expected YYYY-MM-DD"})
+			return
+		}
+		from = &t
+	}
+	if v := c.Query("to"); v != "" {
+		t, err := time.ParseInLocation("2006-01-02", v, time.Local)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid to, expected YYYY-MM-DD"})
+			return
+		}
+		to = &t
+	}
+
+	resp, err := h.statsSvc.Daily(from, to)
+	if err != nil {
+		switch err {
+		case service.ErrInvalidTimeRange, service.ErrDateRangeTooLong:
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		}
+		return
+	}
+	c.JSON(http.StatusOK, resp)
 }
@@ -0,0 +1,61 @@
+package handler
+
+import (
+	"net/http"
+	"strconv"
+
+	"easy-stream/internal/model"
+	"easy-stream/internal/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+type UploadHandler struct {
+	uploadSvc *service.UploadService
+}
+
+func NewUploadHandler(uploadSvc *service.UploadService) *UploadHandler {
+	return &UploadHandler{uploadSvc: uploadSvc}
+}
+
+// List 查询录制文件上传队列（管理员）
+// 支持按 status、storage 筛选
+func (h *UploadHandler) List(c *gin.Context) {
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	pageSize, _ := strconv.Atoi(c.DefaultQuery("pageSize", "20"))
+
+	resp, err := h.uploadSvc.List(&model.UploadListRequest{
+		Status:   c.Query("status"),
+		Storage:  c.Query("storage"),
+		Page:     page,
+		PageSize: pageSize,
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, resp)
+}
+
+// Retry 重试失败的上传任务（管理员）
+func (h *UploadHandler) Retry(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
+		return
+	}
+
+	upload, err := h.uploadSvc.Retry(id, c.GetInt64("user_id"))
+	if err != nil {
+		switch err {
+		case service.ErrUploadNotFound:
+			c.JSON(http.StatusNotFound, gin.H{"error": "upload not found"})
+		case service.ErrUploadNotFailed:
+			c.JSON(http.StatusBadRequest, gin.H{"error": "only failed uploads can be retried"})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		}
+		return
+	}
+	c.JSON(http.StatusOK, upload)
+}
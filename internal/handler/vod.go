@@ -0,0 +1,141 @@
+package handler
+
+import (
+	"fmt"
+	"mime"
+	"net/http"
+	"net/url"
+	"strconv"
+
+	"easy-stream/internal/model"
+	"easy-stream/internal/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+// VODList 获取直播可以点播的录制（游客和管理员都可以使用）
+// 私有直播需要 access_token，返回的 m3u8 地址携带同一个 access_token
+func (h *StreamHandler) VODList(c *gin.Context) {
+	stream, ok := h.vodStream(c)
+	if !ok {
+		return
+	}
+
+	recordings, err := h.streamSvc.VODRecordings(stream)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	query := ""
+	if token := c.Query("access_token"); token != "" {
+		query = "?access_token=" + url.QueryEscape(token)
+	}
+	resp := &model.VODListResponse{Recordings: make([]*model.VODRecording, 0, len(recordings))}
+	for _, rec := range recordings {
+		resp.Recordings = append(resp.Recordings, &model.VODRecording{
+			ID:          rec.ID,
+			StartTime:   rec.StartTime,
+			EndTime:     rec.EndTime,
+			Duration:    rec.Duration,
+			PlaylistURL: fmt.Sprintf("/api/v1/streams/vod/%d/%d/index.m3u8%s", stream.ID, rec.ID, query),
+		})
+	}
+	c.JSON(http.StatusOK, resp)
+}
+
+// VODPlaylist 获取已完成 HLS 录制的点播 m3u8（游客和管理员都可以使用）
+// 切片地址指向 ZLMediaKit，私有直播的切片地址携带播放签名
+func (h *StreamHandler) VODPlaylist(c *gin.Context) {
+	recordingID, err := strconv.ParseInt(c.Param("recordingId"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid recording id"})
+		return
+	}
+	stream, ok := h.vodStream(c)
+	if !ok {
+		return
+	}
+
+	playlist, err := h.streamSvc.VODPlaylist(stream, recordingID)
+	if err != nil {
+		if err == service.ErrRecordingNotFound {
+			c.JSON(http.StatusNotFound, gin.H{"error": "recording not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.Header("Cache-Control", "no-store")
+	c.Data(http.StatusOK, "application/vnd.apple.mpegurl", []byte(playlist))
+}
+
+// DownloadRecording 下载录制文件（游客和管理员都可以使用），观看权限与直播相同
+// 已上传到 S3 兼容存储的录制重定向到带签名的临时地址，否则由服务端返回文件（支持 Range）
+func (h *StreamHandler) DownloadRecording(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
+		return
+	}
+
+	stream, rec, err := h.streamSvc.RecordingForDownload(id)
+	if err != nil {
+		switch err {
+		case service.ErrRecordingNotFound, service.ErrStreamNotFound:
+			c.JSON(http.StatusNotFound, gin.H{"error": "recording not found"})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		}
+		return
+	}
+	if !h.checkPlayAccess(c, stream) {
+		return
+	}
+
+	download, err := h.streamSvc.DownloadRecording(c.Request.Context(), rec)
+	if err != nil {
+		switch err {
+		case service.ErrRecordingNotDownloadable:
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		case service.ErrRecordingFileUnavailable:
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		}
+		return
+	}
+
+	c.Header("Cache-Control", "no-store")
+	if download.URL != "" {
+		c.Redirect(http.StatusFound, download.URL)
+		return
+	}
+	defer download.File.Close()
+	c.Header("Content-Type", "video/mp4")
+	c.Header("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{"filename": download.FileName}))
+	http.ServeContent(c.Writer, c.Request, download.FileName, download.ModTime, download.File)
+}
+
+// vodStream 获取点播请求的直播并检查观看权限，失败时写入错误响应
+func (h *StreamHandler) vodStream(c *gin.Context) (*model.Stream, bool) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
+		return nil, false
+	}
+
+	stream, err := h.streamSvc.GetByID(id)
+	if err != nil {
+		if err == service.ErrStreamNotFound {
+			c.JSON(http.StatusNotFound, gin.H{"error": "stream not found"})
+			return nil, false
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return nil, false
+	}
+	if !h.checkPlayAccess(c, stream) {
+		return nil, false
+	}
+	return stream, true
+}
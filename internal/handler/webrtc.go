@@ -39,11 +39,6 @@ func (h *StreamHandler) WebRTCPlay(c *gin.Context) {
 		return
 	}
 
-	accessToken := c.Query("access_token")
-
-	// 检查用户是否已登录
-	_, isLoggedIn := c.Get("user_id")
-
 	// 获取流信息
 	stream, err := h.streamSvc.GetByID(id)
 	if err != nil {
@@ -55,24 +50,12 @@ func (h *StreamHandler) WebRTCPlay(c *gin.Context) {
 		return
 	}
 
-	// 游客访问：公开直播可以直接看，私有直播需要 access_token
-	if !isLoggedIn {
-		if stream.Visibility == model.StreamVisibilityPrivate {
-			if accessToken == "" {
-				c.JSON(http.StatusForbidden, gin.H{"error": "private stream requires access token"})
-				return
-			}
-			// 验证 access_token
-			valid, err := h.streamSvc.VerifyAccessToken(stream.StreamKey, accessToken)
-			if err != nil || !valid {
-				c.JSON(http.StatusForbidden, gin.H{"error": "invalid access token"})
-				return
-			}
-		}
+	if !h.checkPlayAccess(c, stream) {
+		return
 	}
 
 	// 调用 ZLM WebRTC 播放接口
-	resp, err := h.streamSvc.WebRTCPlay(stream.StreamKey, req.SDP)
+	resp, err := h.streamSvc.WebRTCPlay(stream, req.SDP)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
@@ -90,11 +73,6 @@ func (h *StreamHandler) GetWebRTCSDP(c *gin.Context) {
 		return
 	}
 
-	accessToken := c.Query("access_token")
-
-	// 检查用户是否已登录
-	_, isLoggedIn := c.Get("user_id")
-
 	// 获取流信息
 	stream, err := h.streamSvc.GetByID(id)
 	if err != nil {
@@ -106,20 +84,8 @@ func (h *StreamHandler) GetWebRTCSDP(c *gin.Context) {
 		return
 	}
 
-	// 游客访问：公开直播可以直接看，私有直播需要 access_token
-	if !isLoggedIn {
-		if stream.Visibility == model.StreamVisibilityPrivate {
-			if accessToken == "" {
-				c.JSON(http.StatusForbidden, gin.H{"error": "private stream requires access token"})
-				return
-			}
-			// 验证 access_token
-			valid, err := h.streamSvc.VerifyAccessToken(stream.StreamKey, accessToken)
-			if err != nil || !valid {
-				c.JSON(http.StatusForbidden, gin.H{"error": "invalid access token"})
-				return
-			}
-		}
+	if !h.checkPlayAccess(c, stream) {
+		return
 	}
 
 	// 读取请求体中的 SDP offer
@@ -130,7 +96,7 @@ func (h *StreamHandler) GetWebRTCSDP(c *gin.Context) {
 	}
 
 	// 调用 ZLM WebRTC 播放接口
-	resp, err := h.streamSvc.WebRTCPlay(stream.StreamKey, string(body))
+	resp, err := h.streamSvc.WebRTCPlay(stream, string(body))
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
@@ -145,3 +111,53 @@ func (h *StreamHandler) GetWebRTCSDP(c *gin.Context) {
 
 	c.JSON(http.StatusOK, resp)
 }
+
+// PlayURLs 获取各协议播放地址（游客和管理员都可以使用）
+// 私有直播的地址携带播放签名，有效期见 play.signExpire
+func (h *StreamHandler) PlayURLs(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
+		return
+	}
+
+	stream, err := h.streamSvc.GetByID(id)
+	if err != nil {
+		if err == service.ErrStreamNotFound {
+			c.JSON(http.StatusNotFound, gin.H{"error": "stream not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	if !h.checkPlayAccess(c, stream) {
+		return
+	}
+
+	c.JSON(http.StatusOK, h.streamSvc.BuildPlayURLs(stream))
+}
+
+// checkPlayAccess 检查请求方是否可以观看直播，不可观看时写入 403 响应
+// 游客访问：公开直播可以直接看，私有直播需要 access_token；有查看直播权限的用户不限制
+func (h *StreamHandler) checkPlayAccess(c *gin.Context, stream *model.Stream) bool {
+	if model.HasPermission(c.GetString("role"), model.PermStreamView) {
+		return true
+	}
+	if stream.Visibility != model.StreamVisibilityPrivate {
+		return true
+	}
+
+	accessToken := c.Query("access_token")
+	if accessToken == "" {
+		c.JSON(http.StatusForbidden, gin.H{"error": "private stream requires access token"})
+		return false
+	}
+	// 验证 access_token
+	valid, err := h.streamSvc.VerifyAccessToken(stream.StreamKey, accessToken)
+	if err != nil || !valid {
+		c.JSON(http.StatusForbidden, gin.H{"error": "invalid access token"})
+		return false
+	}
+	return true
+}
@@ -0,0 +1,53 @@
+package metrics
+
+// Default 默认指标注册表，/metrics 接口输出该注册表中的指标
+var Default = NewRegistry()
+
+// 应用指标
+var (
+	// HTTPRequestDuration API 请求耗时，route 为路由模板（未匹配路由为 unmatched）
+	HTTPRequestDuration = Default.NewHistogramVec("easystream_http_request_duration_seconds",
+		"HTTP request latency in seconds.", nil, "method", "route", "status")
+
+	// HookCalls ZLMediaKit Hook 回调次数，result: ok / rejected / error
+	HookCalls = Default.NewCounterVec("easystream_hook_calls_total",
+		"ZLMediaKit hook calls by hook type and result.", "hook", "result")
+
+	// HookAuthFailures Hook 回调认证失败次数，reason: ip / secret / expired / replayed / server
+	HookAuthFailures = Default.NewCounterVec("easystream_hook_auth_failures_total",
+		"ZLMediaKit hook calls rejected by authentication, by hook type and reason.", "hook", "reason")
+
+	// ZLMRequestDuration ZLMediaKit API 调用耗时，result: ok / error
+	ZLMRequestDuration = Default.NewHistogramVec("easystream_zlm_request_duration_seconds",
+		"ZLMediaKit HTTP API latency in seconds.", nil, "api", "result")
+
+	// StorageUploads 录制文件上传次数，result: success / failure
+	StorageUploads = Default.NewCounterVec("easystream_storage_uploads_total",
+		"Recording uploads by storage target and result.", "storage", "result")
+
+	// StorageDeletes 存储目标上录制文件副本的删除次数，result: success / failure
+	StorageDeletes = Default.NewCounterVec("easystream_storage_deletes_total",
+		"Recording copies deleted from storage targets by storage target and result.", "storage", "result")
+
+	// ReconcileCorrections 直播状态校正次数，type 见 service.Correction* 常量
+	ReconcileCorrections = Default.NewCounterVec("easystream_reconcile_corrections_total",
+		"Stream state corrections made by the reconciler, by correction type.", "type")
+
+	// PushingStreams 正在推流的直播数量（抓取时刷新）
+	PushingStreams = Default.NewGaugeVec("easystream_pushing_streams",
+		"Number of streams currently pushing.")
+
+	// StreamViewers 每个正在推流的直播的当前观看人数（抓取时刷新）
+	// 只以直播 ID 作为标签，不暴露直播名称
+	StreamViewers = Default.NewGaugeVec("easystream_stream_viewers",
+		"Current viewers per pushing stream.", "stream_id")
+)
+
+// Hook 回调结果
+const (
+	ResultOK       = "ok"
+	ResultRejected = "rejected"
+	ResultError    = "error"
+	ResultSuccess  = "success"
+	ResultFailure  = "failure"
+)
@@ -0,0 +1,292 @@
+// Package metrics 提供 Prometheus 文本格式的监控指标（计数器、仪表盘、直方图）
+package metrics
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DefaultBuckets 默认直方图分桶（秒）
+var DefaultBuckets = []float64{0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10}
+
+// collector 可输出为 Prometheus 文本格式的指标
+type collector interface {
+	write(w io.Writer)
+}
+
+// Registry 指标注册表
+type Registry struct {
+	mu         sync.Mutex
+	collectors []collector
+	onScrape   []func()
+}
+
+// NewRegistry 创建指标注册表
+func NewRegistry() *Registry {
+	return &Registry{}
+}
+
+// OnScrape 注册抓取前执行的回调，用于在抓取时刷新需要实时计算的指标（如从数据库统计的仪表盘）
+func (r *Registry) OnScrape(fn func()) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.onScrape = append(r.onScrape, fn)
+}
+
+func (r *Registry) register(c collector) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.collectors = append(r.collectors, c)
+}
+
+// Write 以 Prometheus 文本格式输出所有指标
+func (r *Registry) Write(w io.Writer) {
+	r.mu.Lock()
+	onScrape := append([]func(){}, r.onScrape...)
+	collectors := append([]collector{}, r.collectors...)
+	r.mu.Unlock()
+
+	for _, fn := range onScrape {
+		fn()
+	}
+	for _, c := range collectors {
+		c.write(w)
+	}
+}
+
+// Handler 返回输出指标的 HTTP Handler
+func (r *Registry) Handler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+		r.Write(w)
+	})
+}
+
+// desc 指标描述
+type desc struct {
+	name   string
+	help   string
+	labels []string
+}
+
+func (d *desc) writeHeader(w io.Writer, typ string) {
+	fmt.Fprintf(w, "# HELP %s %s\n", d.name, d.help)
+	fmt.Fprintf(w, "# TYPE %s %s\n", d.name, typ)
+}
+
+// key 将标签值拼接为 map 的键
+func (d *desc) key(values []string) string {
+	if len(values) != len(d.labels) {
+		panic(fmt.Sprintf("metrics: %s expects %d label values, got %d", d.name, len(d.labels), len(values)))
+	}
+	return strings.Join(values, "\xff")
+}
+
+// labelPairs 生成 {a="1",b="2"} 形式的标签串，extra 为附加的标签（如直方图的 le）
+func (d *desc) labelPairs(values []string, extra ...string) string {
+	if len(d.labels) == 0 && len(extra) == 0 {
+		return ""
+	}
+	pairs := make([]string, 0, len(d.labels)+len(extra)/2)
+	for i, name := range d.labels {
+		pairs = append(pairs, name+`="`+escapeLabel(values[i])+`"`)
+	}
+	for i := 0; i+1 < len(extra); i += 2 {
+		pairs = append(pairs, extra[i]+`="`+escapeLabel(extra[i+1])+`"`)
+	}
+	return "{" + strings.Join(pairs, ",") + "}"
+}
+
+// series 单个标签组合的取值
+type series struct {
+	labels []string
+	value  float64
+}
+
+// CounterVec 带标签的计数器
+type CounterVec struct {
+	desc
+	mu     sync.Mutex
+	series map[string]*series
+}
+
+// NewCounterVec 创建并注册计数器
+func (r *Registry) NewCounterVec(name, help string, labels ...string) *CounterVec {
+	c := &CounterVec{desc: desc{name: name, help: help, labels: labels}, series: make(map[string]*series)}
+	r.register(c)
+	return c
+}
+
+// Inc 计数加 1
+func (c *CounterVec) Inc(labelValues ...string) {
+	c.Add(1, labelValues...)
+}
+
+// Add 计数增加 v（v 必须非负）
+func (c *CounterVec) Add(v float64, labelValues ...string) {
+	if v < 0 {
+		return
+	}
+	k := c.key(labelValues)
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	s, ok := c.series[k]
+	if !ok {
+		s = &series{labels: append([]string{}, labelValues...)}
+		c.series[k] = s
+	}
+	s.value += v
+}
+
+func (c *CounterVec) write(w io.Writer) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.writeHeader(w, "counter")
+	for _, k := range sortedKeys(c.series) {
+		s := c.series[k]
+		fmt.Fprintf(w, "%s%s %s\n", c.name, c.labelPairs(s.labels), formatFloat(s.value))
+	}
+}
+
+// GaugeVec 带标签的仪表盘
+type GaugeVec struct {
+	desc
+	mu     sync.Mutex
+	series map[string]*series
+}
+
+// NewGaugeVec 创建并注册仪表盘
+func (r *Registry) NewGaugeVec(name, help string, labels ...string) *GaugeVec {
+	g := &GaugeVec{desc: desc{name: name, help: help, labels: labels}, series: make(map[string]*series)}
+	r.register(g)
+	return g
+}
+
+// Set 设置取值
+func (g *GaugeVec) Set(v float64, labelValues ...string) {
+	k := g.key(labelValues)
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.series[k] = &series{labels: append([]string{}, labelValues...), value: v}
+}
+
+// Reset 清空所有标签组合（用于按当前状态整体刷新，避免保留已消失的标签组合）
+func (g *GaugeVec) Reset() {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.series = make(map[string]*series)
+}
+
+func (g *GaugeVec) write(w io.Writer) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.writeHeader(w, "gauge")
+	for _, k := range sortedKeys(g.series) {
+		s := g.series[k]
+		fmt.Fprintf(w, "%s%s %s\n", g.name, g.labelPairs(s.labels), formatFloat(s.value))
+	}
+}
+
+// histogramSeries 单个标签组合的直方图数据
+type histogramSeries struct {
+	labels []string
+	counts []uint64 // 与 buckets 一一对应（非累计）
+	count  uint64
+	sum    float64
+}
+
+// HistogramVec 带标签的直方图
+type HistogramVec struct {
+	desc
+	buckets []float64
+	mu      sync.Mutex
+	series  map[string]*histogramSeries
+}
+
+// NewHistogramVec 创建并注册直方图，buckets 为空时使用 DefaultBuckets
+func (r *Registry) NewHistogramVec(name, help string, buckets []float64, labels ...string) *HistogramVec {
+	if len(buckets) == 0 {
+		buckets = DefaultBuckets
+	}
+	b := append([]float64{}, buckets...)
+	sort.Float64s(b)
+	h := &HistogramVec{desc: desc{name: name, help: help, labels: labels}, buckets: b, series: make(map[string]*histogramSeries)}
+	r.register(h)
+	return h
+}
+
+// Observe 记录一次观测值
+func (h *HistogramVec) Observe(v float64, labelValues ...string) {
+	k := h.key(labelValues)
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	s, ok := h.series[k]
+	if !ok {
+		s = &histogramSeries{labels: append([]string{}, labelValues...), counts: make([]uint64, len(h.buckets))}
+		h.series[k] = s
+	}
+	for i, upper := range h.buckets {
+		if v <= upper {
+			s.counts[i]++
+			break
+		}
+	}
+	s.count++
+	s.sum += v
+}
+
+// ObserveDuration 记录从 start 到现在的耗时（秒）
+func (h *HistogramVec) ObserveDuration(start time.Time, labelValues ...string) {
+	h.Observe(time.Since(start).Seconds(), labelValues...)
+}
+
+func (h *HistogramVec) write(w io.Writer) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.writeHeader(w, "histogram")
+	for _, k := range sortedKeys(h.series) {
+		s := h.series[k]
+		var cumulative uint64
+		for i, upper := range h.buckets {
+			cumulative += s.counts[i]
+			fmt.Fprintf(w, "%s_bucket%s %d\n", h.name, h.labelPairs(s.labels, "le", formatFloat(upper)), cumulative)
+		}
+		fmt.Fprintf(w, "%s_bucket%s %d\n", h.name, h.labelPairs(s.labels, "le", "+Inf"), s.count)
+		fmt.Fprintf(w, "%s_sum%s %s\n", h.name, h.labelPairs(s.labels), formatFloat(s.sum))
+		fmt.Fprintf(w, "%s_count%s %d\n", h.name, h.labelPairs(s.labels), s.count)
+	}
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func formatFloat(v float64) string {
+	switch {
+	case math.IsInf(v, 1):
+		return "+Inf"
+	case math.IsInf(v, -1):
+		return "-Inf"
+	case math.IsNaN(v):
+		return "NaN"
+	}
+	return strconv.FormatFloat(v, 'g', -1, 64)
+}
+
+func escapeLabel(v string) string {
+	v = strings.ReplaceAll(v, `\`, `\\`)
+	v = strings.ReplaceAll(v, "\n", `\n`)
+	return strings.ReplaceAll(v, `"`, `\"`)
+}
@@ -43,8 +43,7 @@ func OptionalAuth(secret string) gin.HandlerFunc {
 		}
 
 		// 将用户信息存入上下文
-		c.Set("user_id", int64(claims["user_id"].(float64)))
-		c.Set("username", claims["username"].(string))
+		setClaims(c, claims)
 
 		c.Next()
 	}
@@ -89,9 +88,17 @@ func Auth(secret string) gin.HandlerFunc {
 		}
 
 		// 将用户信息存入上下文
-		c.Set("user_id", int64(claims["user_id"].(float64)))
-		c.Set("username", claims["username"].(string))
+		setClaims(c, claims)
 
 		c.Next()
 	}
 }
+
+// setClaims 将 JWT 中的用户信息存入上下文
+// 旧版本签发的 token 没有 role 字段，此时角色为空，不具备任何管理权限
+func setClaims(c *gin.Context, claims jwt.MapClaims) {
+	c.Set("user_id", int64(claims["user_id"].(float64)))
+	c.Set("username", claims["username"].(string))
+	role, _ := claims["role"].(string)
+	c.Set("role", role)
+}
@@ -1,8 +1,12 @@
 package middleware
 
 import (
+	"net/url"
+	"strconv"
 	"time"
 
+	"easy-stream/internal/metrics"
+
 	"github.com/gin-gonic/gin"
 	"go.uber.org/zap"
 )
@@ -15,13 +19,19 @@ func Logger() gin.HandlerFunc {
 	return func(c *gin.Context) {
 		start := time.Now()
 		path := c.Request.URL.Path
-		query := c.Request.URL.RawQuery
+		query := redactQuery(c.Request.URL.RawQuery)
 
 		c.Next()
 
 		latency := time.Since(start)
 		status := c.Writer.Status()
 
+		route := c.FullPath()
+		if route == "" {
+			route = "unmatched"
+		}
+		metrics.HTTPRequestDuration.Observe(latency.Seconds(), c.Request.Method, route, strconv.Itoa(status))
+
 		logger.Info("request",
 			zap.Int("status", status),
 			zap.String("method", c.Request.Method),
@@ -33,3 +43,28 @@ func Logger() gin.HandlerFunc {
 		)
 	}
 }
+
+// sensitiveParams 访问日志中需要脱敏的查询参数（Hook 共享密钥、私有直播访问令牌、播放签名）
+var sensitiveParams = []string{"token", "access_token", "secret", "sign"}
+
+// redactQuery 将查询参数中的密钥替换为 REDACTED，无法解析时整体隐藏
+func redactQuery(raw string) string {
+	if raw == "" {
+		return ""
+	}
+	values, err := url.ParseQuery(raw)
+	if err != nil {
+		return "REDACTED"
+	}
+	redacted := false
+	for _, key := range sensitiveParams {
+		if _, ok := values[key]; ok {
+			values.Set(key, "REDACTED")
+			redacted = true
+		}
+	}
+	if !redacted {
+		return raw
+	}
+	return values.Encode()
+}
@@ -0,0 +1,22 @@
+package middleware
+
+import (
+	"net/http"
+
+	"easy-stream/internal/model"
+
+	"github.com/gin-gonic/gin"
+)
+
+// RequirePermission 权限校验中间件（需在 Auth 之后使用）
+func RequirePermission(perm string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !model.HasPermission(c.GetString("role"), perm) {
+			c.JSON(http.StatusForbidden, gin.H{"error": "permission denied"})
+			c.Abort()
+			return
+		}
+
+		c.Next()
+	}
+}
@@ -32,6 +32,9 @@ type OnFlowReportRequest struct {
 	Player        bool   `json:"player"`
 	TotalBytesIn  int64  `json:"totalBytesIn"`
 	TotalBytesOut int64  `json:"totalBytesOut"`
+	IP            string `json:"ip"`   // 客户端 IP
+	Port          int    `json:"port"` // 客户端端口
+	ID            string `json:"id"`   // 会话唯一标识
 }
 
 // OnStreamNoneReaderRequest 无人观看回调
@@ -79,8 +82,99 @@ type OnRecordMP4Request struct {
 	URL        string  `json:"url"`         // 播放地址
 }
 
+// OnRecordTSRequest TS 切片录制完成回调（字段与 MP4 录制回调一致）
+type OnRecordTSRequest struct {
+	App        string  `json:"app"`
+	Stream     string  `json:"stream"`
+	MediaSrvID string  `json:"mediaServerId"`
+	FileName   string  `json:"file_name"`  // 文件名
+	FilePath   string  `json:"file_path"`  // 文件绝对路径
+	FileSize   int64   `json:"file_size"`  // 文件大小（字节）
+	Folder     string  `json:"folder"`     // 文件所在目录
+	StartTime  int64   `json:"start_time"` // 录制开始时间戳
+	TimeLen    float64 `json:"time_len"`   // 录制时长（秒）
+	URL        string  `json:"url"`        // 播放地址
+}
+
+// OnStreamChangedRequest 流注册/注销回调
+// ZLMediaKit 为每种协议（rtmp/rtsp/hls/ts/fmp4）分别回调
+type OnStreamChangedRequest struct {
+	Regist           bool   `json:"regist"` // true 注册，false 注销
+	App              string `json:"app"`
+	Stream           string `json:"stream"`
+	Schema           string `json:"schema"`
+	Vhost            string `json:"vhost"`
+	MediaSrvID       string `json:"mediaServerId"`
+	TotalReaderCount int    `json:"totalReaderCount"` // 观看人数（注册时有效）
+	OriginType       int    `json:"originType"`       // 产生源类型（注册时有效）
+	OriginURL        string `json:"originUrl"`        // 产生源地址（注册时有效）
+}
+
+// OnStreamNotFoundRequest 播放的流不存在回调
+type OnStreamNotFoundRequest struct {
+	App        string `json:"app"`
+	Stream     string `json:"stream"`
+	Schema     string `json:"schema"`
+	Vhost      string `json:"vhost"`
+	MediaSrvID string `json:"mediaServerId"`
+	IP         string `json:"ip"`
+	Port       int    `json:"port"`
+	Params     string `json:"params"`
+	ID         string `json:"id"` // 播放器唯一标识
+}
+
+// OnServerStartedRequest 服务器启动回调
+// 请求体为 ZLMediaKit 的全部配置项（如 general.mediaServerId），这里只解析需要的字段
+type OnServerStartedRequest struct {
+	MediaSrvID string `json:"mediaServerId"`
+	HookIndex  int64  `json:"hook_index"`
+}
+
+// OnServerKeepaliveRequest 服务器心跳回调
+type OnServerKeepaliveRequest struct {
+	MediaSrvID string                 `json:"mediaServerId"`
+	Data       map[string]interface{} `json:"data"` // 各类对象计数（MediaSource、TcpSession 等）
+}
+
+// OnRTPServerTimeoutRequest RTP 服务器收流超时回调
+type OnRTPServerTimeoutRequest struct {
+	MediaSrvID string `json:"mediaServerId"`
+	LocalPort  int    `json:"local_port"`  // RTP 服务器端口
+	ReUsePort  bool   `json:"re_use_port"` // 是否端口复用
+	SSRC       uint32 `json:"ssrc"`
+	StreamID   string `json:"stream_id"`
+	TCPMode    int    `json:"tcp_mode"` // 0 UDP，1 TCP 被动，2 TCP 主动
+}
+
 // HookResponse Hook 响应
 type HookResponse struct {
 	Code int    `json:"code"`
 	Msg  string `json:"msg"`
 }
+
+// OnPublishResponse 推流开始回调响应
+// stream_replace 将媒体注册为指定的流名称，之后的播放、回调和 API 调用都使用该名称
+type OnPublishResponse struct {
+	Code          int    `json:"code"`
+	Msg           string `json:"msg"`
+	StreamReplace string `json:"stream_replace,omitempty"`
+}
+
+// OnHTTPAccessRequest 访问 HTTP 文件服务器回调（HLS 直播之外的文件，如录制文件）
+type OnHTTPAccessRequest struct {
+	MediaSrvID string `json:"mediaServerId"`
+	ID         string `json:"id"`
+	IP         string `json:"ip"`
+	IsDir      bool   `json:"is_dir"`
+	Params     string `json:"params"` // URL 查询参数
+	Path       string `json:"path"`   // 请求路径，如 /record/live/{playback_id}/2024-01-01/10-00-00.ts
+	Port       int    `json:"port"`
+}
+
+// OnHTTPAccessResponse 访问 HTTP 文件服务器回调响应，err 为空表示允许访问
+type OnHTTPAccessResponse struct {
+	Code   int    `json:"code"`
+	Err    string `json:"err"`
+	Path   string `json:"path"`   // 授权适用的目录，为空表示仅当前文件
+	Second int    `json:"second"` // 授权有效期（秒）
+}
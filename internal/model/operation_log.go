@@ -0,0 +1,76 @@
+package model
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// OperationLog 操作日志（审计记录）
+type OperationLog struct {
+	ID         int64           `json:"id" db:"id"`
+	UserID     *int64          `json:"user_id" db:"user_id"` // 操作用户ID（系统自动操作为空）
+	Username   *string         `json:"username"`             // 操作用户名（关联 users 表查询）
+	Action     string          `json:"action" db:"action"`
+	TargetType string          `json:"target_type" db:"target_type"`
+	TargetID   string          `json:"target_id" db:"target_id"`
+	Detail     json.RawMessage `json:"detail" db:"detail"` // 详细信息（变更前后的差异等）
+	CreatedAt  time.Time       `json:"created_at" db:"created_at"`
+}
+
+// 操作动作常量
+const (
+	ActionLogin       = "auth.login"
+	ActionLoginFailed = "auth.login_failed"
+
+	ActionStreamCreate = "stream.create"
+	ActionStreamUpdate = "stream.update"
+	ActionStreamDelete = "stream.delete"
+	ActionStreamKick   = "stream.kick"
+	ActionStreamEnd    = "stream.end"
+
+	ActionStreamPushSecretReset  = "stream.push_secret_reset"
+	ActionStreamPublishRejected  = "stream.publish_rejected"
+	ActionStreamPlaybackIDRotate = "stream.playback_id_rotate"
+	ActionStreamKeyRotate        = "stream.key_rotate"
+
+	ActionShareCodeAdd        = "share_code.add"
+	ActionShareCodeRegenerate = "share_code.regenerate"
+	ActionShareCodeUpdate     = "share_code.update"
+	ActionShareCodeDelete     = "share_code.delete"
+
+	ActionShareLinkCreate = "share_link.create"
+	ActionShareLinkUpdate = "share_link.update"
+	ActionShareLinkDelete = "share_link.delete"
+
+	ActionRecordingUpdate = "recording.update"
+	ActionRecordingDelete = "recording.delete"
+	ActionRecordingExpire = "recording.expire" // 保留策略清理任务删除
+	ActionUploadRetry     = "upload.retry"
+)
+
+// 操作目标类型常量
+const (
+	TargetTypeUser      = "user"
+	TargetTypeStream    = "stream"
+	TargetTypeShareLink = "share_link"
+	TargetTypeRecording = "recording"
+	TargetTypeUpload    = "recording_upload"
+)
+
+// OperationLogListRequest 操作日志查询参数
+type OperationLogListRequest struct {
+	UserID     int64      // 操作用户ID
+	Action     string     // 操作动作
+	TargetType string     // 目标类型
+	TargetID   string     // 目标ID
+	From       *time.Time // 开始时间
+	To         *time.Time // 结束时间
+	Page       int
+	PageSize   int
+}
+
+// OperationLogListResponse 操作日志列表响应
+type OperationLogListResponse struct {
+	Total int64           `json:"total"`
+	Logs  []*OperationLog `json:"logs"`
+}
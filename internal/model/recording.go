@@ -0,0 +1,133 @@
+package model
+
+import "time"
+
+// Recording 录制文件（每个录制分段一条记录）
+type Recording struct {
+	ID             int64              `json:"id" db:"id"`
+	StreamID       int64              `json:"stream_id" db:"stream_id"`
+	MediaServerID  string             `json:"media_server_id" db:"media_server_id"` // 录制所在 ZLMediaKit 节点
+	Format         string             `json:"format" db:"format"`                   // 录制格式：mp4 / hls
+	Status         string             `json:"status" db:"status"`                   // recording（HLS 录制中） / completed
+	FileName       string             `json:"file_name" db:"file_name"`
+	LocalPath      string             `json:"local_path" db:"local_path"` // ZLMediaKit 节点上的文件绝对路径（HLS 为切片所在目录）
+	URL            string             `json:"url" db:"url"`               // ZLMediaKit 返回的相对访问地址（HLS 为切片所在目录）
+	FileSize       int64              `json:"file_size" db:"file_size"`   // 文件大小（字节）
+	Duration       float64            `json:"duration" db:"duration"`     // 时长（秒）
+	SegmentCount   int                `json:"segment_count" db:"-"`       // HLS 切片数
+	StartTime      time.Time          `json:"start_time" db:"start_time"`
+	EndTime        time.Time          `json:"end_time" db:"end_time"`
+	Checksum       *string            `json:"checksum" db:"checksum"`                 // 文件 SHA-256（上传前计算，文件不可读时为空）
+	Starred        bool               `json:"starred" db:"starred"`                   // 是否收藏，收藏的录制永久保留
+	LocalDeletedAt *time.Time         `json:"local_deleted_at" db:"local_deleted_at"` // 本地文件按保留策略删除的时间
+	CreatedAt      time.Time          `json:"created_at" db:"created_at"`
+	Uploads        []*RecordingUpload `json:"uploads"` // 各存储目标的上传状态
+
+	// 所属直播的录制保留天数，仅在保留策略清理任务中填充
+	StreamRetentionDays *int `json:"-" db:"-"`
+}
+
+// RecordingUpload 录制文件在某个存储目标上的上传状态，同时作为持久化的上传任务
+type RecordingUpload struct {
+	ID            int64     `json:"id" db:"id"`
+	RecordingID   int64     `json:"recording_id" db:"recording_id"`
+	Storage       string    `json:"storage" db:"storage"`         // 存储目标名称（storage.targets[].name）
+	Status        string    `json:"status" db:"status"`           // pending / uploading / success / failed / deleted
+	RemotePath    string    `json:"remote_path" db:"remote_path"` // 存储目标中的相对路径
+	RemoteURL     *string   `json:"remote_url" db:"remote_url"`   // 上传成功后的访问地址
+	Error         *string   `json:"error" db:"error"`             // 最近一次上传失败原因
+	Attempts      int       `json:"attempts" db:"attempts"`       // 已尝试次数
+	NextAttemptAt time.Time `json:"next_attempt_at" db:"next_attempt_at"`
+	CreatedAt     time.Time `json:"created_at" db:"created_at"`
+	UpdatedAt     time.Time `json:"updated_at" db:"updated_at"`
+
+	// 所属录制文件信息，仅在上传队列查询和上传任务中填充
+	StreamID  int64  `json:"stream_id,omitempty" db:"-"`
+	Format    string `json:"format,omitempty" db:"-"`
+	FileName  string `json:"file_name,omitempty" db:"-"`
+	LocalPath string `json:"local_path,omitempty" db:"-"`
+}
+
+// RecordingSegment HLS 录制切片
+type RecordingSegment struct {
+	ID          int64     `json:"id" db:"id"`
+	RecordingID int64     `json:"recording_id" db:"recording_id"`
+	Seq         int       `json:"seq" db:"seq"` // 切片序号（从 0 开始）
+	FileName    string    `json:"file_name" db:"file_name"`
+	LocalPath   string    `json:"local_path" db:"local_path"`
+	URL         string    `json:"url" db:"url"` // ZLMediaKit 返回的相对访问地址
+	FileSize    int64     `json:"file_size" db:"file_size"`
+	Duration    float64   `json:"duration" db:"duration"`
+	StartTime   time.Time `json:"start_time" db:"start_time"`
+	CreatedAt   time.Time `json:"created_at" db:"created_at"`
+}
+
+// 录制格式
+const (
+	RecordingFormatMP4 = "mp4"
+	RecordingFormatHLS = "hls"
+)
+
+// 录制状态
+const (
+	RecordingStatusRecording = "recording" // HLS 录制中，仍在追加切片
+	RecordingStatusCompleted = "completed"
+)
+
+// 上传状态
+const (
+	UploadStatusPending   = "pending"   // 等待上传（含等待重试）
+	UploadStatusUploading = "uploading" // 上传中
+	UploadStatusSuccess   = "success"
+	UploadStatusFailed    = "failed"  // 达到最大尝试次数，需手动重试
+	UploadStatusDeleted   = "deleted" // 存储目标上的副本已按保留策略删除
+)
+
+// RecordingListRequest 录制文件列表查询参数
+type RecordingListRequest struct {
+	Format   string     // 录制格式
+	Status   string     // 录制状态
+	From     *time.Time // 录制开始时间不早于
+	To       *time.Time // 录制开始时间早于
+	Page     int
+	PageSize int
+}
+
+// UpdateRecordingRequest 更新录制文件请求
+type UpdateRecordingRequest struct {
+	Starred *bool `json:"starred" binding:"required"` // 是否收藏
+}
+
+// RecordingListResponse 录制文件列表响应
+type RecordingListResponse struct {
+	Total      int64        `json:"total"`
+	Recordings []*Recording `json:"recordings"`
+}
+
+// UploadListRequest 上传队列查询参数
+type UploadListRequest struct {
+	Status   string // 上传状态
+	Storage  string // 存储目标名称
+	Page     int
+	PageSize int
+}
+
+// UploadListResponse 上传队列列表响应
+type UploadListResponse struct {
+	Total   int64              `json:"total"`
+	Uploads []*RecordingUpload `json:"uploads"`
+}
+
+// VODRecording 点播录制（游客视图）
+type VODRecording struct {
+	ID          int64     `json:"id"`
+	StartTime   time.Time `json:"start_time"`
+	EndTime     time.Time `json:"end_time"`
+	Duration    float64   `json:"duration"`
+	PlaylistURL string    `json:"playlist_url"` // m3u8 地址（私有直播携带 access_token）
+}
+
+// VODListResponse 点播录制列表响应
+type VODListResponse struct {
+	Recordings []*VODRecording `json:"recordings"`
+}
@@ -0,0 +1,105 @@
+package model
+
+import "time"
+
+// DailyStat 每日统计
+// 计数类字段在业务事件发生时累加，峰值类字段由定时任务刷新
+type DailyStat struct {
+	Date           string `json:"date" db:"stat_date"`                  // 统计日期（YYYY-MM-DD）
+	StreamsCreated int    `json:"streams_created" db:"streams_created"` // 新建直播数
+	Publishes      int    `json:"publishes" db:"publishes"`             // 推流次数
+	Plays          int    `json:"plays" db:"plays"`                     // 观看人次
+	PeakViewers    int    `json:"peak_viewers" db:"peak_viewers"`       // 全站并发观看人数峰值
+	PeakStreams    int    `json:"peak_streams" db:"peak_streams"`       // 同时推流数峰值
+	RecordFiles    int    `json:"record_files" db:"record_files"`       // 录制文件数
+	RecordSeconds  int64  `json:"record_seconds" db:"record_seconds"`   // 录制时长（秒）
+	RecordBytes    int64  `json:"record_bytes" db:"record_bytes"`       // 录制文件大小（字节）
+	ShareCodeUses  int    `json:"share_code_uses" db:"share_code_uses"` // 分享码兑换次数
+	ShareLinkUses  int    `json:"share_link_uses" db:"share_link_uses"` // 分享链接兑换次数
+}
+
+// 每日统计计数字段（daily_stats 列名）
+const (
+	StatStreamsCreated = "streams_created"
+	StatPublishes      = "publishes"
+	StatPlays          = "plays"
+	StatRecordFiles    = "record_files"
+	StatRecordSeconds  = "record_seconds"
+	StatRecordBytes    = "record_bytes"
+	StatShareCodeUses  = "share_code_uses"
+	StatShareLinkUses  = "share_link_uses"
+)
+
+// IsDailyStatCounter 判断是否为可累加的每日统计字段
+func IsDailyStatCounter(field string) bool {
+	switch field {
+	case StatStreamsCreated, StatPublishes, StatPlays,
+		StatRecordFiles, StatRecordSeconds, StatRecordBytes,
+		StatShareCodeUses, StatShareLinkUses:
+		return true
+	}
+	return false
+}
+
+// StreamCounts 直播数量统计
+type StreamCounts struct {
+	Total   int64 `json:"total"`
+	Idle    int64 `json:"idle"`
+	Pushing int64 `json:"pushing"`
+	Ended   int64 `json:"ended"`
+	Public  int64 `json:"public"`
+	Private int64 `json:"private"`
+}
+
+// ViewerStats 观看统计
+type ViewerStats struct {
+	Current   int64 `json:"current"`    // 当前观看人数（各直播 current_viewers 之和）
+	Total     int64 `json:"total"`      // 累计观看人次（各直播 total_viewers 之和）
+	PeakToday int   `json:"peak_today"` // 今日全站并发观看人数峰值
+}
+
+// RecordingStats 录制统计
+type RecordingStats struct {
+	Files   int64   `json:"files"`   // 录制文件数
+	Hours   float64 `json:"hours"`   // 录制总时长（小时）
+	Seconds int64   `json:"seconds"` // 录制总时长（秒）
+	Bytes   int64   `json:"bytes"`   // 录制文件总大小（字节）
+}
+
+// ShareStats 分享兑换统计
+type ShareStats struct {
+	ShareCodeUses int64 `json:"share_code_uses"` // 分享码兑换次数
+	ShareLinkUses int64 `json:"share_link_uses"` // 分享链接兑换次数
+}
+
+// ZLMStats ZLMediaKit 侧统计（来自各节点的 getMediaList 汇总）
+type ZLMStats struct {
+	Available    bool   `json:"available"`         // 是否至少有一个节点可访问
+	Nodes        int    `json:"nodes"`             // 节点总数
+	NodesOnline  int    `json:"nodes_online"`      // 可访问的节点数
+	Streams      int    `json:"streams"`           // 流数量（按节点和 app/stream 去重）
+	MediaSources int    `json:"media_sources"`     // 媒体源数量（各协议分别计数）
+	Readers      int    `json:"readers"`           // 观看人数
+	BytesSpeed   int    `json:"bytes_speed"`       // 总数据速率（字节/秒）
+	Message      string `json:"message,omitempty"` // 错误信息（不可访问的节点）
+}
+
+// SystemStats 系统实时统计
+type SystemStats struct {
+	OnlineStreams int64           `json:"online_streams"` // 正在推流的直播数（兼容旧字段）
+	TotalStreams  int64           `json:"total_streams"`  // 直播总数（兼容旧字段）
+	Streams       *StreamCounts   `json:"streams"`
+	Viewers       *ViewerStats    `json:"viewers"`
+	Recordings    *RecordingStats `json:"recordings"`
+	Shares        *ShareStats     `json:"shares"`
+	ZLM           *ZLMStats       `json:"zlm"`
+	Today         *DailyStat      `json:"today"`
+	GeneratedAt   time.Time       `json:"generated_at"`
+}
+
+// DailyStatsResponse 每日统计查询响应
+type DailyStatsResponse struct {
+	From string       `json:"from"`
+	To   string       `json:"to"`
+	Days []*DailyStat `json:"days"`
+}
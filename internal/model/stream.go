@@ -35,6 +35,7 @@ func (a StringArray) Value() (driver.Value, error) {
 type Stream struct {
 	ID                 int64       `json:"id" db:"id"`
 	StreamKey          string      `json:"stream_key" db:"stream_key"`
+	PlaybackID         string      `json:"playback_id" db:"playback_id"` // 播放ID（ZLMediaKit 中的流名称，观众使用）
 	Name               string      `json:"name" db:"name"`
 	Description        *string     `json:"description" db:"description"`
 	DeviceID           *string     `json:"device_id" db:"device_id"`
@@ -46,9 +47,15 @@ type Stream struct {
 	ShareCodeUsedCount int         `json:"share_code_used_count" db:"share_code_used_count"` // 分享码已使用次数
 	RecordEnabled      bool        `json:"record_enabled" db:"record_enabled"`               // 是否开启录制
 	RecordFiles        StringArray `json:"record_files" db:"record_files"`                   // 录制文件路径列表
+	RecordFormat       string      `json:"record_format" db:"record_format"`                 // 录制格式：mp4 / hls / both
+	RetentionDays      *int        `json:"retention_days" db:"retention_days"`               // 录制保留天数，为空使用全局保留策略，0 表示永久保留
 	Protocol           *string     `json:"protocol" db:"protocol"`
 	Bitrate            *int        `json:"bitrate" db:"bitrate"`
 	FPS                *int        `json:"fps" db:"fps"`
+	Width              *int        `json:"width" db:"width"`
+	Height             *int        `json:"height" db:"height"`
+	VideoCodec         *string     `json:"video_codec" db:"video_codec"`
+	AudioCodec         *string     `json:"audio_codec" db:"audio_codec"`
 	StreamerName       *string     `json:"streamer_name" db:"streamer_name"`               // 直播人员姓名
 	StreamerContact    *string     `json:"streamer_contact" db:"streamer_contact"`         // 直播人员联系方式
 	ScheduledStartTime *time.Time  `json:"scheduled_start_time" db:"scheduled_start_time"` // 预计开始时间
@@ -58,6 +65,12 @@ type Stream struct {
 	ActualEndTime      *time.Time  `json:"actual_end_time" db:"actual_end_time"`           // 实际结束时间
 	LastUnpublishAt    *time.Time  `json:"last_unpublish_at" db:"last_unpublish_at"`       // 最后断流时间
 	LastFrameAt        *time.Time  `json:"last_frame_at" db:"last_frame_at"`
+	EndReason          *string     `json:"end_reason" db:"end_reason"` // 结束原因
+	// 推流鉴权
+	PushSecret         *string     `json:"-" db:"push_secret"`                       // 推流签名密钥（不对外返回）
+	PushAuthEnabled    bool        `json:"push_auth_enabled" db:"push_auth_enabled"` // 是否开启推流鉴权
+	AllowedCIDRs       StringArray `json:"allowed_cidrs" db:"allowed_cidrs"`         // 推流 IP 白名单（为空表示不限制）
+	MediaServerID      *string     `json:"media_server_id" db:"media_server_id"`     // 推流所在 ZLMediaKit 节点
 	// 观看统计
 	CurrentViewers int   `json:"current_viewers" db:"current_viewers"` // 当前观看人数
 	TotalViewers   int   `json:"total_viewers" db:"total_viewers"`     // 累计观看人次
@@ -67,6 +80,18 @@ type Stream struct {
 	UpdatedAt      time.Time   `json:"updated_at" db:"updated_at"`
 }
 
+// StreamQuality 直播质量采样结果
+type StreamQuality struct {
+	Bitrate     int        // 码率（kbps）
+	BytesSpeed  int        // 数据速率（字节/秒）
+	FPS         int        // 帧率
+	Width       int        // 视频宽度
+	Height      int        // 视频高度
+	VideoCodec  *string    // 视频编码
+	AudioCodec  *string    // 音频编码
+	LastFrameAt *time.Time // 最后一次收到新视频帧的时间（为空表示不更新）
+}
+
 // StreamStatus 流状态常量
 const (
 	StreamStatusIdle    = "idle"
@@ -74,6 +99,30 @@ const (
 	StreamStatusEnded   = "ended"
 )
 
+// StreamEndReason 直播结束原因常量
+const (
+	StreamEndReasonManual      = "manual"       // 管理员手动结束
+	StreamEndReasonAutoTimeout = "auto_timeout" // 超过预计结束时间未推流，自动结束
+	StreamEndReasonEmptyStream = "empty_stream" // 空流检测判定为无内容，自动结束
+)
+
+// 录制格式常量
+const (
+	RecordFormatMP4  = "mp4"
+	RecordFormatHLS  = "hls"
+	RecordFormatBoth = "both" // 同时录制 MP4 和 HLS
+)
+
+// RecordsMP4 是否录制 MP4
+func (s *Stream) RecordsMP4() bool {
+	return s.RecordFormat != RecordFormatHLS
+}
+
+// RecordsHLS 是否录制 HLS
+func (s *Stream) RecordsHLS() bool {
+	return s.RecordFormat == RecordFormatHLS || s.RecordFormat == RecordFormatBoth
+}
+
 // StreamVisibility 流可见性常量
 const (
 	StreamVisibilityPublic  = "public"
@@ -88,6 +137,10 @@ type CreateStreamRequest struct {
 	Visibility         string     `json:"visibility" binding:"required,oneof=public private"`
 	ShareCodeMaxUses   *int       `json:"share_code_max_uses"` // 分享码最大使用次数（仅私有直播有效，0或不传表示无限制）
 	RecordEnabled      bool       `json:"record_enabled"`      // 是否开启录制
+	RecordFormat       string     `json:"record_format" binding:"omitempty,oneof=mp4 hls both"` // 录制格式，默认 mp4
+	RetentionDays      *int       `json:"retention_days" binding:"omitempty,min=0"`             // 录制保留天数，不传使用全局保留策略，0 表示永久保留
+	PushAuthEnabled    bool       `json:"push_auth_enabled"`   // 是否开启推流鉴权
+	AllowedCIDRs       []string   `json:"allowed_cidrs"`       // 推流 IP 白名单（CIDR 或单个 IP）
 	StreamerName       string     `json:"streamer_name" binding:"required"`
 	StreamerContact    string     `json:"streamer_contact"`
 	ScheduledStartTime *time.Time `json:"scheduled_start_time" binding:"required"`
@@ -102,6 +155,10 @@ type UpdateStreamRequest struct {
 	DeviceID           string     `json:"device_id"`
 	Visibility         string     `json:"visibility" binding:"omitempty,oneof=public private"`
 	RecordEnabled      *bool      `json:"record_enabled"` // 使用指针以区分未传和传 false
+	RecordFormat       string     `json:"record_format" binding:"omitempty,oneof=mp4 hls both"`
+	RetentionDays      *int       `json:"retention_days"` // 传负数表示恢复使用全局保留策略
+	PushAuthEnabled    *bool      `json:"push_auth_enabled"`
+	AllowedCIDRs       *[]string  `json:"allowed_cidrs"` // 传空数组表示清除白名单
 	StreamerName       string     `json:"streamer_name"`
 	StreamerContact    string     `json:"streamer_contact"`
 	ScheduledStartTime *time.Time `json:"scheduled_start_time"`
@@ -141,16 +198,20 @@ type StreamAccessToken struct {
 // StreamPublicView 游客可见的直播信息（不含 stream_key）
 type StreamPublicView struct {
 	ID                 int64       `json:"id"`
+	PlaybackID         string      `json:"playback_id"`
 	Name               string      `json:"name"`
 	Description        *string     `json:"description"`
-	DeviceID           *string     `json:"device_id"`
 	Status             string      `json:"status"`
 	Visibility         string      `json:"visibility"`
 	RecordEnabled      bool        `json:"record_enabled"`
-	RecordFiles        StringArray `json:"record_files"`
+	RecordFormat       string      `json:"record_format"`
 	Protocol           *string     `json:"protocol"`
 	Bitrate            *int        `json:"bitrate"`
 	FPS                *int        `json:"fps"`
+	Width              *int        `json:"width"`
+	Height             *int        `json:"height"`
+	VideoCodec         *string     `json:"video_codec"`
+	AudioCodec         *string     `json:"audio_codec"`
 	StreamerName       *string     `json:"streamer_name"`
 	StreamerContact    *string     `json:"streamer_contact"`
 	ScheduledStartTime *time.Time  `json:"scheduled_start_time"`
@@ -165,22 +226,27 @@ type StreamPublicView struct {
 	CreatedBy          int64       `json:"created_by"`
 	CreatedAt          time.Time   `json:"created_at"`
 	UpdatedAt          time.Time   `json:"updated_at"`
+	PlayURLs           *PlayURLsResponse `json:"play_urls,omitempty"` // 播放地址（由 StreamService.PublicView 填充）
 }
 
 // ToPublicView 将 Stream 转换为 StreamPublicView（游客视图）
 func (s *Stream) ToPublicView() *StreamPublicView {
 	return &StreamPublicView{
 		ID:                 s.ID,
+		PlaybackID:         s.PlaybackID,
 		Name:               s.Name,
 		Description:        s.Description,
-		DeviceID:           s.DeviceID,
 		Status:             s.Status,
 		Visibility:         s.Visibility,
 		RecordEnabled:      s.RecordEnabled,
-		RecordFiles:        s.RecordFiles,
+		RecordFormat:       s.RecordFormat,
 		Protocol:           s.Protocol,
 		Bitrate:            s.Bitrate,
 		FPS:                s.FPS,
+		Width:              s.Width,
+		Height:             s.Height,
+		VideoCodec:         s.VideoCodec,
+		AudioCodec:         s.AudioCodec,
 		StreamerName:       s.StreamerName,
 		StreamerContact:    s.StreamerContact,
 		ScheduledStartTime: s.ScheduledStartTime,
@@ -203,3 +269,40 @@ type StreamPublicListResponse struct {
 	Total   int64               `json:"total"`
 	Streams []*StreamPublicView `json:"streams"`
 }
+
+// PlayURLsResponse 播放地址响应
+// 私有直播的各地址已携带 expire 和 sign 参数
+type PlayURLsResponse struct {
+	RTMP     string     `json:"rtmp"`      // RTMP 播放地址
+	RTSP     string     `json:"rtsp"`      // RTSP 播放地址
+	FLV      string     `json:"flv"`       // HTTP-FLV 播放地址
+	WSFLV    string     `json:"ws_flv"`    // WebSocket-FLV 播放地址
+	HLS      string     `json:"hls"`       // HLS 播放地址
+	Signed   bool       `json:"signed"`    // 地址是否携带签名
+	ExpireAt *time.Time `json:"expire_at"` // 签名过期时间（未签名时为空）
+}
+
+// RotateStreamKeyRequest 更换推流码请求
+type RotateStreamKeyRequest struct {
+	Kick        bool `json:"kick"`         // 是否断开当前推流
+	GracePeriod *int `json:"grace_period"` // 旧推流码仍可推流的宽限期（秒），不传使用 push.keyGracePeriod，0 表示立即失效
+}
+
+// RotateStreamKeyResponse 更换推流码响应
+type RotateStreamKeyResponse struct {
+	StreamKey      string            `json:"stream_key"`        // 新推流码
+	OldKeyExpireAt *time.Time        `json:"old_key_expire_at"` // 旧推流码宽限期结束时间（无宽限期时为空）
+	PushURLs       *PushURLsResponse `json:"push_urls"`         // 使用新推流码的推流地址
+}
+
+// PushURLsResponse 推流地址响应
+// 开启推流鉴权时，各地址已携带 expire 和 sign 参数
+type PushURLsResponse struct {
+	RTMP         string     `json:"rtmp"`           // RTMP 完整推流地址
+	OBSServer    string     `json:"obs_server"`     // OBS "服务器" 一栏（rtmp://host:port/live）
+	OBSStreamKey string     `json:"obs_stream_key"` // OBS "串流密钥" 一栏（含签名参数）
+	SRT          string     `json:"srt"`            // SRT 推流地址
+	RTSP         string     `json:"rtsp"`           // RTSP 推流地址
+	Signed       bool       `json:"signed"`         // 地址是否携带签名
+	ExpireAt     *time.Time `json:"expire_at"`      // 签名过期时间（未签名时为空）
+}
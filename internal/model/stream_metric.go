@@ -0,0 +1,39 @@
+package model
+
+import "time"
+
+// StreamMetric 直播健康指标采样
+// Granularity 为 0 表示原始采样；降采样后为时间桶长度（秒），SampledAt 为时间桶起点
+type StreamMetric struct {
+	ID          int64     `json:"id" db:"id"`
+	StreamID    int64     `json:"stream_id" db:"stream_id"`
+	SampledAt   time.Time `json:"sampled_at" db:"sampled_at"`
+	Granularity int       `json:"granularity" db:"granularity"`
+	Bitrate     int       `json:"bitrate" db:"bitrate"`           // 码率（kbps）
+	BitrateMin  int       `json:"bitrate_min" db:"bitrate_min"`   // 最低码率（kbps）
+	FPS         int       `json:"fps" db:"fps"`                   // 帧率
+	FPSMin      int       `json:"fps_min" db:"fps_min"`           // 最低帧率
+	ReaderCount int       `json:"reader_count" db:"reader_count"` // 观看人数
+	BytesSpeed  int       `json:"bytes_speed" db:"bytes_speed"`   // 数据速率（字节/秒）
+}
+
+// StreamMetricPoint 按时间桶聚合后的指标数据点
+type StreamMetricPoint struct {
+	Time        time.Time `json:"time"`         // 时间桶起点
+	Bitrate     int       `json:"bitrate"`      // 平均码率（kbps）
+	BitrateMin  int       `json:"bitrate_min"`  // 最低码率（kbps）
+	FPS         int       `json:"fps"`          // 平均帧率
+	FPSMin      int       `json:"fps_min"`      // 最低帧率
+	ReaderCount int       `json:"reader_count"` // 最大观看人数
+	BytesSpeed  int       `json:"bytes_speed"`  // 平均数据速率（字节/秒）
+	Samples     int       `json:"samples"`      // 时间桶内的数据条数
+}
+
+// StreamMetricsResponse 直播健康指标查询响应
+type StreamMetricsResponse struct {
+	StreamID int64                `json:"stream_id"`
+	From     time.Time            `json:"from"`
+	To       time.Time            `json:"to"`
+	Step     int                  `json:"step"` // 时间桶长度（秒）
+	Points   []*StreamMetricPoint `json:"points"`
+}
@@ -7,6 +7,7 @@ type User struct {
 	ID           int64      `json:"id" db:"id"`
 	Username     string     `json:"username" db:"username"`
 	PasswordHash string     `json:"-" db:"password_hash"`
+	Role         string     `json:"role" db:"role"` // admin / operator / viewer_manager / viewer
 	Email        *string    `json:"email" db:"email"`
 	Phone        *string    `json:"phone" db:"phone"`
 	RealName     *string    `json:"real_name" db:"real_name"`
@@ -16,6 +17,74 @@ type User struct {
 	UpdatedAt    time.Time  `json:"updated_at" db:"updated_at"`
 }
 
+// 用户角色常量
+const (
+	RoleAdmin         = "admin"          // 管理员：拥有全部权限
+	RoleOperator      = "operator"       // 操作员：创建直播，只能管理自己创建的直播，不能删除
+	RoleViewerManager = "viewer_manager" // 观众管理员：管理所有直播的分享码和分享链接
+	RoleViewer        = "viewer"         // 只读用户：只能查看
+)
+
+// 权限常量
+const (
+	PermStreamView    = "stream:view"        // 查看直播详情（不含推流码）
+	PermStreamCreds   = "stream:credentials" // 查看推流码等推流凭证（操作员只能查看自己创建的直播）
+	PermStreamCreate  = "stream:create"      // 创建直播
+	PermStreamUpdate  = "stream:update"      // 更新直播信息
+	PermStreamDelete  = "stream:delete"      // 删除直播
+	PermStreamKick    = "stream:kick"        // 强制断流
+	PermStreamEnd     = "stream:end"         // 结束直播
+	PermShareManage   = "share:manage"       // 管理分享码和分享链接
+	PermSystemStats   = "system:stats"       // 查看系统统计
+	PermAuditView     = "audit:view"         // 查看操作日志（仅管理员）
+	PermStorageManage = "storage:manage"     // 管理录制文件上传队列（仅管理员）
+)
+
+// rolePermissions 各角色拥有的权限（admin 拥有全部权限，不在此列出）
+var rolePermissions = map[string][]string{
+	RoleOperator: {
+		PermStreamView, PermStreamCreds, PermStreamCreate, PermStreamUpdate,
+		PermStreamKick, PermStreamEnd, PermShareManage, PermSystemStats,
+	},
+	RoleViewerManager: {
+		PermStreamView, PermShareManage,
+	},
+	RoleViewer: {
+		PermStreamView,
+	},
+}
+
+// HasPermission 判断角色是否拥有指定权限
+func HasPermission(role, perm string) bool {
+	if role == RoleAdmin {
+		return true
+	}
+	for _, p := range rolePermissions[role] {
+		if p == perm {
+			return true
+		}
+	}
+	return false
+}
+
+// IsValidRole 判断角色是否合法
+func IsValidRole(role string) bool {
+	if role == RoleAdmin {
+		return true
+	}
+	_, ok := rolePermissions[role]
+	return ok
+}
+
+// CanManageStream 判断用户能否操作指定创建者的直播
+// 操作员只能操作自己创建的直播，其他角色的操作范围由权限本身决定
+func CanManageStream(role string, userID, ownerID int64) bool {
+	if role == RoleOperator {
+		return userID == ownerID
+	}
+	return true
+}
+
 // LoginRequest 登录请求
 type LoginRequest struct {
 	Username string `json:"username" binding:"required"`
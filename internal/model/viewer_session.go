@@ -0,0 +1,24 @@
+package model
+
+import "time"
+
+// ViewerSession 观看会话
+// SessionID 为 mediaServerId|播放器唯一标识，同一节点上的播放器标识唯一
+type ViewerSession struct {
+	ID            int64      `json:"id" db:"id"`
+	StreamID      int64      `json:"stream_id" db:"stream_id"`
+	SessionID     string     `json:"session_id" db:"session_id"`
+	MediaServerID string     `json:"media_server_id" db:"media_server_id"` // 播放所在 ZLMediaKit 节点
+	IP            string     `json:"ip" db:"ip"`                           // 观众 IP
+	Protocol      string     `json:"protocol" db:"protocol"`               // 播放协议
+	StartedAt     time.Time  `json:"started_at" db:"started_at"`
+	EndedAt       *time.Time `json:"ended_at" db:"ended_at"`
+	EndReason     *string    `json:"end_reason" db:"end_reason"` // disconnect / timeout / stream_end
+}
+
+// 观看会话结束原因
+const (
+	ViewerEndDisconnect = "disconnect" // 播放器断开
+	ViewerEndTimeout    = "timeout"    // 心跳超时
+	ViewerEndStreamEnd  = "stream_end" // 直播断流或结束
+)
@@ -0,0 +1,124 @@
+package repository
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	"easy-stream/internal/model"
+)
+
+type DailyStatRepository struct {
+	db *sql.DB
+}
+
+func NewDailyStatRepository(db *sql.DB) *DailyStatRepository {
+	return &DailyStatRepository{db: db}
+}
+
+// dailyStatColumns 查询每日统计时使用的字段列表，顺序需与 scanDailyStat 保持一致
+const dailyStatColumns = `to_char(stat_date, 'YYYY-MM-DD'), streams_created, publishes, plays,
+			   peak_viewers, peak_streams, record_files, record_seconds, record_bytes,
+			   share_code_uses, share_link_uses`
+
+// scanDailyStat 按 dailyStatColumns 的顺序扫描一行每日统计
+func scanDailyStat(row rowScanner) (*model.DailyStat, error) {
+	d := &model.DailyStat{}
+	err := row.Scan(
+		&d.Date, &d.StreamsCreated, &d.Publishes, &d.Plays,
+		&d.PeakViewers, &d.PeakStreams, &d.RecordFiles, &d.RecordSeconds, &d.RecordBytes,
+		&d.ShareCodeUses, &d.ShareLinkUses,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return d, nil
+}
+
+// Increment 累加指定日期的计数字段，当天记录不存在时自动创建
+func (r *DailyStatRepository) Increment(date, field string, delta int64) error {
+	if !model.IsDailyStatCounter(field) {
+		return fmt.Errorf("invalid daily stat field: %s", field)
+	}
+	query := fmt.Sprintf(`
+		INSERT INTO daily_stats (stat_date, %[1]s, updated_at)
+		VALUES ($1, $2, $3)
+		ON CONFLICT (stat_date) DO UPDATE SET
+			%[1]s = daily_stats.%[1]s + EXCLUDED.%[1]s,
+			updated_at = EXCLUDED.updated_at
+	`, field)
+	_, err := r.db.Exec(query, date, delta, time.Now())
+	return err
+}
+
+// UpdatePeak 刷新指定日期的并发观看人数和同时推流数峰值（只增不减）
+func (r *DailyStatRepository) UpdatePeak(date string, viewers, streams int) error {
+	query := `
+		INSERT INTO daily_stats (stat_date, peak_viewers, peak_streams, updated_at)
+		VALUES ($1, $2, $3, $4)
+		ON CONFLICT (stat_date) DO UPDATE SET
+			peak_viewers = GREATEST(daily_stats.peak_viewers, EXCLUDED.peak_viewers),
+			peak_streams = GREATEST(daily_stats.peak_streams, EXCLUDED.peak_streams),
+			updated_at = EXCLUDED.updated_at
+	`
+	_, err := r.db.Exec(query, date, viewers, streams, time.Now())
+	return err
+}
+
+// Get 获取指定日期的统计，不存在时返回 nil
+func (r *DailyStatRepository) Get(date string) (*model.DailyStat, error) {
+	query := `
+		SELECT ` + dailyStatColumns + `
+		FROM daily_stats WHERE stat_date = $1
+	`
+	stat, err := scanDailyStat(r.db.QueryRow(query, date))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	return stat, err
+}
+
+// ListRange 获取 [from, to] 日期范围内的统计（按日期升序，没有数据的日期不返回）
+func (r *DailyStatRepository) ListRange(from, to string) ([]*model.DailyStat, error) {
+	query := `
+		SELECT ` + dailyStatColumns + `
+		FROM daily_stats
+		WHERE stat_date >= $1 AND stat_date <= $2
+		ORDER BY stat_date
+	`
+	rows, err := r.db.Query(query, from, to)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	stats := make([]*model.DailyStat, 0)
+	for rows.Next() {
+		d, err := scanDailyStat(rows)
+		if err != nil {
+			return nil, err
+		}
+		stats = append(stats, d)
+	}
+	return stats, rows.Err()
+}
+
+// Totals 汇总所有日期的录制和分享兑换统计
+func (r *DailyStatRepository) Totals() (*model.RecordingStats, *model.ShareStats, error) {
+	query := `
+		SELECT COALESCE(SUM(record_files), 0), COALESCE(SUM(record_seconds), 0), COALESCE(SUM(record_bytes), 0),
+			   COALESCE(SUM(share_code_uses), 0), COALESCE(SUM(share_link_uses), 0)
+		FROM daily_stats
+	`
+	recordings := &model.RecordingStats{}
+	shares := &model.ShareStats{}
+	err := r.db.QueryRow(query).Scan(
+		&recordings.Files, &recordings.Seconds, &recordings.Bytes,
+		&shares.ShareCodeUses, &shares.ShareLinkUses,
+	)
+	if err != nil {
+		return nil, nil, err
+	}
+	recordings.Hours = float64(recordings.Seconds) / 3600
+	return recordings, shares, nil
+}
@@ -22,6 +22,7 @@ CREATE TABLE IF NOT EXISTS users (
     id              SERIAL PRIMARY KEY,
     username        VARCHAR(64) UNIQUE NOT NULL,
     password_hash   VARCHAR(256) NOT NULL,
+    role            VARCHAR(32) DEFAULT 'viewer',
     email           VARCHAR(128),
     phone           VARCHAR(32),
     real_name       VARCHAR(64),
@@ -32,11 +33,13 @@ CREATE TABLE IF NOT EXISTS users (
 );
 
 CREATE INDEX IF NOT EXISTS idx_users_username ON users(username);
+CREATE INDEX IF NOT EXISTS idx_users_role ON users(role);
 
 -- 创建推流表
 CREATE TABLE IF NOT EXISTS streams (
     id                      SERIAL PRIMARY KEY,
     stream_key              VARCHAR(64) UNIQUE NOT NULL,
+    playback_id             VARCHAR(64) UNIQUE NOT NULL,
     name                    VARCHAR(128) NOT NULL,
     description             TEXT,
     device_id               VARCHAR(64),
@@ -47,9 +50,15 @@ CREATE TABLE IF NOT EXISTS streams (
     share_code_used_count   INTEGER DEFAULT 0,
     record_enabled          BOOLEAN DEFAULT FALSE,
     record_files            JSONB DEFAULT '[]',
+    record_format           VARCHAR(8) NOT NULL DEFAULT 'mp4',
+    retention_days          INTEGER,
     protocol                VARCHAR(16),
     bitrate                 INTEGER DEFAULT 0,
     fps                     INTEGER DEFAULT 0,
+    width                   INTEGER DEFAULT 0,
+    height                  INTEGER DEFAULT 0,
+    video_codec             VARCHAR(32),
+    audio_codec             VARCHAR(32),
     streamer_name           VARCHAR(64) NOT NULL,
     streamer_contact        VARCHAR(128),
     scheduled_start_time    TIMESTAMP NOT NULL,
@@ -59,6 +68,11 @@ CREATE TABLE IF NOT EXISTS streams (
     actual_end_time         TIMESTAMP,
     last_unpublish_at       TIMESTAMP,
     last_frame_at           TIMESTAMP,
+    end_reason              VARCHAR(128),
+    push_secret             VARCHAR(64),
+    push_auth_enabled       BOOLEAN DEFAULT FALSE,
+    allowed_cidrs           JSONB DEFAULT '[]',
+    media_server_id         VARCHAR(64),
     current_viewers         INTEGER DEFAULT 0,
     total_viewers           INTEGER DEFAULT 0,
     peak_viewers            INTEGER DEFAULT 0,
@@ -79,7 +93,7 @@ CREATE UNIQUE INDEX IF NOT EXISTS idx_streams_share_code ON streams(share_code)
 -- 创建分享链接表
 CREATE TABLE IF NOT EXISTS share_links (
     id              SERIAL PRIMARY KEY,
-    stream_key      VARCHAR(64) NOT NULL REFERENCES streams(stream_key) ON DELETE CASCADE,
+    stream_key      VARCHAR(64) NOT NULL REFERENCES streams(stream_key) ON DELETE CASCADE ON UPDATE CASCADE,
     token           VARCHAR(64) UNIQUE NOT NULL,
     max_uses        INTEGER DEFAULT 0,
     used_count      INTEGER DEFAULT 0,
@@ -105,15 +119,121 @@ CREATE INDEX IF NOT EXISTS idx_logs_user_id ON operation_logs(user_id);
 CREATE INDEX IF NOT EXISTS idx_logs_created_at ON operation_logs(created_at);
 CREATE INDEX IF NOT EXISTS idx_logs_action ON operation_logs(action);
 
+-- 创建直播健康指标时序表
+CREATE TABLE IF NOT EXISTS stream_metrics (
+    id              BIGSERIAL PRIMARY KEY,
+    stream_id       INTEGER NOT NULL REFERENCES streams(id) ON DELETE CASCADE,
+    sampled_at      TIMESTAMP NOT NULL,
+    granularity     INTEGER DEFAULT 0,
+    bitrate         INTEGER DEFAULT 0,
+    bitrate_min     INTEGER DEFAULT 0,
+    fps             INTEGER DEFAULT 0,
+    fps_min         INTEGER DEFAULT 0,
+    reader_count    INTEGER DEFAULT 0,
+    bytes_speed     INTEGER DEFAULT 0
+);
+
+CREATE INDEX IF NOT EXISTS idx_stream_metrics_stream_time ON stream_metrics(stream_id, sampled_at);
+CREATE INDEX IF NOT EXISTS idx_stream_metrics_granularity_time ON stream_metrics(granularity, sampled_at);
+
+-- 创建每日统计表
+CREATE TABLE IF NOT EXISTS daily_stats (
+    stat_date        DATE PRIMARY KEY,
+    streams_created  INTEGER DEFAULT 0,
+    publishes        INTEGER DEFAULT 0,
+    plays            INTEGER DEFAULT 0,
+    peak_viewers     INTEGER DEFAULT 0,
+    peak_streams     INTEGER DEFAULT 0,
+    record_files     INTEGER DEFAULT 0,
+    record_seconds   BIGINT DEFAULT 0,
+    record_bytes     BIGINT DEFAULT 0,
+    share_code_uses  INTEGER DEFAULT 0,
+    share_link_uses  INTEGER DEFAULT 0,
+    updated_at       TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+-- 创建观看会话历史表
+CREATE TABLE IF NOT EXISTS viewer_sessions (
+    id               BIGSERIAL PRIMARY KEY,
+    stream_id        INTEGER NOT NULL REFERENCES streams(id) ON DELETE CASCADE,
+    session_id       VARCHAR(160) NOT NULL,
+    media_server_id  VARCHAR(64),
+    ip               VARCHAR(64),
+    protocol         VARCHAR(16),
+    started_at       TIMESTAMP NOT NULL,
+    ended_at         TIMESTAMP,
+    end_reason       VARCHAR(16)
+);
+
+CREATE INDEX IF NOT EXISTS idx_viewer_sessions_stream_time ON viewer_sessions(stream_id, started_at);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_viewer_sessions_open ON viewer_sessions(session_id) WHERE ended_at IS NULL;
+
+-- 创建录制文件表
+CREATE TABLE IF NOT EXISTS recordings (
+    id               BIGSERIAL PRIMARY KEY,
+    stream_id        INTEGER NOT NULL REFERENCES streams(id) ON DELETE CASCADE,
+    media_server_id  VARCHAR(64),
+    format           VARCHAR(16) NOT NULL,
+    file_name        VARCHAR(255) NOT NULL,
+    local_path       TEXT NOT NULL,
+    url              TEXT,
+    file_size        BIGINT DEFAULT 0,
+    duration         DOUBLE PRECISION DEFAULT 0,
+    start_time       TIMESTAMP NOT NULL,
+    end_time         TIMESTAMP NOT NULL,
+    checksum         VARCHAR(64),
+    status           VARCHAR(16) NOT NULL DEFAULT 'completed',
+    starred          BOOLEAN NOT NULL DEFAULT FALSE,
+    local_deleted_at TIMESTAMP,
+    created_at       TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_recordings_stream_time ON recordings(stream_id, start_time);
+CREATE INDEX IF NOT EXISTS idx_recordings_end_time ON recordings(end_time);
+
+-- 创建录制文件上传状态表
+CREATE TABLE IF NOT EXISTS recording_uploads (
+    id               BIGSERIAL PRIMARY KEY,
+    recording_id     BIGINT NOT NULL REFERENCES recordings(id) ON DELETE CASCADE,
+    storage          VARCHAR(64) NOT NULL,
+    status           VARCHAR(16) NOT NULL,
+    remote_path      TEXT NOT NULL,
+    remote_url       TEXT,
+    error            TEXT,
+    attempts         INTEGER DEFAULT 0,
+    next_attempt_at  TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    locked_until     TIMESTAMP,
+    created_at       TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at       TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE (recording_id, storage)
+);
+
+CREATE INDEX IF NOT EXISTS idx_recording_uploads_queue ON recording_uploads(status, next_attempt_at);
+
+-- 创建 HLS 录制切片表
+CREATE TABLE IF NOT EXISTS recording_segments (
+    id            BIGSERIAL PRIMARY KEY,
+    recording_id  BIGINT NOT NULL REFERENCES recordings(id) ON DELETE CASCADE,
+    seq           INTEGER NOT NULL,
+    file_name     VARCHAR(255) NOT NULL,
+    local_path    TEXT NOT NULL,
+    url           TEXT NOT NULL,
+    file_size     BIGINT DEFAULT 0,
+    duration      DOUBLE PRECISION DEFAULT 0,
+    start_time    TIMESTAMP NOT NULL,
+    created_at    TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE (recording_id, seq)
+);
+
 -- 插入默认管理员用户 (密码: admin123)
 -- 密码使用 bcrypt 加密
-INSERT INTO users (username, password_hash, real_name)
-VALUES ('admin', '$2a$10$wxWds7XBPNLDPJu2/Fiaj.ryW0ym01KKiYHtrb.56NLsExEewNQxS', '系统管理员')
+INSERT INTO users (username, password_hash, role, real_name)
+VALUES ('admin', '$2a$10$wxWds7XBPNLDPJu2/Fiaj.ryW0ym01KKiYHtrb.56NLsExEewNQxS', 'admin', '系统管理员')
 ON CONFLICT (username) DO NOTHING;
 
 -- 插入测试操作员用户 (密码: operator123)
-INSERT INTO users (username, password_hash, real_name)
-VALUES ('operator', '$2a$10$YourHashHere', '操作员')
+INSERT INTO users (username, password_hash, role, real_name)
+VALUES ('operator', '$2a$10$YourHashHere', 'operator', '操作员')
 ON CONFLICT (username) DO NOTHING;
 
 -- 添加注释
@@ -121,6 +241,7 @@ COMMENT ON TABLE users IS '用户表';
 COMMENT ON COLUMN users.id IS '用户ID';
 COMMENT ON COLUMN users.username IS '用户名';
 COMMENT ON COLUMN users.password_hash IS '密码哈希';
+COMMENT ON COLUMN users.role IS '角色：admin/operator/viewer_manager/viewer';
 COMMENT ON COLUMN users.email IS '邮箱';
 COMMENT ON COLUMN users.phone IS '电话';
 COMMENT ON COLUMN users.real_name IS '真实姓名';
@@ -130,9 +251,10 @@ COMMENT ON COLUMN users.last_login_at IS '最后登录时间';
 COMMENT ON TABLE streams IS '推流表';
 COMMENT ON COLUMN streams.id IS '推流ID';
 COMMENT ON COLUMN streams.stream_key IS '推流密钥';
+COMMENT ON COLUMN streams.playback_id IS '播放ID（观众播放使用的 ZLMediaKit 流名称，与推流密钥分离）';
 COMMENT ON COLUMN streams.name IS '推流名称';
 COMMENT ON COLUMN streams.description IS '推流描述';
-COMMENT ON COLUMN streams.device_id IS '设备ID';
+COMMENT ON COLUMN streams.device_id IS '设备ID（设置后推流参数需携带一致的 device_id）';
 COMMENT ON COLUMN streams.status IS '状态：idle/pushing/ended';
 COMMENT ON COLUMN streams.visibility IS '可见性：public/private';
 COMMENT ON COLUMN streams.share_code IS '分享码（私有直播自动生成）';
@@ -140,9 +262,15 @@ COMMENT ON COLUMN streams.share_code_max_uses IS '分享码最大使用次数（
 COMMENT ON COLUMN streams.share_code_used_count IS '分享码已使用次数';
 COMMENT ON COLUMN streams.record_enabled IS '是否开启录制';
 COMMENT ON COLUMN streams.record_files IS '录制文件路径列表（JSON数组）';
+COMMENT ON COLUMN streams.record_format IS '录制格式: mp4 / hls / both';
+COMMENT ON COLUMN streams.retention_days IS '录制保留天数，为空使用全局保留策略，0 表示永久保留';
 COMMENT ON COLUMN streams.protocol IS '推流协议：rtmp/rtsp/srt';
 COMMENT ON COLUMN streams.bitrate IS '码率（kbps）';
 COMMENT ON COLUMN streams.fps IS '帧率';
+COMMENT ON COLUMN streams.width IS '视频宽度（像素）';
+COMMENT ON COLUMN streams.height IS '视频高度（像素）';
+COMMENT ON COLUMN streams.video_codec IS '视频编码：H264/H265 等';
+COMMENT ON COLUMN streams.audio_codec IS '音频编码：AAC/opus 等';
 COMMENT ON COLUMN streams.streamer_name IS '直播人员姓名';
 COMMENT ON COLUMN streams.streamer_contact IS '直播人员联系方式';
 COMMENT ON COLUMN streams.scheduled_start_time IS '预计开始时间';
@@ -152,6 +280,11 @@ COMMENT ON COLUMN streams.actual_start_time IS '实际开始时间';
 COMMENT ON COLUMN streams.actual_end_time IS '实际结束时间';
 COMMENT ON COLUMN streams.last_unpublish_at IS '最后断流时间，用于计算自动结束';
 COMMENT ON COLUMN streams.last_frame_at IS '最后一帧时间';
+COMMENT ON COLUMN streams.end_reason IS '结束原因：manual/auto_timeout/empty_stream';
+COMMENT ON COLUMN streams.push_secret IS '推流签名密钥';
+COMMENT ON COLUMN streams.push_auth_enabled IS '是否开启推流鉴权（推流地址需携带签名）';
+COMMENT ON COLUMN streams.allowed_cidrs IS '推流 IP 白名单（CIDR 列表，JSON数组，为空表示不限制）';
+COMMENT ON COLUMN streams.media_server_id IS '推流所在 ZLMediaKit 节点的 mediaServerId';
 COMMENT ON COLUMN streams.current_viewers IS '当前观看人数';
 COMMENT ON COLUMN streams.total_viewers IS '累计观看人次';
 COMMENT ON COLUMN streams.peak_viewers IS '峰值观看人数';
@@ -172,3 +305,74 @@ COMMENT ON COLUMN operation_logs.action IS '操作动作';
 COMMENT ON COLUMN operation_logs.target_type IS '目标类型';
 COMMENT ON COLUMN operation_logs.target_id IS '目标ID';
 COMMENT ON COLUMN operation_logs.detail IS '详细信息（JSON）';
+
+COMMENT ON TABLE stream_metrics IS '直播健康指标时序表';
+COMMENT ON COLUMN stream_metrics.stream_id IS '关联的直播ID';
+COMMENT ON COLUMN stream_metrics.sampled_at IS '采样时间（降采样数据为时间桶起点）';
+COMMENT ON COLUMN stream_metrics.granularity IS '数据粒度（秒），0 表示原始采样';
+COMMENT ON COLUMN stream_metrics.bitrate IS '码率（kbps，降采样数据为平均值）';
+COMMENT ON COLUMN stream_metrics.bitrate_min IS '最低码率（kbps）';
+COMMENT ON COLUMN stream_metrics.fps IS '帧率（降采样数据为平均值）';
+COMMENT ON COLUMN stream_metrics.fps_min IS '最低帧率';
+COMMENT ON COLUMN stream_metrics.reader_count IS '观看人数（降采样数据为最大值）';
+COMMENT ON COLUMN stream_metrics.bytes_speed IS '数据速率（字节/秒）';
+
+COMMENT ON TABLE daily_stats IS '每日统计表';
+COMMENT ON COLUMN daily_stats.stat_date IS '统计日期';
+COMMENT ON COLUMN daily_stats.streams_created IS '新建直播数';
+COMMENT ON COLUMN daily_stats.publishes IS '推流次数';
+COMMENT ON COLUMN daily_stats.plays IS '观看人次';
+COMMENT ON COLUMN daily_stats.peak_viewers IS '全站并发观看人数峰值';
+COMMENT ON COLUMN daily_stats.peak_streams IS '同时推流数峰值';
+COMMENT ON COLUMN daily_stats.record_files IS '录制文件数';
+COMMENT ON COLUMN daily_stats.record_seconds IS '录制时长（秒）';
+COMMENT ON COLUMN daily_stats.record_bytes IS '录制文件大小（字节）';
+COMMENT ON COLUMN daily_stats.share_code_uses IS '分享码兑换次数';
+COMMENT ON COLUMN daily_stats.share_link_uses IS '分享链接兑换次数';
+
+COMMENT ON TABLE viewer_sessions IS '观看会话历史表';
+COMMENT ON COLUMN viewer_sessions.stream_id IS '关联的直播ID';
+COMMENT ON COLUMN viewer_sessions.session_id IS '会话标识（mediaServerId|播放器唯一标识）';
+COMMENT ON COLUMN viewer_sessions.media_server_id IS '播放所在 ZLMediaKit 节点';
+COMMENT ON COLUMN viewer_sessions.ip IS '观众 IP';
+COMMENT ON COLUMN viewer_sessions.protocol IS '播放协议';
+COMMENT ON COLUMN viewer_sessions.started_at IS '开始时间';
+COMMENT ON COLUMN viewer_sessions.ended_at IS '结束时间';
+COMMENT ON COLUMN viewer_sessions.end_reason IS '结束原因: disconnect / timeout / stream_end';
+
+COMMENT ON TABLE recordings IS '录制文件表';
+COMMENT ON COLUMN recordings.stream_id IS '关联的直播ID';
+COMMENT ON COLUMN recordings.media_server_id IS '录制所在 ZLMediaKit 节点';
+COMMENT ON COLUMN recordings.format IS '录制格式: mp4 / hls';
+COMMENT ON COLUMN recordings.file_name IS '文件名';
+COMMENT ON COLUMN recordings.local_path IS 'ZLMediaKit 节点上的文件绝对路径';
+COMMENT ON COLUMN recordings.url IS 'ZLMediaKit 返回的相对访问地址';
+COMMENT ON COLUMN recordings.file_size IS '文件大小（字节）';
+COMMENT ON COLUMN recordings.duration IS '时长（秒）';
+COMMENT ON COLUMN recordings.start_time IS '录制开始时间';
+COMMENT ON COLUMN recordings.end_time IS '录制结束时间';
+COMMENT ON COLUMN recordings.checksum IS '文件 SHA-256';
+COMMENT ON COLUMN recordings.status IS '录制状态: recording（HLS 录制中） / completed';
+COMMENT ON COLUMN recordings.starred IS '是否收藏（收藏的录制永久保留）';
+COMMENT ON COLUMN recordings.local_deleted_at IS 'ZLMediaKit 节点上的本地文件删除时间';
+
+COMMENT ON TABLE recording_uploads IS '录制文件上传状态表';
+COMMENT ON COLUMN recording_uploads.recording_id IS '关联的录制文件ID';
+COMMENT ON COLUMN recording_uploads.storage IS '存储目标名称';
+COMMENT ON COLUMN recording_uploads.status IS '上传状态: pending / uploading / success / failed / deleted';
+COMMENT ON COLUMN recording_uploads.remote_path IS '存储目标中的相对路径';
+COMMENT ON COLUMN recording_uploads.remote_url IS '上传成功后的访问地址';
+COMMENT ON COLUMN recording_uploads.error IS '上传失败原因';
+COMMENT ON COLUMN recording_uploads.attempts IS '已尝试次数';
+COMMENT ON COLUMN recording_uploads.next_attempt_at IS '下次尝试时间';
+COMMENT ON COLUMN recording_uploads.locked_until IS '上传中任务的租约到期时间，过期后可被重新领取';
+
+COMMENT ON TABLE recording_segments IS 'HLS 录制切片表';
+COMMENT ON COLUMN recording_segments.recording_id IS '关联的录制文件ID';
+COMMENT ON COLUMN recording_segments.seq IS '切片序号（从 0 开始）';
+COMMENT ON COLUMN recording_segments.file_name IS '切片文件名';
+COMMENT ON COLUMN recording_segments.local_path IS 'ZLMediaKit 节点上的切片绝对路径';
+COMMENT ON COLUMN recording_segments.url IS 'ZLMediaKit 返回的相对访问地址';
+COMMENT ON COLUMN recording_segments.file_size IS '切片大小（字节）';
+COMMENT ON COLUMN recording_segments.duration IS '切片时长（秒）';
+COMMENT ON COLUMN recording_segments.start_time IS '切片开始时间';
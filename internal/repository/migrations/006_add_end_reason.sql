@@ -0,0 +1,6 @@
+-- 迁移脚本: 添加直播结束原因字段
+-- 用于记录直播被结束的原因（手动结束 / 超时自动结束 / 空流检测）
+
+ALTER TABLE streams ADD COLUMN IF NOT EXISTS end_reason VARCHAR(128);
+
+COMMENT ON COLUMN streams.end_reason IS '结束原因：manual/auto_timeout/empty_stream';
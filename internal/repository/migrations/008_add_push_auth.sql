@@ -0,0 +1,12 @@
+-- 迁移脚本: 添加推流鉴权字段
+-- 开启推流鉴权后，推流地址需携带由推流密钥签名的 expire 和 sign 参数
+
+ALTER TABLE streams ADD COLUMN IF NOT EXISTS push_secret VARCHAR(64);
+ALTER TABLE streams ADD COLUMN IF NOT EXISTS push_auth_enabled BOOLEAN DEFAULT FALSE;
+
+-- 为已有直播生成推流密钥
+UPDATE streams SET push_secret = md5(random()::text || id::text || clock_timestamp()::text)
+WHERE push_secret IS NULL;
+
+COMMENT ON COLUMN streams.push_secret IS '推流签名密钥';
+COMMENT ON COLUMN streams.push_auth_enabled IS '是否开启推流鉴权（推流地址需携带签名）';
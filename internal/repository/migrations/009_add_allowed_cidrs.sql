@@ -0,0 +1,7 @@
+-- 迁移脚本: 添加推流 IP 白名单字段
+-- 配置后只允许来自白名单网段的推流；device_id 不为空时推流参数需携带一致的 device_id
+
+ALTER TABLE streams ADD COLUMN IF NOT EXISTS allowed_cidrs JSONB DEFAULT '[]';
+
+COMMENT ON COLUMN streams.allowed_cidrs IS '推流 IP 白名单（CIDR 列表，JSON数组，为空表示不限制）';
+COMMENT ON COLUMN streams.device_id IS '设备ID（设置后推流参数需携带一致的 device_id）';
@@ -0,0 +1,12 @@
+-- 迁移脚本: 添加直播质量字段
+-- 由定时采样任务根据 ZLMediaKit 媒体列表填充分辨率和编码信息
+
+ALTER TABLE streams ADD COLUMN IF NOT EXISTS width INTEGER DEFAULT 0;
+ALTER TABLE streams ADD COLUMN IF NOT EXISTS height INTEGER DEFAULT 0;
+ALTER TABLE streams ADD COLUMN IF NOT EXISTS video_codec VARCHAR(32);
+ALTER TABLE streams ADD COLUMN IF NOT EXISTS audio_codec VARCHAR(32);
+
+COMMENT ON COLUMN streams.width IS '视频宽度（像素）';
+COMMENT ON COLUMN streams.height IS '视频高度（像素）';
+COMMENT ON COLUMN streams.video_codec IS '视频编码：H264/H265 等';
+COMMENT ON COLUMN streams.audio_codec IS '音频编码：AAC/opus 等';
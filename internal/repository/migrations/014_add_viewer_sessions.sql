@@ -0,0 +1,27 @@
+-- 迁移脚本: 添加观看会话历史表
+-- 每个播放会话（ZLMediaKit 播放器唯一标识）开始时插入一条记录，断开、心跳超时或直播结束时记录结束时间
+
+CREATE TABLE IF NOT EXISTS viewer_sessions (
+    id               BIGSERIAL PRIMARY KEY,
+    stream_id        INTEGER NOT NULL REFERENCES streams(id) ON DELETE CASCADE,
+    session_id       VARCHAR(160) NOT NULL,
+    media_server_id  VARCHAR(64),
+    ip               VARCHAR(64),
+    protocol         VARCHAR(16),
+    started_at       TIMESTAMP NOT NULL,
+    ended_at         TIMESTAMP,
+    end_reason       VARCHAR(16)
+);
+
+CREATE INDEX IF NOT EXISTS idx_viewer_sessions_stream_time ON viewer_sessions(stream_id, started_at);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_viewer_sessions_open ON viewer_sessions(session_id) WHERE ended_at IS NULL;
+
+COMMENT ON TABLE viewer_sessions IS '观看会话历史表';
+COMMENT ON COLUMN viewer_sessions.stream_id IS '关联的直播ID';
+COMMENT ON COLUMN viewer_sessions.session_id IS '会话标识（mediaServerId|播放器唯一标识）';
+COMMENT ON COLUMN viewer_sessions.media_server_id IS '播放所在 ZLMediaKit 节点';
+COMMENT ON COLUMN viewer_sessions.ip IS '观众 IP';
+COMMENT ON COLUMN viewer_sessions.protocol IS '播放协议';
+COMMENT ON COLUMN viewer_sessions.started_at IS '开始时间';
+COMMENT ON COLUMN viewer_sessions.ended_at IS '结束时间';
+COMMENT ON COLUMN viewer_sessions.end_reason IS '结束原因: disconnect / timeout / stream_end';
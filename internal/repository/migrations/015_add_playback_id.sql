@@ -0,0 +1,13 @@
+-- 迁移脚本: 添加播放ID字段
+-- 推流时通过 on_publish 回调的 stream_replace 将媒体注册为 playback_id，观众使用 playback_id 播放，不再暴露推流密钥
+
+ALTER TABLE streams ADD COLUMN IF NOT EXISTS playback_id VARCHAR(64);
+
+-- 为已有直播生成播放ID
+UPDATE streams SET playback_id = 'play_' || substr(md5(random()::text || id::text || clock_timestamp()::text), 1, 24)
+WHERE playback_id IS NULL;
+
+ALTER TABLE streams ALTER COLUMN playback_id SET NOT NULL;
+CREATE UNIQUE INDEX IF NOT EXISTS idx_streams_playback_id ON streams(playback_id);
+
+COMMENT ON COLUMN streams.playback_id IS '播放ID（观众播放使用的 ZLMediaKit 流名称，与推流密钥分离）';
@@ -0,0 +1,55 @@
+-- 迁移脚本: 添加录制文件目录
+-- 每个录制分段（on_record_mp4 回调）一条记录，保存录制元数据；recording_uploads 记录各存储目标的上传状态
+-- streams.record_files 保留用于兼容，新代码以 recordings 为准
+
+CREATE TABLE IF NOT EXISTS recordings (
+    id               BIGSERIAL PRIMARY KEY,
+    stream_id        INTEGER NOT NULL REFERENCES streams(id) ON DELETE CASCADE,
+    media_server_id  VARCHAR(64),
+    format           VARCHAR(16) NOT NULL,
+    file_name        VARCHAR(255) NOT NULL,
+    local_path       TEXT NOT NULL,
+    url              TEXT,
+    file_size        BIGINT DEFAULT 0,
+    duration         DOUBLE PRECISION DEFAULT 0,
+    start_time       TIMESTAMP NOT NULL,
+    end_time         TIMESTAMP NOT NULL,
+    checksum         VARCHAR(64),
+    created_at       TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_recordings_stream_time ON recordings(stream_id, start_time);
+
+CREATE TABLE IF NOT EXISTS recording_uploads (
+    id            BIGSERIAL PRIMARY KEY,
+    recording_id  BIGINT NOT NULL REFERENCES recordings(id) ON DELETE CASCADE,
+    storage       VARCHAR(64) NOT NULL,
+    status        VARCHAR(16) NOT NULL,
+    remote_path   TEXT NOT NULL,
+    remote_url    TEXT,
+    error         TEXT,
+    created_at    TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at    TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE (recording_id, storage)
+);
+
+COMMENT ON TABLE recordings IS '录制文件表';
+COMMENT ON COLUMN recordings.stream_id IS '关联的直播ID';
+COMMENT ON COLUMN recordings.media_server_id IS '录制所在 ZLMediaKit 节点';
+COMMENT ON COLUMN recordings.format IS '录制格式: mp4';
+COMMENT ON COLUMN recordings.file_name IS '文件名';
+COMMENT ON COLUMN recordings.local_path IS 'ZLMediaKit 节点上的文件绝对路径';
+COMMENT ON COLUMN recordings.url IS 'ZLMediaKit 返回的相对访问地址';
+COMMENT ON COLUMN recordings.file_size IS '文件大小（字节）';
+COMMENT ON COLUMN recordings.duration IS '时长（秒）';
+COMMENT ON COLUMN recordings.start_time IS '录制开始时间';
+COMMENT ON COLUMN recordings.end_time IS '录制结束时间';
+COMMENT ON COLUMN recordings.checksum IS '文件 SHA-256';
+
+COMMENT ON TABLE recording_uploads IS '录制文件上传状态表';
+COMMENT ON COLUMN recording_uploads.recording_id IS '关联的录制文件ID';
+COMMENT ON COLUMN recording_uploads.storage IS '存储目标名称';
+COMMENT ON COLUMN recording_uploads.status IS '上传状态: pending / success / failed';
+COMMENT ON COLUMN recording_uploads.remote_path IS '存储目标中的相对路径';
+COMMENT ON COLUMN recording_uploads.remote_url IS '上传成功后的访问地址';
+COMMENT ON COLUMN recording_uploads.error IS '上传失败原因';
@@ -0,0 +1,34 @@
+-- 迁移脚本: HLS 录制
+-- streams.record_format 指定录制格式；HLS 录制每次推流登记为一条 recordings 记录，切片保存在 recording_segments
+
+ALTER TABLE streams ADD COLUMN IF NOT EXISTS record_format VARCHAR(8) NOT NULL DEFAULT 'mp4';
+
+ALTER TABLE recordings ADD COLUMN IF NOT EXISTS status VARCHAR(16) NOT NULL DEFAULT 'completed';
+
+CREATE TABLE IF NOT EXISTS recording_segments (
+    id            BIGSERIAL PRIMARY KEY,
+    recording_id  BIGINT NOT NULL REFERENCES recordings(id) ON DELETE CASCADE,
+    seq           INTEGER NOT NULL,
+    file_name     VARCHAR(255) NOT NULL,
+    local_path    TEXT NOT NULL,
+    url           TEXT NOT NULL,
+    file_size     BIGINT DEFAULT 0,
+    duration      DOUBLE PRECISION DEFAULT 0,
+    start_time    TIMESTAMP NOT NULL,
+    created_at    TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE (recording_id, seq)
+);
+
+COMMENT ON COLUMN streams.record_format IS '录制格式: mp4 / hls / both';
+COMMENT ON COLUMN recordings.format IS '录制格式: mp4 / hls';
+COMMENT ON COLUMN recordings.status IS '录制状态: recording（HLS 录制中） / completed';
+
+COMMENT ON TABLE recording_segments IS 'HLS 录制切片表';
+COMMENT ON COLUMN recording_segments.recording_id IS '关联的录制文件ID';
+COMMENT ON COLUMN recording_segments.seq IS '切片序号（从 0 开始）';
+COMMENT ON COLUMN recording_segments.file_name IS '切片文件名';
+COMMENT ON COLUMN recording_segments.local_path IS 'ZLMediaKit 节点上的切片绝对路径';
+COMMENT ON COLUMN recording_segments.url IS 'ZLMediaKit 返回的相对访问地址';
+COMMENT ON COLUMN recording_segments.file_size IS '切片大小（字节）';
+COMMENT ON COLUMN recording_segments.duration IS '切片时长（秒）';
+COMMENT ON COLUMN recording_segments.start_time IS '切片开始时间';
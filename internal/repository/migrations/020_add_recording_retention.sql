@@ -0,0 +1,13 @@
+-- 迁移脚本: 录制文件保留策略
+-- 直播可单独设置录制保留天数，录制文件支持收藏（永久保留），记录本地文件删除时间；上传状态新增 deleted（存储目标上的副本已删除）
+
+ALTER TABLE streams ADD COLUMN IF NOT EXISTS retention_days INTEGER;
+ALTER TABLE recordings ADD COLUMN IF NOT EXISTS starred BOOLEAN NOT NULL DEFAULT FALSE;
+ALTER TABLE recordings ADD COLUMN IF NOT EXISTS local_deleted_at TIMESTAMP;
+
+CREATE INDEX IF NOT EXISTS idx_recordings_end_time ON recordings(end_time);
+
+COMMENT ON COLUMN streams.retention_days IS '录制保留天数，为空使用全局保留策略，0 表示永久保留';
+COMMENT ON COLUMN recordings.starred IS '是否收藏（收藏的录制永久保留）';
+COMMENT ON COLUMN recordings.local_deleted_at IS 'ZLMediaKit 节点上的本地文件删除时间';
+COMMENT ON COLUMN recording_uploads.status IS '上传状态: pending / uploading / success / failed / deleted';
@@ -0,0 +1,117 @@
+package repository
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+
+	"easy-stream/internal/model"
+)
+
+type OperationLogRepository struct {
+	db *sql.DB
+}
+
+func NewOperationLogRepository(db *sql.DB) *OperationLogRepository {
+	return &OperationLogRepository{db: db}
+}
+
+// Create 写入操作日志
+func (r *OperationLogRepository) Create(log *model.OperationLog) error {
+	query := `
+		INSERT INTO operation_logs (user_id, action, target_type, target_id, detail, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		RETURNING id
+	`
+	var detail interface{}
+	if len(log.Detail) > 0 {
+		detail = []byte(log.Detail)
+	}
+	log.CreatedAt = time.Now()
+	return r.db.QueryRow(query,
+		log.UserID, log.Action, log.TargetType, log.TargetID, detail, log.CreatedAt,
+	).Scan(&log.ID)
+}
+
+// List 分页查询操作日志
+func (r *OperationLogRepository) List(req *model.OperationLogListRequest, offset, limit int) ([]*model.OperationLog, int64, error) {
+	var conditions []string
+	var args []interface{}
+	argIndex := 1
+
+	if req.UserID > 0 {
+		conditions = append(conditions, fmt.Sprintf("l.user_id = $%d", argIndex))
+		args = append(args, req.UserID)
+		argIndex++
+	}
+	if req.Action != "" {
+		conditions = append(conditions, fmt.Sprintf("l.action = $%d", argIndex))
+		args = append(args, req.Action)
+		argIndex++
+	}
+	if req.TargetType != "" {
+		conditions = append(conditions, fmt.Sprintf("l.target_type = $%d", argIndex))
+		args = append(args, req.TargetType)
+		argIndex++
+	}
+	if req.TargetID != "" {
+		conditions = append(conditions, fmt.Sprintf("l.target_id = $%d", argIndex))
+		args = append(args, req.TargetID)
+		argIndex++
+	}
+	if req.From != nil {
+		conditions = append(conditions, fmt.Sprintf("l.created_at >= $%d", argIndex))
+		args = append(args, *req.From)
+		argIndex++
+	}
+	if req.To != nil {
+		conditions = append(conditions, fmt.Sprintf("l.created_at < $%d", argIndex))
+		args = append(args, *req.To)
+		argIndex++
+	}
+
+	whereClause := ""
+	if len(conditions) > 0 {
+		whereClause = " WHERE " + strings.Join(conditions, " AND ")
+	}
+
+	// 查询总数
+	var total int64
+	countQuery := "SELECT COUNT(*) FROM operation_logs l" + whereClause
+	if err := r.db.QueryRow(countQuery, args...).Scan(&total); err != nil {
+		return nil, 0, err
+	}
+
+	// 查询列表
+	query := `
+		SELECT l.id, l.user_id, u.username, l.action,
+			   COALESCE(l.target_type, ''), COALESCE(l.target_id, ''), l.detail, l.created_at
+		FROM operation_logs l
+		LEFT JOIN users u ON u.id = l.user_id` + whereClause +
+		fmt.Sprintf(" ORDER BY l.created_at DESC, l.id DESC LIMIT $%d OFFSET $%d", argIndex, argIndex+1)
+
+	args = append(args, limit, offset)
+	rows, err := r.db.Query(query, args...)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows.Close()
+
+	logs := make([]*model.OperationLog, 0)
+	for rows.Next() {
+		l := &model.OperationLog{}
+		var detail []byte
+		if err := rows.Scan(
+			&l.ID, &l.UserID, &l.Username, &l.Action,
+			&l.TargetType, &l.TargetID, &detail, &l.CreatedAt,
+		); err != nil {
+			return nil, 0, err
+		}
+		if detail != nil {
+			l.Detail = detail
+		}
+		logs = append(logs, l)
+	}
+	return logs, total, nil
+}
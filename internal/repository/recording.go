@@ -0,0 +1,565 @@
+package repository
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+
+	"easy-stream/internal/model"
+)
+
+type RecordingRepository struct {
+	db *sql.DB
+}
+
+func NewRecordingRepository(db *sql.DB) *RecordingRepository {
+	return &RecordingRepository{db: db}
+}
+
+// recordingColumns 查询录制文件时使用的字段列表，顺序需与 scanRecording 保持一致
+const recordingColumns = `id, stream_id, COALESCE(media_server_id, ''), format, status, file_name, local_path, COALESCE(url, ''),
+			   file_size, duration, start_time, end_time, checksum, starred, local_deleted_at, created_at,
+			   (SELECT COUNT(*) FROM recording_segments WHERE recording_id = recordings.id)`
+
+// scanRecording 按 recordingColumns 的顺序扫描一行录制文件数据
+func scanRecording(row rowScanner) (*model.Recording, error) {
+	rec := &model.Recording{}
+	err := row.Scan(
+		&rec.ID, &rec.StreamID, &rec.MediaServerID, &rec.Format, &rec.Status, &rec.FileName, &rec.LocalPath, &rec.URL,
+		&rec.FileSize, &rec.Duration, &rec.StartTime, &rec.EndTime, &rec.Checksum, &rec.Starred, &rec.LocalDeletedAt, &rec.CreatedAt,
+		&rec.SegmentCount,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return rec, nil
+}
+
+// Create 创建录制文件记录
+func (r *RecordingRepository) Create(rec *model.Recording) error {
+	query := `
+		INSERT INTO recordings (
+			stream_id, media_server_id, format, status, file_name, local_path, url,
+			file_size, duration, start_time, end_time, created_at
+		)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12)
+		RETURNING id, created_at
+	`
+	return r.db.QueryRow(query,
+		rec.StreamID, rec.MediaServerID, rec.Format, rec.Status, rec.FileName, rec.LocalPath, rec.URL,
+		rec.FileSize, rec.Duration, rec.StartTime, rec.EndTime, time.Now(),
+	).Scan(&rec.ID, &rec.CreatedAt)
+}
+
+// GetByID 根据 ID 获取录制文件（含上传状态）
+func (r *RecordingRepository) GetByID(id int64) (*model.Recording, error) {
+	query := `SELECT ` + recordingColumns + ` FROM recordings WHERE id = $1`
+
+	rec, err := scanRecording(r.db.QueryRow(query, id))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := r.loadUploads([]*model.Recording{rec}); err != nil {
+		return nil, err
+	}
+	return rec, nil
+}
+
+// ListByStream 分页获取直播的录制文件（含上传状态），按录制开始时间倒序
+func (r *RecordingRepository) ListByStream(streamID int64, req *model.RecordingListRequest, offset, limit int) ([]*model.Recording, int64, error) {
+	conditions := []string{"stream_id = $1"}
+	args := []interface{}{streamID}
+	argIndex := 2
+
+	if req.Format != "" {
+		conditions = append(conditions, fmt.Sprintf("format = $%d", argIndex))
+		args = append(args, req.Format)
+		argIndex++
+	}
+	if req.Status != "" {
+		conditions = append(conditions, fmt.Sprintf("status = $%d", argIndex))
+		args = append(args, req.Status)
+		argIndex++
+	}
+	if req.From != nil {
+		conditions = append(conditions, fmt.Sprintf("start_time >= $%d", argIndex))
+		args = append(args, *req.From)
+		argIndex++
+	}
+	if req.To != nil {
+		conditions = append(conditions, fmt.Sprintf("start_time < $%d", argIndex))
+		args = append(args, *req.To)
+		argIndex++
+	}
+	whereClause := " WHERE " + strings.Join(conditions, " AND ")
+
+	var total int64
+	if err := r.db.QueryRow("SELECT COUNT(*) FROM recordings"+whereClause, args...).Scan(&total); err != nil {
+		return nil, 0, err
+	}
+
+	query := fmt.Sprintf(`SELECT `+recordingColumns+` FROM recordings%s ORDER BY start_time DESC, id DESC LIMIT $%d OFFSET $%d`,
+		whereClause, argIndex, argIndex+1)
+	args = append(args, limit, offset)
+
+	rows, err := r.db.Query(query, args...)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows.Close()
+
+	recordings := make([]*model.Recording, 0)
+	for rows.Next() {
+		rec, err := scanRecording(rows)
+		if err != nil {
+			return nil, 0, err
+		}
+		recordings = append(recordings, rec)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, 0, err
+	}
+
+	if err := r.loadUploads(recordings); err != nil {
+		return nil, 0, err
+	}
+	return recordings, total, nil
+}
+
+// GetLatestHLS 获取直播最近一条 HLS 录制，没有时返回 nil
+func (r *RecordingRepository) GetLatestHLS(streamID int64) (*model.Recording, error) {
+	query := `SELECT ` + recordingColumns + ` FROM recordings
+		WHERE stream_id = $1 AND format = $2
+		ORDER BY start_time DESC, id DESC LIMIT 1`
+
+	rec, err := scanRecording(r.db.QueryRow(query, streamID, model.RecordingFormatHLS))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	return rec, err
+}
+
+// AddSegment 追加 HLS 切片，同时累加录制的时长、大小并更新结束时间
+func (r *RecordingRepository) AddSegment(seg *model.RecordingSegment) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	// 锁定录制记录，保证切片序号连续
+	var endTime time.Time
+	if err := tx.QueryRow("SELECT end_time FROM recordings WHERE id = $1 FOR UPDATE", seg.RecordingID).Scan(&endTime); err != nil {
+		return err
+	}
+
+	query := `
+		INSERT INTO recording_segments (recording_id, seq, file_name, local_path, url, file_size, duration, start_time, created_at)
+		SELECT $1, COALESCE(MAX(seq) + 1, 0), $2, $3, $4, $5, $6, $7, $8
+		FROM recording_segments WHERE recording_id = $1
+		RETURNING id, seq, created_at
+	`
+	if err := tx.QueryRow(query, seg.RecordingID, seg.FileName, seg.LocalPath, seg.URL,
+		seg.FileSize, seg.Duration, seg.StartTime, time.Now(),
+	).Scan(&seg.ID, &seg.Seq, &seg.CreatedAt); err != nil {
+		return err
+	}
+
+	segEnd := seg.StartTime.Add(time.Duration(seg.Duration * float64(time.Second)))
+	if segEnd.Before(endTime) {
+		segEnd = endTime
+	}
+	update := `
+		UPDATE recordings
+		SET file_size = file_size + $1, duration = duration + $2, end_time = $3
+		WHERE id = $4
+	`
+	if _, err := tx.Exec(update, seg.FileSize, seg.Duration, segEnd, seg.RecordingID); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// ListSegments 获取 HLS 录制的所有切片，按序号排列
+func (r *RecordingRepository) ListSegments(recordingID int64) ([]*model.RecordingSegment, error) {
+	query := `
+		SELECT id, recording_id, seq, file_name, local_path, url, file_size, duration, start_time, created_at
+		FROM recording_segments WHERE recording_id = $1 ORDER BY seq
+	`
+	rows, err := r.db.Query(query, recordingID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	segments := make([]*model.RecordingSegment, 0)
+	for rows.Next() {
+		seg := &model.RecordingSegment{}
+		if err := rows.Scan(&seg.ID, &seg.RecordingID, &seg.Seq, &seg.FileName, &seg.LocalPath, &seg.URL,
+			&seg.FileSize, &seg.Duration, &seg.StartTime, &seg.CreatedAt); err != nil {
+			return nil, err
+		}
+		segments = append(segments, seg)
+	}
+	return segments, rows.Err()
+}
+
+// CompleteRecordings 将直播录制中的 HLS 录制标记为已完成，返回被标记的录制 ID
+func (r *RecordingRepository) CompleteRecordings(streamID int64) ([]int64, error) {
+	rows, err := r.db.Query("UPDATE recordings SET status = $1 WHERE stream_id = $2 AND status = $3 RETURNING id",
+		model.RecordingStatusCompleted, streamID, model.RecordingStatusRecording)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	ids := make([]int64, 0)
+	for rows.Next() {
+		var id int64
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+	return ids, rows.Err()
+}
+
+// UpdateStatus 更新录制状态
+func (r *RecordingRepository) UpdateStatus(id int64, status string) error {
+	_, err := r.db.Exec("UPDATE recordings SET status = $1 WHERE id = $2", status, id)
+	return err
+}
+
+// UpdateChecksum 更新文件校验和
+func (r *RecordingRepository) UpdateChecksum(id int64, checksum string) error {
+	_, err := r.db.Exec("UPDATE recordings SET checksum = $1 WHERE id = $2", checksum, id)
+	return err
+}
+
+// UpdateStarred 更新收藏状态
+func (r *RecordingRepository) UpdateStarred(id int64, starred bool) error {
+	_, err := r.db.Exec("UPDATE recordings SET starred = $1 WHERE id = $2", starred, id)
+	return err
+}
+
+// ListForRetention 按 ID 顺序分批获取需要检查保留策略的录制文件（含上传状态和所属直播的保留天数）
+// 包括已完成、未收藏，且本地文件未删除或仍有存储目标副本的录制
+func (r *RecordingRepository) ListForRetention(afterID int64, limit int) ([]*model.Recording, error) {
+	query := `
+		SELECT ` + recordingColumns + `,
+			   (SELECT retention_days FROM streams WHERE streams.id = recordings.stream_id)
+		FROM recordings
+		WHERE id > $1 AND status = $2 AND starred = FALSE
+		  AND (local_deleted_at IS NULL OR EXISTS (
+			  SELECT 1 FROM recording_uploads WHERE recording_id = recordings.id AND status = $3
+		  ))
+		ORDER BY id LIMIT $4
+	`
+	rows, err := r.db.Query(query, afterID, model.RecordingStatusCompleted, model.UploadStatusSuccess, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	recordings := make([]*model.Recording, 0)
+	for rows.Next() {
+		rec := &model.Recording{}
+		if err := rows.Scan(
+			&rec.ID, &rec.StreamID, &rec.MediaServerID, &rec.Format, &rec.Status, &rec.FileName, &rec.LocalPath, &rec.URL,
+			&rec.FileSize, &rec.Duration, &rec.StartTime, &rec.EndTime, &rec.Checksum, &rec.Starred, &rec.LocalDeletedAt, &rec.CreatedAt,
+			&rec.SegmentCount, &rec.StreamRetentionDays,
+		); err != nil {
+			return nil, err
+		}
+		recordings = append(recordings, rec)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	if err := r.loadUploads(recordings); err != nil {
+		return nil, err
+	}
+	return recordings, nil
+}
+
+// MarkLocalDeleted 记录本地文件已删除，MP4 录制同时从直播的 record_files 中移除
+func (r *RecordingRepository) MarkLocalDeleted(rec *model.Recording) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	now := time.Now()
+	if _, err := tx.Exec("UPDATE recordings SET local_deleted_at = $1 WHERE id = $2", now, rec.ID); err != nil {
+		return err
+	}
+	if rec.Format == model.RecordingFormatMP4 {
+		query := `UPDATE streams SET record_files = record_files - $1, updated_at = $2 WHERE id = $3`
+		if _, err := tx.Exec(query, rec.LocalPath, now, rec.StreamID); err != nil {
+			return err
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+	rec.LocalDeletedAt = &now
+	return nil
+}
+
+// Delete 删除录制文件记录（上传状态随外键级联删除）
+func (r *RecordingRepository) Delete(id int64) error {
+	_, err := r.db.Exec("DELETE FROM recordings WHERE id = $1", id)
+	return err
+}
+
+// uploadColumns 查询上传状态时使用的字段列表，顺序需与 scanUpload 保持一致
+const uploadColumns = `u.id, u.recording_id, u.storage, u.status, u.remote_path, u.remote_url, u.error,
+			   u.attempts, u.next_attempt_at, u.created_at, u.updated_at`
+
+// scanUpload 按 uploadColumns 的顺序扫描一行上传状态
+func scanUpload(row rowScanner) (*model.RecordingUpload, error) {
+	u := &model.RecordingUpload{}
+	err := row.Scan(
+		&u.ID, &u.RecordingID, &u.Storage, &u.Status, &u.RemotePath, &u.RemoteURL, &u.Error,
+		&u.Attempts, &u.NextAttemptAt, &u.CreatedAt, &u.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return u, nil
+}
+
+// uploadJobColumns 上传状态字段加所属录制文件信息，顺序需与 scanUploadJob 保持一致
+const uploadJobColumns = uploadColumns + `, r.stream_id, r.format, r.file_name, r.local_path`
+
+// scanUploadJob 按 uploadJobColumns 的顺序扫描一行上传任务
+func scanUploadJob(row rowScanner) (*model.RecordingUpload, error) {
+	u := &model.RecordingUpload{}
+	err := row.Scan(
+		&u.ID, &u.RecordingID, &u.Storage, &u.Status, &u.RemotePath, &u.RemoteURL, &u.Error,
+		&u.Attempts, &u.NextAttemptAt, &u.CreatedAt, &u.UpdatedAt,
+		&u.StreamID, &u.Format, &u.FileName, &u.LocalPath,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return u, nil
+}
+
+// EnqueueUpload 添加上传任务，同一录制文件的同一存储目标只保留一条，已存在时重置为待上传
+func (r *RecordingRepository) EnqueueUpload(upload *model.RecordingUpload) error {
+	query := `
+		INSERT INTO recording_uploads (recording_id, storage, status, remote_path, attempts, next_attempt_at, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, 0, $5, $5, $5)
+		ON CONFLICT (recording_id, storage) DO UPDATE SET
+			status = EXCLUDED.status,
+			remote_path = EXCLUDED.remote_path,
+			remote_url = NULL,
+			error = NULL,
+			attempts = 0,
+			next_attempt_at = EXCLUDED.next_attempt_at,
+			locked_until = NULL,
+			updated_at = EXCLUDED.updated_at
+	`
+	_, err := r.db.Exec(query, upload.RecordingID, upload.Storage, model.UploadStatusPending, upload.RemotePath, time.Now())
+	return err
+}
+
+// ClaimUpload 领取一个到期的上传任务并标记为上传中，尝试次数加一
+// 上传中但租约已过期的任务（如服务在上传过程中重启）会被重新领取；没有可领取的任务时返回 nil
+func (r *RecordingRepository) ClaimUpload(lease time.Duration) (*model.RecordingUpload, error) {
+	now := time.Now()
+	query := `
+		WITH job AS (
+			UPDATE recording_uploads SET
+				status = $1, attempts = attempts + 1, locked_until = $2, updated_at = $3
+			WHERE id = (
+				SELECT id FROM recording_uploads
+				WHERE (status = $4 AND next_attempt_at <= $3) OR (status = $1 AND locked_until < $3)
+				ORDER BY next_attempt_at
+				LIMIT 1
+				FOR UPDATE SKIP LOCKED
+			)
+			RETURNING *
+		)
+		SELECT ` + uploadJobColumns + `
+		FROM job u JOIN recordings r ON r.id = u.recording_id
+	`
+	job, err := scanUploadJob(r.db.QueryRow(query, model.UploadStatusUploading, now.Add(lease), now, model.UploadStatusPending))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return job, nil
+}
+
+// CompleteUpload 标记上传任务成功
+// 只更新上传中的任务：上传过程中任务被重新添加（如 HLS 录制继续录制后再次完成）时保持待上传
+func (r *RecordingRepository) CompleteUpload(id int64, remoteURL string) error {
+	query := `
+		UPDATE recording_uploads
+		SET status = $1, remote_url = $2, error = NULL, locked_until = NULL, updated_at = $3
+		WHERE id = $4 AND status = $5
+	`
+	_, err := r.db.Exec(query, model.UploadStatusSuccess, remoteURL, time.Now(), id, model.UploadStatusUploading)
+	return err
+}
+
+// ExtendUploadLease 延长上传中任务的租约（HLS 录制逐个上传切片时调用，避免被其他 worker 重新领取）
+func (r *RecordingRepository) ExtendUploadLease(id int64, lease time.Duration) error {
+	now := time.Now()
+	query := `UPDATE recording_uploads SET locked_until = $1, updated_at = $2 WHERE id = $3 AND status = $4`
+	_, err := r.db.Exec(query, now.Add(lease), now, id, model.UploadStatusUploading)
+	return err
+}
+
+// FailUpload 记录上传失败：nextAttemptAt 不为空时等待重试，为空时标记为失败
+func (r *RecordingRepository) FailUpload(id int64, errMsg string, nextAttemptAt *time.Time) error {
+	status := model.UploadStatusFailed
+	next := time.Now()
+	if nextAttemptAt != nil {
+		status = model.UploadStatusPending
+		next = *nextAttemptAt
+	}
+	query := `
+		UPDATE recording_uploads
+		SET status = $1, error = $2, next_attempt_at = $3, locked_until = NULL, updated_at = $4
+		WHERE id = $5 AND status = $6
+	`
+	_, err := r.db.Exec(query, status, errMsg, next, time.Now(), id, model.UploadStatusUploading)
+	return err
+}
+
+// MarkUploadDeleted 标记存储目标上的副本已删除
+func (r *RecordingRepository) MarkUploadDeleted(id int64) error {
+	query := `
+		UPDATE recording_uploads
+		SET status = $1, remote_url = NULL, updated_at = $2
+		WHERE id = $3
+	`
+	_, err := r.db.Exec(query, model.UploadStatusDeleted, time.Now(), id)
+	return err
+}
+
+// GetUpload 根据 ID 获取上传任务（含所属录制文件信息）
+func (r *RecordingRepository) GetUpload(id int64) (*model.RecordingUpload, error) {
+	query := `SELECT ` + uploadJobColumns + `
+		FROM recording_uploads u JOIN recordings r ON r.id = u.recording_id
+		WHERE u.id = $1`
+
+	u, err := scanUploadJob(r.db.QueryRow(query, id))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return u, nil
+}
+
+// RetryUpload 将失败的上传任务重置为待上传，尝试次数清零
+func (r *RecordingRepository) RetryUpload(id int64) error {
+	query := `
+		UPDATE recording_uploads
+		SET status = $1, attempts = 0, next_attempt_at = $2, locked_until = NULL, updated_at = $2
+		WHERE id = $3
+	`
+	_, err := r.db.Exec(query, model.UploadStatusPending, time.Now(), id)
+	return err
+}
+
+// ListUploads 分页查询上传队列（含所属录制文件信息），按更新时间倒序
+func (r *RecordingRepository) ListUploads(req *model.UploadListRequest, offset, limit int) ([]*model.RecordingUpload, int64, error) {
+	conditions := []string{}
+	args := []interface{}{}
+	argIndex := 1
+
+	if req.Status != "" {
+		conditions = append(conditions, fmt.Sprintf("u.status = $%d", argIndex))
+		args = append(args, req.Status)
+		argIndex++
+	}
+	if req.Storage != "" {
+		conditions = append(conditions, fmt.Sprintf("u.storage = $%d", argIndex))
+		args = append(args, req.Storage)
+		argIndex++
+	}
+	whereClause := ""
+	if len(conditions) > 0 {
+		whereClause = " WHERE " + strings.Join(conditions, " AND ")
+	}
+
+	var total int64
+	if err := r.db.QueryRow("SELECT COUNT(*) FROM recording_uploads u"+whereClause, args...).Scan(&total); err != nil {
+		return nil, 0, err
+	}
+
+	query := fmt.Sprintf(`SELECT `+uploadJobColumns+`
+		FROM recording_uploads u JOIN recordings r ON r.id = u.recording_id%s
+		ORDER BY u.updated_at DESC, u.id DESC LIMIT $%d OFFSET $%d`, whereClause, argIndex, argIndex+1)
+	args = append(args, limit, offset)
+
+	rows, err := r.db.Query(query, args...)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows.Close()
+
+	uploads := make([]*model.RecordingUpload, 0)
+	for rows.Next() {
+		u, err := scanUploadJob(rows)
+		if err != nil {
+			return nil, 0, err
+		}
+		uploads = append(uploads, u)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, 0, err
+	}
+	return uploads, total, nil
+}
+
+// loadUploads 批量加载录制文件的上传状态
+func (r *RecordingRepository) loadUploads(recordings []*model.Recording) error {
+	if len(recordings) == 0 {
+		return nil
+	}
+
+	byID := make(map[int64]*model.Recording, len(recordings))
+	placeholders := make([]string, len(recordings))
+	args := make([]interface{}, len(recordings))
+	for i, rec := range recordings {
+		rec.Uploads = make([]*model.RecordingUpload, 0)
+		byID[rec.ID] = rec
+		placeholders[i] = fmt.Sprintf("$%d", i+1)
+		args[i] = rec.ID
+	}
+
+	query := `SELECT ` + uploadColumns + ` FROM recording_uploads u
+		WHERE u.recording_id IN (` + strings.Join(placeholders, ", ") + `)
+		ORDER BY u.id`
+	rows, err := r.db.Query(query, args...)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		u, err := scanUpload(rows)
+		if err != nil {
+			return err
+		}
+		if rec := byID[u.RecordingID]; rec != nil {
+			rec.Uploads = append(rec.Uploads, u)
+		}
+	}
+	return rows.Err()
+}
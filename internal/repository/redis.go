@@ -110,6 +110,232 @@ func (r *RedisClient) DeleteStreamAccessTokens(streamKey string) error {
 	return nil
 }
 
+// MigrateStreamKey 将 stream_key 相关的数据迁移到新的 stream_key（更换推流码时调用）
+// 包括访问令牌及其反向索引、观看会话、边缘节点回源拉流会话和观众去重记录，迁移时保留原有过期时间
+func (r *RedisClient) MigrateStreamKey(oldKey, newKey string) error {
+	ctx := context.Background()
+
+	// 访问令牌：stream_access:{streamKey}:{token}
+	prefix := fmt.Sprintf("stream_access:%s:", oldKey)
+	if err := r.renameByPrefix(prefix, fmt.Sprintf("stream_access:%s:", newKey), func(token string) error {
+		reverseKey := fmt.Sprintf("access_token_stream:%s", token)
+		return r.SetXX(ctx, reverseKey, newKey, redis.KeepTTL).Err()
+	}); err != nil {
+		return err
+	}
+
+	// 观众去重记录：viewer_identity:{streamKey}:{identity}
+	if err := r.renameByPrefix(fmt.Sprintf("viewer_identity:%s:", oldKey), fmt.Sprintf("viewer_identity:%s:", newKey), nil); err != nil {
+		return err
+	}
+
+	// 观看会话：viewer_sessions:{streamKey}，边缘节点回源拉流会话：viewer_edge_pulls:{streamKey}
+	for _, name := range []string{"viewer_sessions", "viewer_edge_pulls"} {
+		key := fmt.Sprintf("%s:%s", name, oldKey)
+		exists, err := r.Exists(ctx, key).Result()
+		if err != nil {
+			return err
+		}
+		if exists == 0 {
+			continue
+		}
+		if err := r.Rename(ctx, key, fmt.Sprintf("%s:%s", name, newKey)).Err(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// renameByPrefix 将以 oldPrefix 开头的 key 重命名为 newPrefix 开头（RENAME 保留过期时间）
+// renamed 不为空时，对每个重命名的 key 以去掉前缀后的部分回调
+func (r *RedisClient) renameByPrefix(oldPrefix, newPrefix string, renamed func(suffix string) error) error {
+	ctx := context.Background()
+	var cursor uint64
+	for {
+		keys, nextCursor, err := r.Scan(ctx, cursor, oldPrefix+"*", 100).Result()
+		if err != nil {
+			return err
+		}
+		for _, k := range keys {
+			suffix := k[len(oldPrefix):]
+			if err := r.Rename(ctx, k, newPrefix+suffix).Err(); err != nil {
+				// 扫描期间过期的 key 不再处理
+				if err.Error() == "ERR no such key" {
+					continue
+				}
+				return err
+			}
+			if renamed != nil {
+				if err := renamed(suffix); err != nil {
+					return err
+				}
+			}
+		}
+		cursor = nextCursor
+		if cursor == 0 {
+			break
+		}
+	}
+	return nil
+}
+
+// SetStreamKeyGrace 记录更换前的推流码，宽限期内旧推流码仍可推流
+func (r *RedisClient) SetStreamKeyGrace(oldKey, newKey string, expiration time.Duration) error {
+	ctx := context.Background()
+	key := fmt.Sprintf("stream_key_grace:%s", oldKey)
+	return r.Set(ctx, key, newKey, expiration).Err()
+}
+
+// GetStreamKeyGrace 获取旧推流码对应的新推流码，不在宽限期内时返回空
+func (r *RedisClient) GetStreamKeyGrace(oldKey string) (string, error) {
+	ctx := context.Background()
+	key := fmt.Sprintf("stream_key_grace:%s", oldKey)
+	val, err := r.Get(ctx, key).Result()
+	if err == redis.Nil {
+		return "", nil
+	}
+	return val, err
+}
+
+// AddViewerSession 添加观看会话（心跳时间为 now），会话已存在时返回 false
+func (r *RedisClient) AddViewerSession(streamKey, sessionID string, now time.Time) (bool, error) {
+	ctx := context.Background()
+	key := fmt.Sprintf("viewer_sessions:%s", streamKey)
+	added, err := r.ZAddNX(ctx, key, redis.Z{Score: float64(now.Unix()), Member: sessionID}).Result()
+	return added > 0, err
+}
+
+// TouchViewerSessions 刷新已存在会话的心跳时间
+func (r *RedisClient) TouchViewerSessions(streamKey string, sessionIDs []string, now time.Time) error {
+	if len(sessionIDs) == 0 {
+		return nil
+	}
+	ctx := context.Background()
+	key := fmt.Sprintf("viewer_sessions:%s", streamKey)
+	members := make([]redis.Z, 0, len(sessionIDs))
+	for _, id := range sessionIDs {
+		members = append(members, redis.Z{Score: float64(now.Unix()), Member: id})
+	}
+	return r.ZAddXX(ctx, key, members...).Err()
+}
+
+// RemoveViewerSession 移除观看会话，会话不存在时返回 false
+func (r *RedisClient) RemoveViewerSession(streamKey, sessionID string) (bool, error) {
+	ctx := context.Background()
+	key := fmt.Sprintf("viewer_sessions:%s", streamKey)
+	removed, err := r.ZRem(ctx, key, sessionID).Result()
+	return removed > 0, err
+}
+
+// ListViewerSessions 获取直播的所有观看会话
+func (r *RedisClient) ListViewerSessions(streamKey string) ([]string, error) {
+	ctx := context.Background()
+	key := fmt.Sprintf("viewer_sessions:%s", streamKey)
+	return r.ZRange(ctx, key, 0, -1).Result()
+}
+
+// ExpireViewerSessions 移除心跳时间早于 before 的会话，返回被移除的会话
+func (r *RedisClient) ExpireViewerSessions(streamKey string, before time.Time) ([]string, error) {
+	ctx := context.Background()
+	key := fmt.Sprintf("viewer_sessions:%s", streamKey)
+	max := "(" + strconv.FormatInt(before.Unix(), 10)
+	expired, err := r.ZRangeByScore(ctx, key, &redis.ZRangeBy{Min: "-inf", Max: max}).Result()
+	if err != nil || len(expired) == 0 {
+		return nil, err
+	}
+	members := make([]interface{}, len(expired))
+	for i, m := range expired {
+		members[i] = m
+	}
+	if err := r.ZRem(ctx, key, members...).Err(); err != nil {
+		return nil, err
+	}
+	return expired, nil
+}
+
+// CountViewerSessions 获取直播的观看会话数
+func (r *RedisClient) CountViewerSessions(streamKey string) (int64, error) {
+	ctx := context.Background()
+	key := fmt.Sprintf("viewer_sessions:%s", streamKey)
+	return r.ZCard(ctx, key).Result()
+}
+
+// ClearViewerSessions 删除直播的所有观看会话，返回被删除的会话
+func (r *RedisClient) ClearViewerSessions(streamKey string) ([]string, error) {
+	sessions, err := r.ListViewerSessions(streamKey)
+	if err != nil {
+		return nil, err
+	}
+	ctx := context.Background()
+	key := fmt.Sprintf("viewer_sessions:%s", streamKey)
+	return sessions, r.Del(ctx, key).Err()
+}
+
+// ViewerSessionStreams 获取所有存在观看会话的直播 stream_key
+func (r *RedisClient) ViewerSessionStreams() ([]string, error) {
+	ctx := context.Background()
+	const prefix = "viewer_sessions:"
+
+	var streamKeys []string
+	var cursor uint64
+	for {
+		keys, nextCursor, err := r.Scan(ctx, cursor, prefix+"*", 100).Result()
+		if err != nil {
+			return nil, err
+		}
+		for _, k := range keys {
+			streamKeys = append(streamKeys, k[len(prefix):])
+		}
+		cursor = nextCursor
+		if cursor == 0 {
+			break
+		}
+	}
+	return streamKeys, nil
+}
+
+// AddEdgePullSession 记录边缘节点回源拉流的播放会话（刷新心跳时不当作观众补记）
+func (r *RedisClient) AddEdgePullSession(streamKey, sessionID string) error {
+	ctx := context.Background()
+	key := fmt.Sprintf("viewer_edge_pulls:%s", streamKey)
+	return r.SAdd(ctx, key, sessionID).Err()
+}
+
+// RemoveEdgePullSession 移除边缘节点回源拉流的播放会话
+func (r *RedisClient) RemoveEdgePullSession(streamKey, sessionID string) error {
+	ctx := context.Background()
+	key := fmt.Sprintf("viewer_edge_pulls:%s", streamKey)
+	return r.SRem(ctx, key, sessionID).Err()
+}
+
+// ListEdgePullSessions 获取直播的所有边缘节点回源拉流会话
+func (r *RedisClient) ListEdgePullSessions(streamKey string) ([]string, error) {
+	ctx := context.Background()
+	key := fmt.Sprintf("viewer_edge_pulls:%s", streamKey)
+	return r.SMembers(ctx, key).Result()
+}
+
+// ClearEdgePullSessions 删除直播的所有边缘节点回源拉流会话
+func (r *RedisClient) ClearEdgePullSessions(streamKey string) error {
+	ctx := context.Background()
+	key := fmt.Sprintf("viewer_edge_pulls:%s", streamKey)
+	return r.Del(ctx, key).Err()
+}
+
+// MarkViewerIdentity 记录观众身份（去重窗口内只计一次观看人次），窗口内首次出现时返回 true
+func (r *RedisClient) MarkViewerIdentity(streamKey, identity string, window time.Duration) (bool, error) {
+	ctx := context.Background()
+	key := fmt.Sprintf("viewer_identity:%s:%s", streamKey, identity)
+	return r.SetNX(ctx, key, "1", window).Result()
+}
+
+// MarkHookSignature 记录已使用的 Hook 签名，签名已存在时返回 false（用于防重放）
+func (r *RedisClient) MarkHookSignature(signature string, expiration time.Duration) (bool, error) {
+	ctx := context.Background()
+	key := fmt.Sprintf("hook_sig:%s", signature)
+	return r.SetNX(ctx, key, "1", expiration).Result()
+}
+
 // GetStreamKeyByAccessToken 通过访问令牌获取 stream_key
 func (r *RedisClient) GetStreamKeyByAccessToken(token string) (string, error) {
 	ctx := context.Background()
@@ -5,6 +5,8 @@ import (
 	"log"
 	"time"
 
+	"easy-stream/internal/model"
+
 	"golang.org/x/crypto/bcrypt"
 )
 
@@ -31,12 +33,14 @@ func seedUsers(db *sql.DB) error {
 	users := []struct {
 		username string
 		password string
+		role     string
 		realName string
 		email    string
 	}{
-		{"admin", "admin123", "管理员", "admin@example.com"},
-		{"operator", "operator123", "操作员", "operator@example.com"},
-		{"viewer", "viewer123", "观众", "viewer@example.com"},
+		{"admin", "admin123", model.RoleAdmin, "管理员", "admin@example.com"},
+		{"operator", "operator123", model.RoleOperator, "操作员", "operator@example.com"},
+		{"manager", "manager123", model.RoleViewerManager, "观众管理员", "manager@example.com"},
+		{"viewer", "viewer123", model.RoleViewer, "观众", "viewer@example.com"},
 	}
 
 	for _, u := range users {
@@ -58,13 +62,13 @@ func seedUsers(db *sql.DB) error {
 
 		// 插入用户
 		_, err = db.Exec(`
-			INSERT INTO users (username, password_hash, real_name, email, created_at, updated_at)
-			VALUES ($1, $2, $3, $4, $5, $5)
-		`, u.username, string(hash), u.realName, u.email, time.Now())
+			INSERT INTO users (username, password_hash, role, real_name, email, created_at, updated_at)
+			VALUES ($1, $2, $3, $4, $5, $6, $6)
+		`, u.username, string(hash), u.role, u.realName, u.email, time.Now())
 		if err != nil {
 			return err
 		}
-		log.Printf("Debug模式: 创建用户 %s (密码: %s)", u.username, u.password)
+		log.Printf("Debug模式: 创建用户 %s (密码: %s, 角色: %s)", u.username, u.password, u.role)
 	}
 
 	return nil
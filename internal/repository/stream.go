@@ -17,59 +17,94 @@ func NewStreamRepository(db *sql.DB) *StreamRepository {
 	return &StreamRepository{db: db}
 }
 
+// streamColumns 查询推流时使用的字段列表，顺序需与 scanStream 保持一致
+const streamColumns = `id, stream_key, playback_id, name, description, device_id, status, visibility,
+			   share_code, share_code_max_uses, share_code_used_count,
+			   record_enabled, record_files, record_format, retention_days,
+			   protocol, bitrate, fps, width, height, video_codec, audio_codec, streamer_name, streamer_contact,
+			   scheduled_start_time, scheduled_end_time, auto_kick_delay,
+			   actual_start_time, actual_end_time, last_unpublish_at, last_frame_at, end_reason,
+			   push_secret, push_auth_enabled, allowed_cidrs, media_server_id,
+			   current_viewers, total_viewers, peak_viewers,
+			   created_by, created_at, updated_at`
+
+// rowScanner 兼容 *sql.Row 和 *sql.Rows
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+// scanStream 按 streamColumns 的顺序扫描一行推流数据
+func scanStream(row rowScanner) (*model.Stream, error) {
+	s := &model.Stream{}
+	err := row.Scan(
+		&s.ID, &s.StreamKey, &s.PlaybackID, &s.Name, &s.Description,
+		&s.DeviceID, &s.Status, &s.Visibility,
+		&s.ShareCode, &s.ShareCodeMaxUses, &s.ShareCodeUsedCount,
+		&s.RecordEnabled, &s.RecordFiles, &s.RecordFormat, &s.RetentionDays,
+		&s.Protocol, &s.Bitrate, &s.FPS,
+		&s.Width, &s.Height, &s.VideoCodec, &s.AudioCodec,
+		&s.StreamerName, &s.StreamerContact,
+		&s.ScheduledStartTime, &s.ScheduledEndTime, &s.AutoKickDelay,
+		&s.ActualStartTime, &s.ActualEndTime, &s.LastUnpublishAt, &s.LastFrameAt, &s.EndReason,
+		&s.PushSecret, &s.PushAuthEnabled, &s.AllowedCIDRs, &s.MediaServerID,
+		&s.CurrentViewers, &s.TotalViewers, &s.PeakViewers,
+		&s.CreatedBy, &s.CreatedAt, &s.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
 // Create 创建推流
 func (r *StreamRepository) Create(stream *model.Stream) error {
 	query := `
 		INSERT INTO streams (
-			stream_key, name, description, device_id, status, visibility,
+			stream_key, playback_id, name, description, device_id, status, visibility,
 			share_code, share_code_max_uses, share_code_used_count,
-			record_enabled, record_files,
+			record_enabled, record_files, record_format, retention_days,
 			streamer_name, streamer_contact, scheduled_start_time, scheduled_end_time,
-			auto_kick_delay, created_by, created_at, updated_at
+			auto_kick_delay, push_secret, push_auth_enabled, allowed_cidrs, created_by, created_at, updated_at
 		)
-		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17, $18, $19)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17, $18, $19, $20, $21, $22, $23, $24, $25)
 		RETURNING id
 	`
 	now := time.Now()
 	recordFiles, _ := stream.RecordFiles.Value()
+	allowedCIDRs, _ := stream.AllowedCIDRs.Value()
 	return r.db.QueryRow(query,
-		stream.StreamKey, stream.Name, stream.Description, stream.DeviceID,
+		stream.StreamKey, stream.PlaybackID, stream.Name, stream.Description, stream.DeviceID,
 		stream.Status, stream.Visibility,
 		stream.ShareCode, stream.ShareCodeMaxUses, stream.ShareCodeUsedCount,
-		stream.RecordEnabled, recordFiles,
+		stream.RecordEnabled, recordFiles, stream.RecordFormat, stream.RetentionDays,
 		stream.StreamerName, stream.StreamerContact,
 		stream.ScheduledStartTime, stream.ScheduledEndTime,
-		stream.AutoKickDelay, stream.CreatedBy, now, now,
+		stream.AutoKickDelay, stream.PushSecret, stream.PushAuthEnabled, allowedCIDRs, stream.CreatedBy, now, now,
 	).Scan(&stream.ID)
 }
 
 // GetByKey 根据 stream_key 获取
 func (r *StreamRepository) GetByKey(key string) (*model.Stream, error) {
 	query := `
-		SELECT id, stream_key, name, description, device_id, status, visibility,
-			   share_code, share_code_max_uses, share_code_used_count,
-			   record_enabled, record_files,
-			   protocol, bitrate, fps, streamer_name, streamer_contact,
-			   scheduled_start_time, scheduled_end_time, auto_kick_delay,
-			   actual_start_time, actual_end_time, last_unpublish_at, last_frame_at,
-			   current_viewers, total_viewers, peak_viewers,
-			   created_by, created_at, updated_at
+		SELECT ` + streamColumns + `
 		FROM streams WHERE stream_key = $1
 	`
 
-	stream := &model.Stream{}
-	err := r.db.QueryRow(query, key).Scan(
-		&stream.ID, &stream.StreamKey, &stream.Name, &stream.Description,
-		&stream.DeviceID, &stream.Status, &stream.Visibility,
-		&stream.ShareCode, &stream.ShareCodeMaxUses, &stream.ShareCodeUsedCount,
-		&stream.RecordEnabled, &stream.RecordFiles,
-		&stream.Protocol, &stream.Bitrate, &stream.FPS,
-		&stream.StreamerName, &stream.StreamerContact,
-		&stream.ScheduledStartTime, &stream.ScheduledEndTime, &stream.AutoKickDelay,
-		&stream.ActualStartTime, &stream.ActualEndTime, &stream.LastUnpublishAt, &stream.LastFrameAt,
-		&stream.CurrentViewers, &stream.TotalViewers, &stream.PeakViewers,
-		&stream.CreatedBy, &stream.CreatedAt, &stream.UpdatedAt,
-	)
+	stream, err := scanStream(r.db.QueryRow(query, key))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	return stream, err
+}
+
+// GetByPlaybackID 根据播放ID获取（ZLMediaKit 回调中的流名称）
+func (r *StreamRepository) GetByPlaybackID(playbackID string) (*model.Stream, error) {
+	query := `
+		SELECT ` + streamColumns + `
+		FROM streams WHERE playback_id = $1
+	`
+
+	stream, err := scanStream(r.db.QueryRow(query, playbackID))
 	if err == sql.ErrNoRows {
 		return nil, nil
 	}
@@ -79,30 +114,11 @@ func (r *StreamRepository) GetByKey(key string) (*model.Stream, error) {
 // GetByID 根据 ID 获取
 func (r *StreamRepository) GetByID(id int64) (*model.Stream, error) {
 	query := `
-		SELECT id, stream_key, name, description, device_id, status, visibility,
-			   share_code, share_code_max_uses, share_code_used_count,
-			   record_enabled, record_files,
-			   protocol, bitrate, fps, streamer_name, streamer_contact,
-			   scheduled_start_time, scheduled_end_time, auto_kick_delay,
-			   actual_start_time, actual_end_time, last_unpublish_at, last_frame_at,
-			   current_viewers, total_viewers, peak_viewers,
-			   created_by, created_at, updated_at
+		SELECT ` + streamColumns + `
 		FROM streams WHERE id = $1
 	`
 
-	stream := &model.Stream{}
-	err := r.db.QueryRow(query, id).Scan(
-		&stream.ID, &stream.StreamKey, &stream.Name, &stream.Description,
-		&stream.DeviceID, &stream.Status, &stream.Visibility,
-		&stream.ShareCode, &stream.ShareCodeMaxUses, &stream.ShareCodeUsedCount,
-		&stream.RecordEnabled, &stream.RecordFiles,
-		&stream.Protocol, &stream.Bitrate, &stream.FPS,
-		&stream.StreamerName, &stream.StreamerContact,
-		&stream.ScheduledStartTime, &stream.ScheduledEndTime, &stream.AutoKickDelay,
-		&stream.ActualStartTime, &stream.ActualEndTime, &stream.LastUnpublishAt, &stream.LastFrameAt,
-		&stream.CurrentViewers, &stream.TotalViewers, &stream.PeakViewers,
-		&stream.CreatedBy, &stream.CreatedAt, &stream.UpdatedAt,
-	)
+	stream, err := scanStream(r.db.QueryRow(query, id))
 	if err == sql.ErrNoRows {
 		return nil, nil
 	}
@@ -163,14 +179,7 @@ func (r *StreamRepository) List(req *model.StreamListRequest, offset, limit int)
 
 	// 查询列表
 	query := `
-		SELECT id, stream_key, name, description, device_id, status, visibility,
-			   share_code, share_code_max_uses, share_code_used_count,
-			   record_enabled, record_files,
-			   protocol, bitrate, fps, streamer_name, streamer_contact,
-			   scheduled_start_time, scheduled_end_time, auto_kick_delay,
-			   actual_start_time, actual_end_time, last_unpublish_at, last_frame_at,
-			   current_viewers, total_viewers, peak_viewers,
-			   created_by, created_at, updated_at
+		SELECT ` + streamColumns + `
 		FROM streams` + whereClause + ` ORDER BY created_at DESC LIMIT $` +
 		fmt.Sprintf("%d", argIndex) + ` OFFSET $` + fmt.Sprintf("%d", argIndex+1)
 
@@ -183,19 +192,7 @@ func (r *StreamRepository) List(req *model.StreamListRequest, offset, limit int)
 
 	streams := make([]*model.Stream, 0)
 	for rows.Next() {
-		s := &model.Stream{}
-		err := rows.Scan(
-			&s.ID, &s.StreamKey, &s.Name, &s.Description,
-			&s.DeviceID, &s.Status, &s.Visibility,
-			&s.ShareCode, &s.ShareCodeMaxUses, &s.ShareCodeUsedCount,
-			&s.RecordEnabled, &s.RecordFiles,
-			&s.Protocol, &s.Bitrate, &s.FPS,
-			&s.StreamerName, &s.StreamerContact,
-			&s.ScheduledStartTime, &s.ScheduledEndTime, &s.AutoKickDelay,
-			&s.ActualStartTime, &s.ActualEndTime, &s.LastUnpublishAt, &s.LastFrameAt,
-			&s.CurrentViewers, &s.TotalViewers, &s.PeakViewers,
-			&s.CreatedBy, &s.CreatedAt, &s.UpdatedAt,
-		)
+		s, err := scanStream(rows)
 		if err != nil {
 			return nil, 0, err
 		}
@@ -205,36 +202,82 @@ func (r *StreamRepository) List(req *model.StreamListRequest, offset, limit int)
 }
 
 // Update 更新推流信息
+// 不更新观看人数（current_viewers / total_viewers / peak_viewers），观看人数只通过对应的原子更新方法修改，
+// 避免用调用方读取的旧值覆盖并发写入
+// 不更新观看人数（current_viewers / total_viewers / peak_viewers），观看人数只通过对应的原子更新方法修改，
+// 避免用调用方读取的旧值覆盖并发写入
 func (r *StreamRepository) Update(stream *model.Stream) error {
 	query := `
 		UPDATE streams SET
 			name=$1, description=$2, device_id=$3, status=$4, visibility=$5,
 			share_code=$6, share_code_max_uses=$7, share_code_used_count=$8,
-			record_enabled=$9, record_files=$10,
-			protocol=$11, bitrate=$12, fps=$13,
-			streamer_name=$14, streamer_contact=$15,
-			scheduled_start_time=$16, scheduled_end_time=$17, auto_kick_delay=$18,
-			actual_start_time=$19, actual_end_time=$20, last_unpublish_at=$21, last_frame_at=$22,
-			current_viewers=$23, total_viewers=$24, peak_viewers=$25,
-			updated_at=$26
-		WHERE stream_key=$27
+			record_enabled=$9, record_files=$10, record_format=$11, retention_days=$12,
+			protocol=$13, bitrate=$14, fps=$15,
+			streamer_name=$16, streamer_contact=$17,
+			scheduled_start_time=$18, scheduled_end_time=$19, auto_kick_delay=$20,
+			actual_start_time=$21, actual_end_time=$22, last_unpublish_at=$23, last_frame_at=$24,
+			end_reason=$25, push_secret=$26, push_auth_enabled=$27, allowed_cidrs=$28,
+			media_server_id=$29,
+			updated_at=$30
+		WHERE stream_key=$31
 	`
 	recordFiles, _ := stream.RecordFiles.Value()
+	allowedCIDRs, _ := stream.AllowedCIDRs.Value()
 	_, err := r.db.Exec(query,
 		stream.Name, stream.Description, stream.DeviceID, stream.Status,
 		stream.Visibility,
 		stream.ShareCode, stream.ShareCodeMaxUses, stream.ShareCodeUsedCount,
-		stream.RecordEnabled, recordFiles,
+		stream.RecordEnabled, recordFiles, stream.RecordFormat, stream.RetentionDays,
 		stream.Protocol, stream.Bitrate, stream.FPS,
 		stream.StreamerName, stream.StreamerContact,
 		stream.ScheduledStartTime, stream.ScheduledEndTime, stream.AutoKickDelay,
 		stream.ActualStartTime, stream.ActualEndTime, stream.LastUnpublishAt, stream.LastFrameAt,
-		stream.CurrentViewers, stream.TotalViewers, stream.PeakViewers,
+		stream.EndReason, stream.PushSecret, stream.PushAuthEnabled, allowedCIDRs,
+		stream.MediaServerID,
 		time.Now(), stream.StreamKey,
 	)
 	return err
 }
 
+// UpdateQuality 更新直播质量信息（采样任务调用，只更新质量相关字段）
+func (r *StreamRepository) UpdateQuality(key string, q *model.StreamQuality) error {
+	query := `
+		UPDATE streams SET
+			bitrate = $1, fps = $2, width = $3, height = $4,
+			video_codec = COALESCE($5, video_codec), audio_codec = COALESCE($6, audio_codec),
+			last_frame_at = COALESCE($7, last_frame_at),
+			updated_at = $8
+		WHERE stream_key = $9
+	`
+	_, err := r.db.Exec(query,
+		q.Bitrate, q.FPS, q.Width, q.Height,
+		q.VideoCodec, q.AudioCodec, q.LastFrameAt,
+		time.Now(), key,
+	)
+	return err
+}
+
+// UpdateBitrate 更新码率（kbps）
+func (r *StreamRepository) UpdateBitrate(key string, bitrate int) error {
+	query := `UPDATE streams SET bitrate = $1, updated_at = $2 WHERE stream_key = $3`
+	_, err := r.db.Exec(query, bitrate, time.Now(), key)
+	return err
+}
+
+// UpdateStreamKey 更换推流码，share_links 通过外键 ON UPDATE CASCADE 同步更新
+func (r *StreamRepository) UpdateStreamKey(oldKey, newKey string) error {
+	query := `UPDATE streams SET stream_key=$1, updated_at=$2 WHERE stream_key=$3`
+	_, err := r.db.Exec(query, newKey, time.Now(), oldKey)
+	return err
+}
+
+// UpdatePlaybackID 更新播放ID
+func (r *StreamRepository) UpdatePlaybackID(key, playbackID string) error {
+	query := `UPDATE streams SET playback_id=$1, updated_at=$2 WHERE stream_key=$3`
+	_, err := r.db.Exec(query, playbackID, time.Now(), key)
+	return err
+}
+
 // UpdateStatus 更新状态
 func (r *StreamRepository) UpdateStatus(key, status string) error {
 	query := `UPDATE streams SET status=$1, updated_at=$2 WHERE stream_key=$3`
@@ -242,6 +285,22 @@ func (r *StreamRepository) UpdateStatus(key, status string) error {
 	return err
 }
 
+// MarkPushing 将仍为 idle 的直播改为推流中，记录推流所在节点，未记录开始时间时记录开始时间
+// 只更新状态、节点和开始时间，不覆盖其他字段；直播已不是 idle 时不更新，返回 false
+func (r *StreamRepository) MarkPushing(key, mediaServerID string, startTime time.Time) (bool, error) {
+	query := `
+		UPDATE streams
+		SET status = $1, media_server_id = $2, actual_start_time = COALESCE(actual_start_time, $3), updated_at = $4
+		WHERE stream_key = $5 AND status = $6
+	`
+	result, err := r.db.Exec(query, model.StreamStatusPushing, mediaServerID, startTime, time.Now(), key, model.StreamStatusIdle)
+	if err != nil {
+		return false, err
+	}
+	n, err := result.RowsAffected()
+	return n > 0, err
+}
+
 // AppendRecordFile 追加录制文件路径
 func (r *StreamRepository) AppendRecordFile(key, filePath string) error {
 	query := `
@@ -254,6 +313,17 @@ func (r *StreamRepository) AppendRecordFile(key, filePath string) error {
 	return err
 }
 
+// RemoveRecordFile 从录制文件列表中移除指定文件
+func (r *StreamRepository) RemoveRecordFile(key, filePath string) error {
+	query := `
+		UPDATE streams
+		SET record_files = record_files - $1, updated_at = $2
+		WHERE stream_key = $3
+	`
+	_, err := r.db.Exec(query, filePath, time.Now(), key)
+	return err
+}
+
 // UpdateRecordEnabled 更新录制状态
 func (r *StreamRepository) UpdateRecordEnabled(key string, enabled bool) error {
 	query := `UPDATE streams SET record_enabled=$1, updated_at=$2 WHERE stream_key=$3`
@@ -264,14 +334,7 @@ func (r *StreamRepository) UpdateRecordEnabled(key string, enabled bool) error {
 // GetPushingStreams 获取所有正在推流的直播
 func (r *StreamRepository) GetPushingStreams() ([]*model.Stream, error) {
 	query := `
-		SELECT id, stream_key, name, description, device_id, status, visibility,
-			   share_code, share_code_max_uses, share_code_used_count,
-			   record_enabled, record_files,
-			   protocol, bitrate, fps, streamer_name, streamer_contact,
-			   scheduled_start_time, scheduled_end_time, auto_kick_delay,
-			   actual_start_time, actual_end_time, last_unpublish_at, last_frame_at,
-			   current_viewers, total_viewers, peak_viewers,
-			   created_by, created_at, updated_at
+		SELECT ` + streamColumns + `
 		FROM streams WHERE status = $1
 	`
 	rows, err := r.db.Query(query, model.StreamStatusPushing)
@@ -282,19 +345,7 @@ func (r *StreamRepository) GetPushingStreams() ([]*model.Stream, error) {
 
 	streams := make([]*model.Stream, 0)
 	for rows.Next() {
-		s := &model.Stream{}
-		err := rows.Scan(
-			&s.ID, &s.StreamKey, &s.Name, &s.Description,
-			&s.DeviceID, &s.Status, &s.Visibility,
-			&s.ShareCode, &s.ShareCodeMaxUses, &s.ShareCodeUsedCount,
-			&s.RecordEnabled, &s.RecordFiles,
-			&s.Protocol, &s.Bitrate, &s.FPS,
-			&s.StreamerName, &s.StreamerContact,
-			&s.ScheduledStartTime, &s.ScheduledEndTime, &s.AutoKickDelay,
-			&s.ActualStartTime, &s.ActualEndTime, &s.LastUnpublishAt, &s.LastFrameAt,
-			&s.CurrentViewers, &s.TotalViewers, &s.PeakViewers,
-			&s.CreatedBy, &s.CreatedAt, &s.UpdatedAt,
-		)
+		s, err := scanStream(rows)
 		if err != nil {
 			return nil, err
 		}
@@ -306,14 +357,7 @@ func (r *StreamRepository) GetPushingStreams() ([]*model.Stream, error) {
 // GetIdleStreams 获取所有空闲状态的直播（用于检查自动结束）
 func (r *StreamRepository) GetIdleStreams() ([]*model.Stream, error) {
 	query := `
-		SELECT id, stream_key, name, description, device_id, status, visibility,
-			   share_code, share_code_max_uses, share_code_used_count,
-			   record_enabled, record_files,
-			   protocol, bitrate, fps, streamer_name, streamer_contact,
-			   scheduled_start_time, scheduled_end_time, auto_kick_delay,
-			   actual_start_time, actual_end_time, last_unpublish_at, last_frame_at,
-			   current_viewers, total_viewers, peak_viewers,
-			   created_by, created_at, updated_at
+		SELECT ` + streamColumns + `
 		FROM streams WHERE status = $1
 	`
 	rows, err := r.db.Query(query, model.StreamStatusIdle)
@@ -324,19 +368,7 @@ func (r *StreamRepository) GetIdleStreams() ([]*model.Stream, error) {
 
 	streams := make([]*model.Stream, 0)
 	for rows.Next() {
-		s := &model.Stream{}
-		err := rows.Scan(
-			&s.ID, &s.StreamKey, &s.Name, &s.Description,
-			&s.DeviceID, &s.Status, &s.Visibility,
-			&s.ShareCode, &s.ShareCodeMaxUses, &s.ShareCodeUsedCount,
-			&s.RecordEnabled, &s.RecordFiles,
-			&s.Protocol, &s.Bitrate, &s.FPS,
-			&s.StreamerName, &s.StreamerContact,
-			&s.ScheduledStartTime, &s.ScheduledEndTime, &s.AutoKickDelay,
-			&s.ActualStartTime, &s.ActualEndTime, &s.LastUnpublishAt, &s.LastFrameAt,
-			&s.CurrentViewers, &s.TotalViewers, &s.PeakViewers,
-			&s.CreatedBy, &s.CreatedAt, &s.UpdatedAt,
-		)
+		s, err := scanStream(rows)
 		if err != nil {
 			return nil, err
 		}
@@ -345,33 +377,27 @@ func (r *StreamRepository) GetIdleStreams() ([]*model.Stream, error) {
 	return streams, nil
 }
 
-// IncrementViewers 增加观看人数（有人进入观看）
-func (r *StreamRepository) IncrementViewers(key string) error {
-	query := `
-		UPDATE streams SET
-			current_viewers = current_viewers + 1,
-			total_viewers = total_viewers + 1,
-			peak_viewers = GREATEST(peak_viewers, current_viewers + 1),
-			updated_at = $1
-		WHERE stream_key = $2
-	`
+// IncrementTotalViewers 增加累计观看人次（去重后的新观众）
+func (r *StreamRepository) IncrementTotalViewers(key string) error {
+	query := `UPDATE streams SET total_viewers = total_viewers + 1, updated_at = $1 WHERE stream_key = $2`
 	_, err := r.db.Exec(query, time.Now(), key)
 	return err
 }
 
-// DecrementViewers 减少观看人数（有人离开）
-func (r *StreamRepository) DecrementViewers(key string) error {
+// SetCurrentViewers 设置当前观看人数（观看会话数），同时刷新峰值
+func (r *StreamRepository) SetCurrentViewers(key string, viewers int) error {
 	query := `
 		UPDATE streams SET
-			current_viewers = GREATEST(0, current_viewers - 1),
-			updated_at = $1
-		WHERE stream_key = $2
+			current_viewers = $1,
+			peak_viewers = GREATEST(peak_viewers, $1),
+			updated_at = $2
+		WHERE stream_key = $3
 	`
-	_, err := r.db.Exec(query, time.Now(), key)
+	_, err := r.db.Exec(query, viewers, time.Now(), key)
 	return err
 }
 
-// ResetCurrentViewers 重置当前观看人数（直播结束时调用）
+// ResetCurrentViewers 重置当前观看人数（断流或直播结束时调用）
 func (r *StreamRepository) ResetCurrentViewers(key string) error {
 	query := `UPDATE streams SET current_viewers = 0, updated_at = $1 WHERE stream_key = $2`
 	_, err := r.db.Exec(query, time.Now(), key)
@@ -387,30 +413,11 @@ func (r *StreamRepository) Delete(key string) error {
 // GetByShareCode 根据分享码获取直播
 func (r *StreamRepository) GetByShareCode(shareCode string) (*model.Stream, error) {
 	query := `
-		SELECT id, stream_key, name, description, device_id, status, visibility,
-			   share_code, share_code_max_uses, share_code_used_count,
-			   record_enabled, record_files,
-			   protocol, bitrate, fps, streamer_name, streamer_contact,
-			   scheduled_start_time, scheduled_end_time, auto_kick_delay,
-			   actual_start_time, actual_end_time, last_unpublish_at, last_frame_at,
-			   current_viewers, total_viewers, peak_viewers,
-			   created_by, created_at, updated_at
+		SELECT ` + streamColumns + `
 		FROM streams WHERE share_code = $1
 	`
 
-	stream := &model.Stream{}
-	err := r.db.QueryRow(query, shareCode).Scan(
-		&stream.ID, &stream.StreamKey, &stream.Name, &stream.Description,
-		&stream.DeviceID, &stream.Status, &stream.Visibility,
-		&stream.ShareCode, &stream.ShareCodeMaxUses, &stream.ShareCodeUsedCount,
-		&stream.RecordEnabled, &stream.RecordFiles,
-		&stream.Protocol, &stream.Bitrate, &stream.FPS,
-		&stream.StreamerName, &stream.StreamerContact,
-		&stream.ScheduledStartTime, &stream.ScheduledEndTime, &stream.AutoKickDelay,
-		&stream.ActualStartTime, &stream.ActualEndTime, &stream.LastUnpublishAt, &stream.LastFrameAt,
-		&stream.CurrentViewers, &stream.TotalViewers, &stream.PeakViewers,
-		&stream.CreatedBy, &stream.CreatedAt, &stream.UpdatedAt,
-	)
+	stream, err := scanStream(r.db.QueryRow(query, shareCode))
 	if err == sql.ErrNoRows {
 		return nil, nil
 	}
@@ -451,3 +458,32 @@ func (r *StreamRepository) DeleteShareCode(streamKey string) error {
 	_, err := r.db.Exec(query, time.Now(), streamKey)
 	return err
 }
+
+// CountStats 统计各状态、各可见性的直播数量以及观看人数
+func (r *StreamRepository) CountStats() (*model.StreamCounts, *model.ViewerStats, error) {
+	query := `
+		SELECT COUNT(*),
+			   COUNT(*) FILTER (WHERE status = $1),
+			   COUNT(*) FILTER (WHERE status = $2),
+			   COUNT(*) FILTER (WHERE status = $3),
+			   COUNT(*) FILTER (WHERE visibility = $4),
+			   COUNT(*) FILTER (WHERE visibility = $5),
+			   COALESCE(SUM(current_viewers), 0),
+			   COALESCE(SUM(total_viewers), 0)
+		FROM streams
+	`
+	counts := &model.StreamCounts{}
+	viewers := &model.ViewerStats{}
+	err := r.db.QueryRow(query,
+		model.StreamStatusIdle, model.StreamStatusPushing, model.StreamStatusEnded,
+		model.StreamVisibilityPublic, model.StreamVisibilityPrivate,
+	).Scan(
+		&counts.Total, &counts.Idle, &counts.Pushing, &counts.Ended,
+		&counts.Public, &counts.Private,
+		&viewers.Current, &viewers.Total,
+	)
+	if err != nil {
+		return nil, nil, err
+	}
+	return counts, viewers, nil
+}
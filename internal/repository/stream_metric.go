@@ -0,0 +1,129 @@
+package repository
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+
+	"easy-stream/internal/model"
+)
+
+type StreamMetricRepository struct {
+	db *sql.DB
+}
+
+func NewStreamMetricRepository(db *sql.DB) *StreamMetricRepository {
+	return &StreamMetricRepository{db: db}
+}
+
+// CreateBatch 批量写入原始采样
+func (r *StreamMetricRepository) CreateBatch(metrics []*model.StreamMetric) error {
+	if len(metrics) == 0 {
+		return nil
+	}
+
+	const columns = 8
+	values := make([]string, 0, len(metrics))
+	args := make([]interface{}, 0, len(metrics)*columns)
+	for i, m := range metrics {
+		n := i * columns
+		values = append(values, fmt.Sprintf("($%d, $%d, $%d, $%d, $%d, $%d, $%d, $%d)",
+			n+1, n+2, n+3, n+4, n+5, n+6, n+7, n+8))
+		args = append(args,
+			m.StreamID, m.SampledAt, m.Bitrate, m.BitrateMin,
+			m.FPS, m.FPSMin, m.ReaderCount, m.BytesSpeed,
+		)
+	}
+
+	query := `
+		INSERT INTO stream_metrics (
+			stream_id, sampled_at, bitrate, bitrate_min,
+			fps, fps_min, reader_count, bytes_speed
+		)
+		VALUES ` + strings.Join(values, ", ")
+	_, err := r.db.Exec(query, args...)
+	return err
+}
+
+// Query 按时间桶聚合查询指定直播的指标，时间范围为 [from, to)
+func (r *StreamMetricRepository) Query(streamID int64, from, to time.Time, step int) ([]*model.StreamMetricPoint, error) {
+	query := `
+		SELECT to_timestamp(floor(extract(epoch FROM sampled_at) / $4::integer) * $4::integer) AT TIME ZONE 'UTC' AS bucket,
+			   ROUND(AVG(bitrate)), MIN(bitrate_min),
+			   ROUND(AVG(fps)), MIN(fps_min),
+			   MAX(reader_count), ROUND(AVG(bytes_speed)),
+			   COUNT(*)
+		FROM stream_metrics
+		WHERE stream_id = $1 AND sampled_at >= $2 AND sampled_at < $3
+		GROUP BY bucket
+		ORDER BY bucket
+	`
+	rows, err := r.db.Query(query, streamID, from, to, step)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	points := make([]*model.StreamMetricPoint, 0)
+	for rows.Next() {
+		p := &model.StreamMetricPoint{}
+		if err := rows.Scan(
+			&p.Time, &p.Bitrate, &p.BitrateMin,
+			&p.FPS, &p.FPSMin,
+			&p.ReaderCount, &p.BytesSpeed,
+			&p.Samples,
+		); err != nil {
+			return nil, err
+		}
+		points = append(points, p)
+	}
+	return points, rows.Err()
+}
+
+// Downsample 将 before 之前的原始采样按 step 秒聚合为降采样数据，并删除对应的原始采样
+// before 向下对齐到 step 的整数倍，跨越 before 的时间桶留到下次完整聚合，避免同一时间桶产生两条降采样数据
+func (r *StreamMetricRepository) Downsample(before time.Time, step int) (int64, error) {
+	before = time.Unix(before.Unix()/int64(step)*int64(step), 0)
+
+	tx, err := r.db.Begin()
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	insert := `
+		INSERT INTO stream_metrics (
+			stream_id, sampled_at, granularity, bitrate, bitrate_min,
+			fps, fps_min, reader_count, bytes_speed
+		)
+		SELECT stream_id,
+			   to_timestamp(floor(extract(epoch FROM sampled_at) / $2::integer) * $2::integer) AT TIME ZONE 'UTC',
+			   $2::integer, ROUND(AVG(bitrate)), MIN(bitrate_min),
+			   ROUND(AVG(fps)), MIN(fps_min),
+			   MAX(reader_count), ROUND(AVG(bytes_speed))
+		FROM stream_metrics
+		WHERE granularity = 0 AND sampled_at < $1
+		GROUP BY 1, 2
+	`
+	result, err := tx.Exec(insert, before, step)
+	if err != nil {
+		return 0, err
+	}
+	if _, err := tx.Exec(`DELETE FROM stream_metrics WHERE granularity = 0 AND sampled_at < $1`, before); err != nil {
+		return 0, err
+	}
+	if err := tx.Commit(); err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+// DeleteBefore 删除 before 之前的所有指标数据
+func (r *StreamMetricRepository) DeleteBefore(before time.Time) (int64, error) {
+	result, err := r.db.Exec(`DELETE FROM stream_metrics WHERE sampled_at < $1`, before)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
@@ -17,13 +17,13 @@ func NewUserRepository(db *sql.DB) *UserRepository {
 
 // GetByUsername 根据用户名获取用户
 func (r *UserRepository) GetByUsername(username string) (*model.User, error) {
-	query := `SELECT id, username, password_hash, email, phone, real_name,
+	query := `SELECT id, username, password_hash, role, email, phone, real_name,
 			  avatar, last_login_at, created_at, updated_at
 			  FROM users WHERE username = $1`
 
 	user := &model.User{}
 	err := r.db.QueryRow(query, username).Scan(
-		&user.ID, &user.Username, &user.PasswordHash,
+		&user.ID, &user.Username, &user.PasswordHash, &user.Role,
 		&user.Email, &user.Phone, &user.RealName, &user.Avatar,
 		&user.LastLoginAt, &user.CreatedAt, &user.UpdatedAt,
 	)
@@ -35,13 +35,13 @@ func (r *UserRepository) GetByUsername(username string) (*model.User, error) {
 
 // GetByID 根据 ID 获取用户
 func (r *UserRepository) GetByID(id int64) (*model.User, error) {
-	query := `SELECT id, username, password_hash, email, phone, real_name,
+	query := `SELECT id, username, password_hash, role, email, phone, real_name,
 			  avatar, last_login_at, created_at, updated_at
 			  FROM users WHERE id = $1`
 
 	user := &model.User{}
 	err := r.db.QueryRow(query, id).Scan(
-		&user.ID, &user.Username, &user.PasswordHash,
+		&user.ID, &user.Username, &user.PasswordHash, &user.Role,
 		&user.Email, &user.Phone, &user.RealName, &user.Avatar,
 		&user.LastLoginAt, &user.CreatedAt, &user.UpdatedAt,
 	)
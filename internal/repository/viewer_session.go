@@ -0,0 +1,48 @@
+package repository
+
+import (
+	"database/sql"
+	"time"
+
+	"easy-stream/internal/model"
+)
+
+type ViewerSessionRepository struct {
+	db *sql.DB
+}
+
+func NewViewerSessionRepository(db *sql.DB) *ViewerSessionRepository {
+	return &ViewerSessionRepository{db: db}
+}
+
+// Start 记录会话开始，同一会话已有未结束的记录时忽略
+func (r *ViewerSessionRepository) Start(session *model.ViewerSession) error {
+	query := `
+		INSERT INTO viewer_sessions (stream_id, session_id, media_server_id, ip, protocol, started_at)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		ON CONFLICT (session_id) WHERE ended_at IS NULL DO NOTHING
+	`
+	_, err := r.db.Exec(query, session.StreamID, session.SessionID, session.MediaServerID,
+		session.IP, session.Protocol, session.StartedAt)
+	return err
+}
+
+// End 记录会话结束
+func (r *ViewerSessionRepository) End(sessionID string, endedAt time.Time, reason string) error {
+	query := `
+		UPDATE viewer_sessions SET ended_at = $1, end_reason = $2
+		WHERE session_id = $3 AND ended_at IS NULL
+	`
+	_, err := r.db.Exec(query, endedAt, reason, sessionID)
+	return err
+}
+
+// EndByStream 结束指定直播所有未结束的会话
+func (r *ViewerSessionRepository) EndByStream(streamID int64, endedAt time.Time, reason string) error {
+	query := `
+		UPDATE viewer_sessions SET ended_at = $1, end_reason = $2
+		WHERE stream_id = $3 AND ended_at IS NULL
+	`
+	_, err := r.db.Exec(query, endedAt, reason, streamID)
+	return err
+}
@@ -0,0 +1,104 @@
+package service
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+
+	"easy-stream/internal/model"
+	"easy-stream/internal/repository"
+)
+
+// AuditService 操作日志（审计）服务
+type AuditService struct {
+	logRepo *repository.OperationLogRepository
+}
+
+func NewAuditService(logRepo *repository.OperationLogRepository) *AuditService {
+	return &AuditService{logRepo: logRepo}
+}
+
+// Record 记录一条操作日志
+// userID 为 0 表示系统自动执行的操作；写入失败只打印错误，不影响业务流程
+func (s *AuditService) Record(userID int64, action, targetType, targetID string, detail interface{}) {
+	entry := &model.OperationLog{
+		Action:     action,
+		TargetType: targetType,
+		TargetID:   targetID,
+	}
+	if userID > 0 {
+		entry.UserID = &userID
+	}
+	if detail != nil {
+		data, err := json.Marshal(detail)
+		if err != nil {
+			fmt.Printf("failed to marshal audit detail for %s %s/%s: %v\n", action, targetType, targetID, err)
+		} else {
+			entry.Detail = data
+		}
+	}
+
+	if err := s.logRepo.Create(entry); err != nil {
+		fmt.Printf("failed to write audit log %s %s/%s: %v\n", action, targetType, targetID, err)
+	}
+}
+
+// List 分页查询操作日志（管理员）
+func (s *AuditService) List(req *model.OperationLogListRequest) (*model.OperationLogListResponse, error) {
+	if req.Page < 1 {
+		req.Page = 1
+	}
+	if req.PageSize < 1 {
+		req.PageSize = 20
+	}
+	if req.PageSize > 100 {
+		req.PageSize = 100
+	}
+	offset := (req.Page - 1) * req.PageSize
+
+	logs, total, err := s.logRepo.List(req, offset, req.PageSize)
+	if err != nil {
+		return nil, err
+	}
+	return &model.OperationLogListResponse{
+		Total: total,
+		Logs:  logs,
+	}, nil
+}
+
+// auditChanges 对比变更前后两个对象序列化后的字段，返回 {"changes": {"字段": {"before": 旧值, "after": 新值}}}
+func auditChanges(before, after interface{}) map[string]interface{} {
+	beforeFields := toFieldMap(before)
+	afterFields := toFieldMap(after)
+
+	changes := make(map[string]interface{})
+	for field, newValue := range afterFields {
+		if field == "updated_at" {
+			continue
+		}
+		oldValue := beforeFields[field]
+		if !reflect.DeepEqual(oldValue, newValue) {
+			changes[field] = map[string]interface{}{"before": oldValue, "after": newValue}
+		}
+	}
+	for field, oldValue := range beforeFields {
+		if _, ok := afterFields[field]; !ok {
+			changes[field] = map[string]interface{}{"before": oldValue, "after": nil}
+		}
+	}
+	return map[string]interface{}{"changes": changes}
+}
+
+// toFieldMap 将对象按 JSON 序列化结果转换为字段映射
+func toFieldMap(v interface{}) map[string]interface{} {
+	fields := make(map[string]interface{})
+	if v == nil {
+		return fields
+	}
+	data, err := json.Marshal(v)
+	if err != nil {
+		return fields
+	}
+	json.Unmarshal(data, &fields)
+	return fields
+}
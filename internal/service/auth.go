@@ -4,6 +4,7 @@ import (
 	"crypto/rand"
 	"encoding/hex"
 	"fmt"
+	"strconv"
 	"time"
 
 	"easy-stream/internal/config"
@@ -18,13 +19,15 @@ type AuthService struct {
 	userRepo  *repository.UserRepository
 	redisRepo *repository.RedisClient
 	jwtCfg    config.JWTConfig
+	auditSvc  *AuditService
 }
 
-func NewAuthService(userRepo *repository.UserRepository, redisRepo *repository.RedisClient, jwtCfg config.JWTConfig) *AuthService {
+func NewAuthService(userRepo *repository.UserRepository, redisRepo *repository.RedisClient, jwtCfg config.JWTConfig, auditSvc *AuditService) *AuthService {
 	return &AuthService{
 		userRepo:  userRepo,
 		redisRepo: redisRepo,
 		jwtCfg:    jwtCfg,
+		auditSvc:  auditSvc,
 	}
 }
 
@@ -35,11 +38,13 @@ func (s *AuthService) Login(username, password string) (*model.LoginResponse, er
 		return nil, err
 	}
 	if user == nil {
+		s.auditSvc.Record(0, model.ActionLoginFailed, model.TargetTypeUser, "", map[string]interface{}{"username": username})
 		return nil, ErrInvalidCredentials
 	}
 
 	// 验证密码
 	if err := bcrypt.CompareHashAndPassword([]byte(user.PasswordHash), []byte(password)); err != nil {
+		s.auditSvc.Record(user.ID, model.ActionLoginFailed, model.TargetTypeUser, strconv.FormatInt(user.ID, 10), map[string]interface{}{"username": username})
 		return nil, ErrInvalidCredentials
 	}
 
@@ -65,6 +70,8 @@ func (s *AuthService) Login(username, password string) (*model.LoginResponse, er
 		return nil, err
 	}
 
+	s.auditSvc.Record(user.ID, model.ActionLogin, model.TargetTypeUser, strconv.FormatInt(user.ID, 10), nil)
+
 	return &model.LoginResponse{
 		AccessToken:  accessToken,
 		RefreshToken: refreshToken,
@@ -130,6 +137,7 @@ func (s *AuthService) generateAccessToken(user *model.User) (string, error) {
 	claims := jwt.MapClaims{
 		"user_id":  user.ID,
 		"username": user.Username,
+		"role":     user.Role,
 		"type":     "access",
 		"exp":      time.Now().Add(2 * time.Hour).Unix(),
 		"iat":      time.Now().Unix(),
@@ -0,0 +1,115 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"time"
+
+	"easy-stream/internal/model"
+	"easy-stream/internal/storage"
+)
+
+// RecordingDownload 录制文件的下载方式
+// URL 不为空时重定向到存储的临时下载地址，否则由服务端读取 File 返回（调用方负责关闭）
+type RecordingDownload struct {
+	URL      string
+	File     io.ReadSeekCloser
+	FileName string
+	ModTime  time.Time
+}
+
+// RecordingForDownload 获取要下载的录制文件及其所属直播
+// 调用方需先确认请求方有权观看该直播
+func (s *StreamService) RecordingForDownload(id int64) (*model.Stream, *model.Recording, error) {
+	rec, err := s.recordingSvc.recordingRepo.GetByID(id)
+	if err != nil {
+		return nil, nil, err
+	}
+	if rec == nil {
+		return nil, nil, ErrRecordingNotFound
+	}
+	stream, err := s.GetByID(rec.StreamID)
+	if err != nil {
+		return nil, nil, err
+	}
+	return stream, rec, nil
+}
+
+// DownloadRecording 获取录制文件的下载方式
+func (s *StreamService) DownloadRecording(ctx context.Context, rec *model.Recording) (*RecordingDownload, error) {
+	return s.recordingSvc.Download(ctx, rec)
+}
+
+// Download 获取录制文件的下载方式，只支持已完成的 MP4 录制，按以下顺序选择：
+//  1. 已上传到支持临时地址的存储（S3 兼容存储）：生成带签名的临时地址，bucket 保持私有
+//  2. 节点上的本地文件
+//  3. 已上传到本地存储的副本
+func (s *RecordingService) Download(ctx context.Context, rec *model.Recording) (*RecordingDownload, error) {
+	if rec.Format != model.RecordingFormatMP4 || rec.Status != model.RecordingStatusCompleted {
+		return nil, ErrRecordingNotDownloadable
+	}
+
+	fileName := path.Base(rec.FileName)
+	expire := time.Duration(s.downloadCfg.Expire) * time.Second
+
+	// 上传成功且存储仍然启用的副本
+	var copies []*model.RecordingUpload
+	for _, upload := range rec.Uploads {
+		if upload.Status == model.UploadStatusSuccess && s.target(upload.Storage) != nil {
+			copies = append(copies, upload)
+		}
+	}
+
+	for _, upload := range copies {
+		url, err := s.target(upload.Storage).PresignURL(ctx, upload.RemotePath, expire)
+		if err == nil {
+			return &RecordingDownload{URL: url, FileName: fileName}, nil
+		}
+		if !errors.Is(err, storage.ErrPresignNotSupported) {
+			fmt.Printf("Failed to presign recording %d on %s: %v\n", rec.ID, upload.Storage, err)
+		}
+	}
+
+	if rec.LocalDeletedAt == nil {
+		f, err := os.Open(rec.LocalPath)
+		if err == nil {
+			if fi, err := f.Stat(); err == nil {
+				return &RecordingDownload{File: f, FileName: fileName, ModTime: fi.ModTime()}, nil
+			}
+			f.Close()
+		} else if !os.IsNotExist(err) {
+			fmt.Printf("Failed to open recording file %s: %v\n", rec.LocalPath, err)
+		}
+	}
+
+	for _, upload := range copies {
+		target := s.target(upload.Storage)
+		info, err := target.Stat(ctx, upload.RemotePath)
+		if err != nil {
+			continue
+		}
+		r, err := target.Open(ctx, upload.RemotePath)
+		if err != nil {
+			continue
+		}
+		// 需要支持 Seek 才能响应 Range 请求
+		if f, ok := r.(io.ReadSeekCloser); ok {
+			return &RecordingDownload{File: f, FileName: fileName, ModTime: info.ModTime}, nil
+		}
+		r.Close()
+	}
+
+	return nil, ErrRecordingFileUnavailable
+}
+
+// target 获取启用的存储目标，未启用时返回 nil
+func (s *RecordingService) target(name string) storage.Storage {
+	if s.storageManager == nil {
+		return nil
+	}
+	return s.storageManager.Get(name)
+}
@@ -0,0 +1,159 @@
+package service
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"easy-stream/internal/config"
+	"easy-stream/internal/model"
+	"easy-stream/internal/zlm"
+)
+
+// EmptyStreamDetector 空流检测器
+// 定时查询所有 pushing 状态的直播在 ZLMediaKit 中的流状态，
+// 无视频轨道、码率为 0、画面冻结或长时间无关键帧超过阈值时判定为空流，踢流并结束直播
+type EmptyStreamDetector struct {
+	streamSvc *StreamService
+	cfg       config.EmptyStreamConfig
+
+	mu     sync.Mutex
+	states map[string]*emptyStreamState // stream_key -> 检测状态
+}
+
+// emptyStreamState 单个流的检测状态，记录各项指标最后一次正常的时间
+type emptyStreamState struct {
+	lastVideoAt    time.Time // 最后一次视频轨道就绪的时间
+	lastBytesAt    time.Time // 最后一次码率有效的时间
+	lastFrames     int64     // 上次检测时的视频帧数
+	lastFrameAt    time.Time // 视频帧数最后一次增长的时间
+	lastKeyFrames  int64     // 上次检测时的关键帧数
+	lastKeyFrameAt time.Time // 关键帧数最后一次增长的时间
+}
+
+// NewEmptyStreamDetector 创建空流检测器
+func NewEmptyStreamDetector(streamSvc *StreamService, cfg config.EmptyStreamConfig) *EmptyStreamDetector {
+	return &EmptyStreamDetector{
+		streamSvc: streamSvc,
+		cfg:       cfg,
+		states:    make(map[string]*emptyStreamState),
+	}
+}
+
+// Check 执行一次空流检测（定时任务）
+func (d *EmptyStreamDetector) Check() error {
+	streams, err := d.streamSvc.streamRepo.GetPushingStreams()
+	if err != nil {
+		return err
+	}
+
+	// ZLM 返回异常的节点不做任何判定，避免误杀正常推流
+	medias, err := d.streamSvc.fetchMedias("live")
+	if err != nil {
+		return err
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	now := time.Now()
+	alive := make(map[string]bool, len(streams))
+	for _, stream := range streams {
+		list, ok := medias.lookup(stream)
+		if !ok {
+			// ZLM 中不存在该流（数据库状态滞后），不在空流检测的处理范围内
+			continue
+		}
+		alive[stream.StreamKey] = true
+
+		reason := d.evaluate(stream.StreamKey, list, now)
+		if reason == "" {
+			continue
+		}
+
+		fmt.Printf("Empty stream detected: %s (%s), kicking publisher and ending stream\n", stream.StreamKey, reason)
+		if _, err := d.streamSvc.zlmFor(stream).CloseStreams("live", stream.PlaybackID, true); err != nil {
+			fmt.Printf("failed to close empty stream %s: %v\n", stream.StreamKey, err)
+		}
+		if err := d.streamSvc.endStreamInternal(stream, model.StreamEndReasonEmptyStream+": "+reason, 0); err != nil {
+			fmt.Printf("failed to end empty stream %s: %v\n", stream.StreamKey, err)
+		}
+		delete(d.states, stream.StreamKey)
+	}
+
+	// 清理已不在推流的检测状态
+	for key := range d.states {
+		if !alive[key] {
+			delete(d.states, key)
+		}
+	}
+
+	return nil
+}
+
+// evaluate 更新单个流的检测状态，判定为空流时返回原因，否则返回空字符串
+func (d *EmptyStreamDetector) evaluate(streamKey string, medias []zlm.MediaInfo, now time.Time) string {
+	state, ok := d.states[streamKey]
+	if !ok {
+		// 首次检测到的流，从现在开始计时
+		state = &emptyStreamState{
+			lastVideoAt:    now,
+			lastBytesAt:    now,
+			lastFrameAt:    now,
+			lastKeyFrameAt: now,
+		}
+		d.states[streamKey] = state
+	}
+
+	// 同一个流会以多种协议（rtmp/rtsp/hls...）出现，取各协议中的最大值
+	var video *zlm.Track
+	bytesSpeed := 0
+	for i := range medias {
+		if medias[i].BytesSpeed > bytesSpeed {
+			bytesSpeed = medias[i].BytesSpeed
+		}
+		if vt := medias[i].VideoTrack(); vt != nil && (video == nil || vt.Frames > video.Frames) {
+			video = vt
+		}
+	}
+
+	if video != nil && video.Ready {
+		state.lastVideoAt = now
+	}
+	if bytesSpeed >= d.cfg.MinBytesSpeed {
+		state.lastBytesAt = now
+	}
+
+	// 部分 ZLM 版本不返回帧计数，计数从未大于 0 时不做帧相关判定
+	if video != nil && video.Frames > state.lastFrames {
+		state.lastFrames = video.Frames
+		state.lastFrameAt = now
+	} else if state.lastFrames == 0 {
+		state.lastFrameAt = now
+	}
+	if video != nil && video.KeyFrames > state.lastKeyFrames {
+		state.lastKeyFrames = video.KeyFrames
+		state.lastKeyFrameAt = now
+	} else if state.lastKeyFrames == 0 {
+		state.lastKeyFrameAt = now
+	}
+
+	if exceeded(now, state.lastVideoAt, d.cfg.NoVideoTimeout) {
+		return fmt.Sprintf("no ready video track for %ds", int(now.Sub(state.lastVideoAt).Seconds()))
+	}
+	if exceeded(now, state.lastBytesAt, d.cfg.ZeroBitrateTimeout) {
+		return fmt.Sprintf("zero bitrate for %ds", int(now.Sub(state.lastBytesAt).Seconds()))
+	}
+	if exceeded(now, state.lastFrameAt, d.cfg.NoFrameTimeout) {
+		return fmt.Sprintf("no new video frames for %ds", int(now.Sub(state.lastFrameAt).Seconds()))
+	}
+	if exceeded(now, state.lastKeyFrameAt, d.cfg.NoKeyFrameTimeout) {
+		return fmt.Sprintf("no key frames for %ds", int(now.Sub(state.lastKeyFrameAt).Seconds()))
+	}
+	return ""
+}
+
+// exceeded 判断距离 since 是否已超过 timeout 秒，timeout <= 0 表示不启用该项检测
+func exceeded(now, since time.Time, timeout int) bool {
+	return timeout > 0 && now.Sub(since) > time.Duration(timeout)*time.Second
+}
@@ -9,6 +9,30 @@ var (
 	ErrInvalidToken       = errors.New("invalid or expired token")
 	ErrStreamExpired      = errors.New("stream has expired")
 	ErrPrivateStream      = errors.New("private stream requires authentication")
+	ErrForbidden          = errors.New("permission denied")
+	ErrInvalidTimeRange   = errors.New("invalid time range")
+	ErrTooManyPoints      = errors.New("too many data points, increase step or narrow the time range")
+	ErrDateRangeTooLong   = errors.New("date range too long, at most 366 days")
+
+	// 推流鉴权相关错误
+	ErrPushSignMissing = errors.New("push signature missing")
+	ErrPushSignExpired = errors.New("push signature expired")
+	ErrPushSignInvalid = errors.New("push signature invalid")
+	ErrDeviceMismatch  = errors.New("device id mismatch")
+	ErrIPNotAllowed    = errors.New("publisher ip not allowed")
+	ErrInvalidCIDR     = errors.New("invalid allowed_cidrs entry")
+
+	// 播放鉴权相关错误
+	ErrPlaySignMissing = errors.New("play signature missing")
+	ErrPlaySignExpired = errors.New("play signature expired")
+	ErrPlaySignInvalid = errors.New("play signature invalid")
+
+	// Hook 回调认证相关错误
+	ErrHookIPNotAllowed     = errors.New("hook source ip not allowed")
+	ErrHookSecretInvalid    = errors.New("hook secret invalid")
+	ErrHookSignatureExpired = errors.New("hook signature expired")
+	ErrHookReplayed         = errors.New("hook signature already used")
+	ErrHookUnknownServer    = errors.New("hook mediaServerId not registered")
 
 	// 分享码相关错误
 	ErrInvalidShareCode        = errors.New("invalid share code")
@@ -21,4 +45,15 @@ var (
 	ErrInvalidShareLink        = errors.New("invalid share link")
 	ErrShareLinkMaxUsesReached = errors.New("share link max uses reached")
 	ErrShareLinkNotFound       = errors.New("share link not found")
+
+	// 录制文件相关错误
+	ErrRecordingNotFound = errors.New("recording not found")
+	ErrUploadNotFound    = errors.New("upload not found")
+	ErrUploadNotFailed   = errors.New("only failed uploads can be retried")
+
+	ErrRecordingInProgress = errors.New("recording is still in progress")
+	ErrRecordingUploading  = errors.New("recording upload is in progress")
+
+	ErrRecordingNotDownloadable = errors.New("only completed mp4 recordings can be downloaded")
+	ErrRecordingFileUnavailable = errors.New("recording file is not available")
 )
@@ -0,0 +1,118 @@
+package service
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"time"
+
+	"easy-stream/internal/config"
+	"easy-stream/internal/model"
+	"easy-stream/internal/repository"
+	"easy-stream/internal/zlm"
+)
+
+// HookAuthRequest Hook 回调认证所需的请求信息
+type HookAuthRequest struct {
+	Path      string // 请求路径，如 /api/v1/hooks/on_publish
+	RemoteIP  string // TCP 连接来源 IP（不信任 X-Forwarded-For）
+	Token     string // 查询参数 token
+	Timestamp string // 请求头 X-Hook-Timestamp（Unix 秒）
+	Signature string // 请求头 X-Hook-Signature
+	Body      []byte // 请求体
+}
+
+// HookAuthService Hook 回调认证服务
+// 支持两种密钥校验方式：
+//   - 共享密钥：回调地址携带 ?token={secret}（ZLMediaKit 原生支持，配置 Hook 时自动附加），需启用 hook.queryToken，无防重放能力
+//   - HMAC 签名（默认）：请求头 X-Hook-Timestamp 和 X-Hook-Signature，
+//     签名为 hex(HMAC-SHA256(secret, timestamp + "\n" + path + "\n" + body))，超出有效期或重复使用的签名被拒绝
+type HookAuthService struct {
+	cfg        config.HookConfig
+	allowedIPs model.StringArray
+	zlmNodes   *zlm.Registry
+	redis      *repository.RedisClient
+}
+
+// NewHookAuthService 创建 Hook 回调认证服务，IP 白名单格式不正确时返回错误
+func NewHookAuthService(cfg config.HookConfig, zlmNodes *zlm.Registry, redis *repository.RedisClient) (*HookAuthService, error) {
+	allowedIPs, err := normalizeCIDRs(cfg.AllowedIPs)
+	if err != nil {
+		return nil, fmt.Errorf("invalid hook.allowedIPs: %w", err)
+	}
+	return &HookAuthService{
+		cfg:        cfg,
+		allowedIPs: allowedIPs,
+		zlmNodes:   zlmNodes,
+		redis:      redis,
+	}, nil
+}
+
+// Verify 校验 Hook 回调请求
+func (s *HookAuthService) Verify(req *HookAuthRequest) error {
+	if len(s.allowedIPs) > 0 && !ipAllowed(req.RemoteIP, s.allowedIPs) {
+		return ErrHookIPNotAllowed
+	}
+
+	if s.cfg.Secret != "" {
+		if err := s.verifySecret(req); err != nil {
+			return err
+		}
+	}
+
+	if s.cfg.VerifyServerID {
+		var body struct {
+			MediaSrvID string `json:"mediaServerId"`
+		}
+		if err := json.Unmarshal(req.Body, &body); err != nil || !s.zlmNodes.Has(body.MediaSrvID) {
+			return ErrHookUnknownServer
+		}
+	}
+
+	return nil
+}
+
+// verifySecret 校验共享密钥或 HMAC 签名
+func (s *HookAuthService) verifySecret(req *HookAuthRequest) error {
+	if req.Signature == "" {
+		if !s.cfg.QueryToken || req.Token == "" {
+			return ErrHookSecretInvalid
+		}
+		if subtle.ConstantTimeCompare([]byte(req.Token), []byte(s.cfg.Secret)) != 1 {
+			return ErrHookSecretInvalid
+		}
+		return nil
+	}
+
+	mac := hmac.New(sha256.New, []byte(s.cfg.Secret))
+	mac.Write([]byte(req.Timestamp + "\n" + req.Path + "\n"))
+	mac.Write(req.Body)
+	expected := hex.EncodeToString(mac.Sum(nil))
+	if !hmac.Equal([]byte(expected), []byte(req.Signature)) {
+		return ErrHookSecretInvalid
+	}
+
+	ts, err := strconv.ParseInt(req.Timestamp, 10, 64)
+	if err != nil {
+		return ErrHookSecretInvalid
+	}
+	ttl := time.Duration(s.cfg.SignatureTTL) * time.Second
+	age := time.Since(time.Unix(ts, 0))
+	if age > ttl || age < -ttl {
+		return ErrHookSignatureExpired
+	}
+
+	// 签名在有效期内只能使用一次（时钟偏差两个方向各 ttl，记录保留 2*ttl）
+	fresh, err := s.redis.MarkHookSignature(req.Signature, 2*ttl)
+	if err != nil {
+		return err
+	}
+	if !fresh {
+		return ErrHookReplayed
+	}
+	return nil
+}
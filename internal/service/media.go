@@ -0,0 +1,69 @@
+package service
+
+import (
+	"fmt"
+
+	"easy-stream/internal/model"
+	"easy-stream/internal/zlm"
+)
+
+// mediaSnapshot 各 ZLMediaKit 节点上的媒体列表快照，按节点 mediaServerId 和流名称（playback_id）分组
+type mediaSnapshot struct {
+	nodes  *zlm.Registry
+	byNode map[string]map[string][]zlm.MediaInfo
+}
+
+// fetchMedias 查询所有节点指定 app 下的媒体列表
+// 部分节点查询失败时只打印错误并跳过该节点（该节点上的直播视为查询不到），全部失败时返回错误
+func (s *StreamService) fetchMedias(app string) (*mediaSnapshot, error) {
+	snapshot := &mediaSnapshot{
+		nodes:  s.zlmNodes,
+		byNode: make(map[string]map[string][]zlm.MediaInfo),
+	}
+
+	var lastErr error
+	for _, node := range s.zlmNodes.Nodes() {
+		resp, err := node.Client.GetMediaList(app, "")
+		if err == nil && resp.Code != 0 {
+			err = fmt.Errorf("getMediaList returned code %d", resp.Code)
+		}
+		if err != nil {
+			fmt.Printf("failed to get media list from zlm node %s: %v\n", node.ID, err)
+			lastErr = err
+			continue
+		}
+
+		medias := make(map[string][]zlm.MediaInfo)
+		for _, m := range resp.Data {
+			medias[m.Stream] = append(medias[m.Stream], m)
+		}
+		snapshot.byNode[node.ID] = medias
+	}
+
+	if len(snapshot.byNode) == 0 && lastErr != nil {
+		return nil, lastErr
+	}
+	return snapshot, nil
+}
+
+// lookup 获取直播在其推流所在节点上的媒体信息
+func (m *mediaSnapshot) lookup(stream *model.Stream) ([]zlm.MediaInfo, bool) {
+	id := ""
+	if stream.MediaServerID != nil {
+		id = *stream.MediaServerID
+	}
+	list, ok := m.byNode[m.nodes.Node(id).ID][stream.PlaybackID]
+	return list, ok
+}
+
+// available 判断节点的媒体列表是否查询成功
+func (m *mediaSnapshot) available(nodeID string) bool {
+	_, ok := m.byNode[nodeID]
+	return ok
+}
+
+// find 获取指定节点上的流媒体信息
+func (m *mediaSnapshot) find(nodeID, playbackID string) ([]zlm.MediaInfo, bool) {
+	list, ok := m.byNode[nodeID][playbackID]
+	return list, ok
+}
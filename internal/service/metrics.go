@@ -0,0 +1,23 @@
+package service
+
+import (
+	"fmt"
+	"strconv"
+
+	"easy-stream/internal/metrics"
+)
+
+// CollectMetrics 刷新推流数量和各直播观看人数的监控指标（/metrics 抓取时调用）
+func (s *StreamService) CollectMetrics() {
+	streams, err := s.streamRepo.GetPushingStreams()
+	if err != nil {
+		fmt.Printf("failed to collect stream metrics: %v\n", err)
+		return
+	}
+
+	metrics.PushingStreams.Set(float64(len(streams)))
+	metrics.StreamViewers.Reset()
+	for _, stream := range streams {
+		metrics.StreamViewers.Set(float64(stream.CurrentViewers), strconv.FormatInt(stream.ID, 10))
+	}
+}
@@ -0,0 +1,95 @@
+package service
+
+import (
+	"fmt"
+
+	"easy-stream/internal/model"
+	"easy-stream/internal/repository"
+	"easy-stream/internal/zlm"
+)
+
+// NodeService ZLMediaKit 节点生命周期服务（Hook 配置、启动、心跳）
+type NodeService struct {
+	zlmNodes    *zlm.Registry
+	streamRepo  *repository.StreamRepository
+	streamSvc   *StreamService
+	hookBaseURL string
+	hookToken   string
+}
+
+func NewNodeService(zlmNodes *zlm.Registry, streamRepo *repository.StreamRepository, streamSvc *StreamService, hookBaseURL, hookToken string) *NodeService {
+	return &NodeService{
+		zlmNodes:    zlmNodes,
+		streamRepo:  streamRepo,
+		streamSvc:   streamSvc,
+		hookBaseURL: hookBaseURL,
+		hookToken:   hookToken,
+	}
+}
+
+// ConfigureHooks 为节点配置 Hook 回调地址，未配置 hookBaseURL 时不做处理
+func (s *NodeService) ConfigureHooks(node *zlm.Node) error {
+	if s.hookBaseURL == "" {
+		return nil
+	}
+	return node.Client.ConfigureHooks(s.hookBaseURL, s.hookToken)
+}
+
+// OnServerStarted 处理节点启动回调
+// 节点重启后运行时配置和所有流都已丢失：重新配置 Hook，并将记录在该节点上推流的直播按断流处理
+// （结束观看会话、完成 HLS 录制并回退为 idle）
+func (s *NodeService) OnServerStarted(req *model.OnServerStartedRequest) error {
+	if !s.zlmNodes.Has(req.MediaSrvID) {
+		fmt.Printf("ignored server started from unregistered zlm node %q\n", req.MediaSrvID)
+		return nil
+	}
+	node := s.zlmNodes.Node(req.MediaSrvID)
+
+	if err := s.ConfigureHooks(node); err != nil {
+		return fmt.Errorf("failed to configure hooks on zlm node %s: %w", node.ID, err)
+	}
+	s.zlmNodes.Touch(node.ID)
+
+	if node.IsEdge() {
+		return nil
+	}
+
+	streams, err := s.streamRepo.GetPushingStreams()
+	if err != nil {
+		return err
+	}
+	for _, stream := range streams {
+		if s.streamNodeID(stream) != node.ID {
+			continue
+		}
+		if err := s.streamSvc.markUnpublished(stream); err != nil {
+			fmt.Printf("failed to reset stream %s after zlm node %s restarted: %v\n", stream.StreamKey, node.ID, err)
+		}
+	}
+	return nil
+}
+
+// OnServerKeepalive 处理节点心跳回调，记录最近心跳时间
+func (s *NodeService) OnServerKeepalive(req *model.OnServerKeepaliveRequest) error {
+	if !s.zlmNodes.Has(req.MediaSrvID) {
+		return nil
+	}
+	s.zlmNodes.Touch(req.MediaSrvID)
+	return nil
+}
+
+// OnRTPServerTimeout 处理 RTP 服务器收流超时回调
+// 系统不主动开启 RTP 服务器（GB28181 等），只记录日志便于排查
+func (s *NodeService) OnRTPServerTimeout(req *model.OnRTPServerTimeoutRequest) error {
+	fmt.Printf("rtp server timeout on zlm node %s: port=%d, ssrc=%d, stream_id=%s\n",
+		req.MediaSrvID, req.LocalPort, req.SSRC, req.StreamID)
+	return nil
+}
+
+// streamNodeID 获取直播所在节点的 mediaServerId，未记录节点时为默认节点
+func (s *NodeService) streamNodeID(stream *model.Stream) string {
+	if stream.MediaServerID == nil {
+		return s.zlmNodes.Default().ID
+	}
+	return s.zlmNodes.Node(*stream.MediaServerID).ID
+}
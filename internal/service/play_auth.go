@@ -0,0 +1,199 @@
+package service
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"easy-stream/internal/model"
+	"easy-stream/internal/zlm"
+	"easy-stream/pkg/utils"
+)
+
+// PublicView 生成游客视图，只暴露 playback_id 和播放地址，不含 stream_key
+// 调用方需先确认请求方有权观看该直播
+func (s *StreamService) PublicView(stream *model.Stream) *model.StreamPublicView {
+	view := stream.ToPublicView()
+	if stream.Status != model.StreamStatusEnded {
+		view.PlayURLs = s.BuildPlayURLs(stream)
+	}
+	return view
+}
+
+// RotatePlaybackID 重新生成播放ID（管理员），之前分发的播放地址全部失效
+// 正在推流时断开推流（推流端重连后以新的 playback_id 注册），已在观看的观众随之断开
+func (s *StreamService) RotatePlaybackID(key string, userID int64) (*model.PlayURLsResponse, error) {
+	stream, err := s.streamRepo.GetByKey(key)
+	if err != nil {
+		return nil, err
+	}
+	if stream == nil {
+		return nil, ErrStreamNotFound
+	}
+
+	before := *stream
+	playbackID := utils.GeneratePlaybackID()
+	if err := s.streamRepo.UpdatePlaybackID(key, playbackID); err != nil {
+		return nil, err
+	}
+
+	// 旧 playback_id 的注销回调已查不到直播，这里直接完成断流处理
+	if stream.Status == model.StreamStatusPushing {
+		if _, err := s.zlmFor(stream).CloseStreams("live", stream.PlaybackID, true); err != nil {
+			fmt.Printf("failed to close stream %s after rotating playback id: %v\n", key, err)
+		}
+		if err := s.markUnpublished(stream); err != nil {
+			fmt.Printf("failed to mark stream %s unpublished after rotating playback id: %v\n", key, err)
+		}
+	}
+	stream.PlaybackID = playbackID
+
+	s.auditSvc.Record(userID, model.ActionStreamPlaybackIDRotate, model.TargetTypeStream, key, auditChanges(&before, stream))
+	return s.BuildPlayURLs(stream), nil
+}
+
+// BuildPlayURLs 生成各协议播放地址，私有直播的地址携带播放签名
+// 调用方需先确认请求方有权观看该直播
+func (s *StreamService) BuildPlayURLs(stream *model.Stream) *model.PlayURLsResponse {
+	resp := &model.PlayURLsResponse{}
+
+	query := ""
+	if params := s.playParams(stream); len(params) > 0 {
+		query = "?" + params.Encode()
+		expire, _ := strconv.ParseInt(params.Get("expire"), 10, 64)
+		expireAt := time.Unix(expire, 0)
+		resp.Signed = true
+		resp.ExpireAt = &expireAt
+	}
+
+	host := s.playCfg.Host
+	id := stream.PlaybackID
+	resp.RTMP = fmt.Sprintf("rtmp://%s:%d/live/%s%s", host, s.playCfg.RTMPPort, id, query)
+	resp.RTSP = fmt.Sprintf("rtsp://%s:%d/live/%s%s", host, s.playCfg.RTSPPort, id, query)
+	resp.FLV = fmt.Sprintf("http://%s:%d/live/%s.live.flv%s", host, s.playCfg.HTTPPort, id, query)
+	resp.WSFLV = fmt.Sprintf("ws://%s:%d/live/%s.live.flv%s", host, s.playCfg.HTTPPort, id, query)
+	resp.HLS = fmt.Sprintf("http://%s:%d/live/%s/hls.m3u8%s", host, s.playCfg.HTTPPort, id, query)
+	return resp
+}
+
+// playParams 生成播放签名参数，公开直播返回空
+func (s *StreamService) playParams(stream *model.Stream) url.Values {
+	if stream.Visibility != model.StreamVisibilityPrivate {
+		return nil
+	}
+	expire := strconv.FormatInt(time.Now().Add(time.Duration(s.playCfg.SignExpire)*time.Second).Unix(), 10)
+	return url.Values{
+		"expire": {expire},
+		"sign":   {signPlay(s.playCfg.SignSecret, stream.PlaybackID, expire)},
+	}
+}
+
+// authorizePlay 校验播放请求，公开直播直接通过
+// 私有直播需携带有效的 access_token（分享码/分享链接兑换的访问令牌）或未过期的播放签名；
+// 边缘节点回源拉流的地址同样携带播放签名，与观众播放走相同的校验
+func (s *StreamService) authorizePlay(stream *model.Stream, req *model.OnPlayRequest) error {
+	if stream.Visibility != model.StreamVisibilityPrivate {
+		return nil
+	}
+
+	values, _ := url.ParseQuery(req.Params)
+	if token := values.Get("access_token"); token != "" {
+		valid, err := s.VerifyAccessToken(stream.StreamKey, token)
+		if err != nil {
+			return err
+		}
+		if !valid {
+			return ErrInvalidToken
+		}
+		return nil
+	}
+
+	return s.verifyPlaySign(stream.PlaybackID, values)
+}
+
+// recordHTTPDir ZLMediaKit HTTP 文件服务器上录制文件的根目录（record.appName）
+const recordHTTPDir = "record"
+
+// AuthorizeHTTPAccess 校验 HTTP 文件服务器的访问请求
+// 录制文件（record/{app}/{playback_id}/...）所属直播为公开直播时直接通过，
+// 私有直播或查不到直播（如播放ID已更换）时需携带该路径中播放ID的有效播放签名；其他文件不限制
+func (s *StreamService) AuthorizeHTTPAccess(req *model.OnHTTPAccessRequest) error {
+	parts := strings.Split(strings.TrimPrefix(req.Path, "/"), "/")
+	if len(parts) < 3 || parts[0] != recordHTTPDir {
+		return nil
+	}
+	name := parts[2]
+
+	stream, err := s.streamRepo.GetByPlaybackID(name)
+	if err != nil {
+		return err
+	}
+	if stream != nil && stream.Visibility != model.StreamVisibilityPrivate {
+		return nil
+	}
+
+	values, _ := url.ParseQuery(req.Params)
+	return s.verifyPlaySign(name, values)
+}
+
+// verifyPlaySign 校验播放签名参数（expire、sign）
+func (s *StreamService) verifyPlaySign(playbackID string, values url.Values) error {
+	expire := values.Get("expire")
+	sign := values.Get("sign")
+	if expire == "" || sign == "" {
+		return ErrPlaySignMissing
+	}
+	expireAt, err := strconv.ParseInt(expire, 10, 64)
+	if err != nil {
+		return ErrPlaySignInvalid
+	}
+	if time.Now().Unix() > expireAt {
+		return ErrPlaySignExpired
+	}
+	if !hmac.Equal([]byte(signPlay(s.playCfg.SignSecret, playbackID, expire)), []byte(sign)) {
+		return ErrPlaySignInvalid
+	}
+	return nil
+}
+
+// edgePullParams 边缘节点回源拉流的地址参数：私有直播的播放签名，以及标识回源拉流的 edge、edge_sign
+// 源站据此识别回源拉流的连接，不计入观看人数
+func (s *StreamService) edgePullParams(stream *model.Stream, edge *zlm.Node) url.Values {
+	params := s.playParams(stream)
+	if params == nil {
+		params = url.Values{}
+	}
+	params.Set("edge", edge.ID)
+	params.Set("edge_sign", signEdgePull(s.playCfg.SignSecret, stream.PlaybackID, edge.ID))
+	return params
+}
+
+// isEdgePull 判断播放请求是否为边缘节点回源拉流（参数携带已注册边缘节点的有效 edge_sign）
+func (s *StreamService) isEdgePull(stream *model.Stream, params string) bool {
+	values, _ := url.ParseQuery(params)
+	edgeID := values.Get("edge")
+	sign := values.Get("edge_sign")
+	if edgeID == "" || sign == "" || !s.zlmNodes.Has(edgeID) || !s.zlmNodes.Node(edgeID).IsEdge() {
+		return false
+	}
+	return hmac.Equal([]byte(signEdgePull(s.playCfg.SignSecret, stream.PlaybackID, edgeID)), []byte(sign))
+}
+
+// signEdgePull 计算回源拉流标识签名：hex(HMAC-SHA256(secret, "edge:playback_id:edge_id"))
+func signEdgePull(secret, playbackID, edgeID string) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte("edge:" + playbackID + ":" + edgeID))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// signPlay 计算播放签名：hex(HMAC-SHA256(secret, "play:playback_id:expire"))
+func signPlay(secret, playbackID, expire string) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte("play:" + playbackID + ":" + expire))
+	return hex.EncodeToString(mac.Sum(nil))
+}
@@ -0,0 +1,257 @@
+package service
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/netip"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"easy-stream/internal/model"
+	"easy-stream/pkg/utils"
+)
+
+// GetPushURLs 生成推流地址（管理员）
+// 开启推流鉴权时地址携带签名，ttl 为签名有效期（秒），<= 0 时使用配置的默认值
+func (s *StreamService) GetPushURLs(key string, ttl int) (*model.PushURLsResponse, error) {
+	stream, err := s.streamRepo.GetByKey(key)
+	if err != nil {
+		return nil, err
+	}
+	if stream == nil {
+		return nil, ErrStreamNotFound
+	}
+	return s.buildPushURLs(stream, ttl), nil
+}
+
+// ResetPushSecret 重新生成推流密钥（管理员），之前签发的推流地址全部失效
+func (s *StreamService) ResetPushSecret(key string, userID int64) (*model.PushURLsResponse, error) {
+	stream, err := s.streamRepo.GetByKey(key)
+	if err != nil {
+		return nil, err
+	}
+	if stream == nil {
+		return nil, ErrStreamNotFound
+	}
+
+	stream.PushSecret = strPtr(generatePushSecret())
+	if err := s.streamRepo.Update(stream); err != nil {
+		return nil, err
+	}
+
+	// 推流密钥不对外输出，审计日志只记录发生了重置
+	s.auditSvc.Record(userID, model.ActionStreamPushSecretReset, model.TargetTypeStream, key, nil)
+	return s.buildPushURLs(stream, 0), nil
+}
+
+// RotateStreamKey 更换推流码（管理员）
+// 分享链接随外键同步更新，Redis 中的访问令牌和观看会话迁移到新推流码；正在推流的连接和播放不受影响（媒体以 playback_id 注册）。
+// 宽限期内旧推流码仍可推流（推流端重连时不会被拒绝），req.Kick 为 true 时断开当前推流
+func (s *StreamService) RotateStreamKey(key string, req *model.RotateStreamKeyRequest, userID int64) (*model.RotateStreamKeyResponse, error) {
+	stream, err := s.streamRepo.GetByKey(key)
+	if err != nil {
+		return nil, err
+	}
+	if stream == nil {
+		return nil, ErrStreamNotFound
+	}
+	if stream.Status == model.StreamStatusEnded {
+		return nil, ErrStreamEnded
+	}
+
+	before := *stream
+	newKey := utils.GenerateStreamKey()
+	if err := s.streamRepo.UpdateStreamKey(key, newKey); err != nil {
+		return nil, err
+	}
+	stream.StreamKey = newKey
+
+	if err := s.redisRepo.MigrateStreamKey(key, newKey); err != nil {
+		fmt.Printf("failed to migrate redis data from stream key %s to %s: %v\n", key, newKey, err)
+	}
+
+	resp := &model.RotateStreamKeyResponse{StreamKey: newKey}
+	gracePeriod := s.pushCfg.KeyGracePeriod
+	if req.GracePeriod != nil {
+		gracePeriod = *req.GracePeriod
+	}
+	if gracePeriod > 0 {
+		grace := time.Duration(gracePeriod) * time.Second
+		if err := s.redisRepo.SetStreamKeyGrace(key, newKey, grace); err != nil {
+			fmt.Printf("failed to set grace period for old stream key %s: %v\n", key, err)
+		} else {
+			expireAt := time.Now().Add(grace)
+			resp.OldKeyExpireAt = &expireAt
+		}
+	}
+
+	if req.Kick && stream.Status == model.StreamStatusPushing {
+		if _, err := s.zlmFor(stream).CloseStreams("live", stream.PlaybackID, true); err != nil {
+			fmt.Printf("failed to kick stream %s after rotating stream key: %v\n", newKey, err)
+		}
+		if err := s.markUnpublished(stream); err != nil {
+			fmt.Printf("failed to mark stream %s unpublished after rotating stream key: %v\n", newKey, err)
+		}
+	}
+
+	detail := auditChanges(&before, stream)
+	detail["kick"] = req.Kick
+	detail["grace_period"] = gracePeriod
+	s.auditSvc.Record(userID, model.ActionStreamKeyRotate, model.TargetTypeStream, newKey, detail)
+
+	resp.PushURLs = s.buildPushURLs(stream, 0)
+	return resp, nil
+}
+
+// buildPushURLs 按推流配置拼接 RTMP/SRT/RTSP 推流地址
+// 绑定了设备 ID 时地址携带 device_id 参数，开启推流鉴权时携带 expire 和 sign 参数
+func (s *StreamService) buildPushURLs(stream *model.Stream, ttl int) *model.PushURLsResponse {
+	resp := &model.PushURLsResponse{}
+	var params [][2]string
+
+	if stream.DeviceID != nil && *stream.DeviceID != "" {
+		params = append(params, [2]string{"device_id", *stream.DeviceID})
+	}
+	if stream.PushAuthEnabled && stream.PushSecret != nil {
+		if ttl <= 0 {
+			ttl = s.pushCfg.SignExpire
+		}
+		expireAt := time.Now().Add(time.Duration(ttl) * time.Second)
+		expire := strconv.FormatInt(expireAt.Unix(), 10)
+		params = append(params,
+			[2]string{"expire", expire},
+			[2]string{"sign", signPush(*stream.PushSecret, stream.StreamKey, expire)},
+		)
+		resp.Signed = true
+		resp.ExpireAt = &expireAt
+	}
+
+	// url 参数形式（RTMP/RTSP）和 SRT streamid 扩展参数形式
+	query := ""
+	srtParams := ""
+	for i, kv := range params {
+		if i == 0 {
+			query += "?"
+		} else {
+			query += "&"
+		}
+		query += kv[0] + "=" + url.QueryEscape(kv[1])
+		srtParams += "," + kv[0] + "=" + url.QueryEscape(kv[1])
+	}
+
+	host := s.pushCfg.Host
+	resp.OBSServer = fmt.Sprintf("rtmp://%s:%d/live", host, s.pushCfg.RTMPPort)
+	resp.OBSStreamKey = stream.StreamKey + query
+	resp.RTMP = resp.OBSServer + "/" + resp.OBSStreamKey
+	resp.SRT = fmt.Sprintf("srt://%s:%d?streamid=#!::r=live/%s,m=publish%s", host, s.pushCfg.SRTPort, stream.StreamKey, srtParams)
+	resp.RTSP = fmt.Sprintf("rtsp://%s:%d/live/%s%s", host, s.pushCfg.RTSPPort, stream.StreamKey, query)
+	return resp
+}
+
+// checkPublisher 校验推流端是否允许推流：设备 ID、IP 白名单、推流签名
+func (s *StreamService) checkPublisher(stream *model.Stream, req *model.OnPublishRequest) error {
+	values, _ := url.ParseQuery(req.Params)
+
+	// 绑定了设备 ID 时，推流参数中的 device_id 必须一致
+	if stream.DeviceID != nil && *stream.DeviceID != "" && values.Get("device_id") != *stream.DeviceID {
+		return ErrDeviceMismatch
+	}
+
+	if len(stream.AllowedCIDRs) > 0 && !ipAllowed(req.IP, stream.AllowedCIDRs) {
+		return ErrIPNotAllowed
+	}
+
+	return s.verifyPushSign(stream, req.Stream, values)
+}
+
+// ipAllowed 判断 IP 是否在白名单网段内
+func ipAllowed(ip string, cidrs []string) bool {
+	addr, err := netip.ParseAddr(ip)
+	if err != nil {
+		return false
+	}
+	addr = addr.Unmap()
+	for _, c := range cidrs {
+		prefix, err := netip.ParsePrefix(c)
+		if err != nil {
+			continue
+		}
+		if prefix.Contains(addr) {
+			return true
+		}
+	}
+	return false
+}
+
+// normalizeCIDRs 校验并规范化 IP 白名单，单个 IP 转换为 /32（IPv6 为 /128）
+func normalizeCIDRs(list []string) (model.StringArray, error) {
+	result := model.StringArray{}
+	for _, item := range list {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+		if !strings.Contains(item, "/") {
+			addr, err := netip.ParseAddr(item)
+			if err != nil {
+				return nil, ErrInvalidCIDR
+			}
+			addr = addr.Unmap()
+			item = netip.PrefixFrom(addr, addr.BitLen()).String()
+		}
+		prefix, err := netip.ParsePrefix(item)
+		if err != nil {
+			return nil, ErrInvalidCIDR
+		}
+		result = append(result, prefix.Masked().String())
+	}
+	return result, nil
+}
+
+// verifyPushSign 校验推流参数中的签名，未开启推流鉴权时直接通过
+// streamKey 为推流使用的推流码（宽限期内可能是更换前的推流码）
+// values 解析自 ZLMediaKit on_publish 回调中的 url 参数（SRT 的 streamid 扩展参数同样会被转换为 url 参数）
+func (s *StreamService) verifyPushSign(stream *model.Stream, streamKey string, values url.Values) error {
+	if !stream.PushAuthEnabled {
+		return nil
+	}
+	if stream.PushSecret == nil {
+		return ErrPushSignInvalid
+	}
+
+	expire := values.Get("expire")
+	sign := values.Get("sign")
+	if expire == "" || sign == "" {
+		return ErrPushSignMissing
+	}
+
+	expireAt, err := strconv.ParseInt(expire, 10, 64)
+	if err != nil {
+		return ErrPushSignInvalid
+	}
+	if time.Now().Unix() > expireAt {
+		return ErrPushSignExpired
+	}
+
+	expected := signPush(*stream.PushSecret, streamKey, expire)
+	if !hmac.Equal([]byte(expected), []byte(sign)) {
+		return ErrPushSignInvalid
+	}
+	return nil
+}
+
+// signPush 计算推流签名：hex(HMAC-SHA256(secret, "stream_key:expire"))
+func signPush(secret, streamKey, expire string) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(streamKey + ":" + expire))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// generatePushSecret 生成推流签名密钥
+func generatePushSecret() string {
+	return utils.GenerateToken(16)
+}
@@ -0,0 +1,217 @@
+package service
+
+import (
+	"fmt"
+	"time"
+
+	"easy-stream/internal/config"
+	"easy-stream/internal/metrics"
+	"easy-stream/internal/model"
+	"easy-stream/internal/zlm"
+)
+
+// 状态校正类型（easystream_reconcile_corrections_total 的 type 标签）
+const (
+	CorrectionPushingToIdle = "pushing_to_idle" // 数据库为 pushing，ZLMediaKit 中不存在
+	CorrectionIdleToPushing = "idle_to_pushing" // 数据库为 idle，ZLMediaKit 中正在推流
+	CorrectionMediaServer   = "media_server"    // 推流所在节点与记录不一致
+	CorrectionStartTime     = "start_time"      // 正在推流但缺少实际开始时间
+	CorrectionOrphan        = "orphan"          // ZLMediaKit 中的流没有对应的有效直播
+	CorrectionOrphanClosed  = "orphan_closed"   // 已关闭 ZLMediaKit 中没有对应有效直播的流
+)
+
+// StreamReconciler 直播状态校正器
+// 以 ZLMediaKit 各源站的媒体列表为准，校正数据库中 pushing / idle 直播的状态、实际开始时间和推流节点。
+// 当前观看人数由观看会话统计（ViewerService）维护，不在此校正。
+// 媒体列表查询失败的节点上的直播不做校正
+type StreamReconciler struct {
+	streamSvc *StreamService
+	cfg       config.ReconcileConfig
+}
+
+// NewStreamReconciler 创建直播状态校正器
+func NewStreamReconciler(streamSvc *StreamService, cfg config.ReconcileConfig) *StreamReconciler {
+	return &StreamReconciler{
+		streamSvc: streamSvc,
+		cfg:       cfg,
+	}
+}
+
+// Reconcile 执行一次校正（启动时和定时任务）
+func (r *StreamReconciler) Reconcile() error {
+	pushing, err := r.streamSvc.streamRepo.GetPushingStreams()
+	if err != nil {
+		return err
+	}
+	idle, err := r.streamSvc.streamRepo.GetIdleStreams()
+	if err != nil {
+		return err
+	}
+
+	medias, err := r.streamSvc.fetchMedias("live")
+	if err != nil {
+		return err
+	}
+
+	known := make(map[string]bool, len(pushing)+len(idle))
+	for _, stream := range pushing {
+		known[stream.PlaybackID] = true
+		r.reconcilePushing(stream, medias)
+	}
+	for _, stream := range idle {
+		known[stream.PlaybackID] = true
+		r.reconcileIdle(stream, medias)
+	}
+
+	r.reconcileOrphans(known, medias)
+	return nil
+}
+
+// reconcilePushing 校正 pushing 状态的直播
+func (r *StreamReconciler) reconcilePushing(stream *model.Stream, medias *mediaSnapshot) {
+	node, ok := r.locate(stream, medias)
+	if node == nil {
+		if !ok {
+			return
+		}
+		r.markUnpublished(stream)
+		return
+	}
+
+	var corrections []string
+	if stream.MediaServerID == nil || *stream.MediaServerID != node.ID {
+		stream.MediaServerID = strPtr(node.ID)
+		corrections = append(corrections, CorrectionMediaServer)
+	}
+	if stream.ActualStartTime == nil {
+		now := time.Now()
+		stream.ActualStartTime = &now
+		corrections = append(corrections, CorrectionStartTime)
+	}
+
+	if len(corrections) > 0 {
+		r.save(stream, "", corrections...)
+	}
+}
+
+// markUnpublished 按推流结束回调相同的流程处理已不在任何源站上的直播（结束观看会话、完成 HLS 录制、改为 idle）
+// 重新读取直播并确认仍为 pushing，避免覆盖查询期间回调写入的状态
+func (r *StreamReconciler) markUnpublished(stream *model.Stream) {
+	current, err := r.streamSvc.streamRepo.GetByKey(stream.StreamKey)
+	if err != nil {
+		fmt.Printf("reconcile: failed to get stream %s: %v\n", stream.StreamKey, err)
+		return
+	}
+	if current == nil || current.Status != model.StreamStatusPushing {
+		return
+	}
+	if err := r.streamSvc.markUnpublished(current); err != nil {
+		fmt.Printf("reconcile: failed to update stream %s [%s]: %v\n", stream.StreamKey, CorrectionPushingToIdle, err)
+		return
+	}
+	r.count(stream.StreamKey, CorrectionPushingToIdle, "stream not found on any zlm node")
+}
+
+// reconcileIdle 校正 idle 状态的直播（错过了推流开始回调）
+func (r *StreamReconciler) reconcileIdle(stream *model.Stream, medias *mediaSnapshot) {
+	node, _ := r.locate(stream, medias)
+	if node == nil {
+		return
+	}
+
+	// 重新读取直播并确认仍为 idle，只更新状态、节点和开始时间，避免覆盖查询期间用户的修改或结束操作
+	current, err := r.streamSvc.streamRepo.GetByKey(stream.StreamKey)
+	if err != nil {
+		fmt.Printf("reconcile: failed to get stream %s: %v\n", stream.StreamKey, err)
+		return
+	}
+	if current == nil || current.Status != model.StreamStatusIdle {
+		return
+	}
+	updated, err := r.streamSvc.streamRepo.MarkPushing(current.StreamKey, node.ID, time.Now())
+	if err != nil {
+		fmt.Printf("reconcile: failed to update stream %s [%s]: %v\n", stream.StreamKey, CorrectionIdleToPushing, err)
+		return
+	}
+	if updated {
+		r.count(stream.StreamKey, CorrectionIdleToPushing, "on node "+node.ID)
+	}
+}
+
+// reconcileOrphans 处理 ZLMediaKit 源站中没有对应 pushing / idle 直播的流
+func (r *StreamReconciler) reconcileOrphans(known map[string]bool, medias *mediaSnapshot) {
+	for _, node := range r.streamSvc.zlmNodes.Nodes() {
+		if node.IsEdge() {
+			continue
+		}
+		for name := range medias.byNode[node.ID] {
+			if known[name] {
+				continue
+			}
+			// 查询期间可能有新推流，重新确认数据库中的状态
+			stream, err := r.streamSvc.streamRepo.GetByPlaybackID(name)
+			if err != nil {
+				fmt.Printf("reconcile: failed to get stream %s: %v\n", name, err)
+				continue
+			}
+			if stream != nil && stream.Status != model.StreamStatusEnded {
+				continue
+			}
+
+			if !r.cfg.CloseOrphans {
+				r.count(name, CorrectionOrphan, "on node "+node.ID)
+				continue
+			}
+			if _, err := node.Client.CloseStreams("live", name, true); err != nil {
+				fmt.Printf("reconcile: failed to close orphan stream %s on node %s: %v\n", name, node.ID, err)
+				continue
+			}
+			r.count(name, CorrectionOrphanClosed, "on node "+node.ID)
+		}
+	}
+}
+
+// locate 查找直播实际推流所在的源站
+// 优先查找记录的节点，其次查找其他源站；ok 为 false 表示有源站查询失败，无法确认流不存在
+func (r *StreamReconciler) locate(stream *model.Stream, medias *mediaSnapshot) (node *zlm.Node, ok bool) {
+	recorded := r.streamSvc.nodeFor(stream)
+	if _, found := medias.find(recorded.ID, stream.PlaybackID); found {
+		return recorded, true
+	}
+
+	ok = true
+	for _, n := range r.streamSvc.zlmNodes.Nodes() {
+		if n.IsEdge() {
+			continue
+		}
+		if !medias.available(n.ID) {
+			ok = false
+			continue
+		}
+		if _, found := medias.find(n.ID, stream.PlaybackID); found {
+			return n, true
+		}
+	}
+	return nil, ok
+}
+
+// save 保存校正后的直播并记录各项校正
+func (r *StreamReconciler) save(stream *model.Stream, detail string, corrections ...string) {
+	if err := r.streamSvc.streamRepo.Update(stream); err != nil {
+		fmt.Printf("reconcile: failed to update stream %s %v: %v\n", stream.StreamKey, corrections, err)
+		return
+	}
+	for _, correction := range corrections {
+		r.count(stream.StreamKey, correction, detail)
+	}
+}
+
+// count 输出校正日志并计入指标，name 为 stream_key（孤儿流为 ZLMediaKit 中的流名称）
+func (r *StreamReconciler) count(name, correction, detail string) {
+	metrics.ReconcileCorrections.Inc(correction)
+	if detail != "" {
+		fmt.Printf("reconcile: stream %s corrected (%s): %s\n", name, correction, detail)
+		return
+	}
+	fmt.Printf("reconcile: stream %s corrected (%s)\n", name, correction)
+}
@@ -0,0 +1,461 @@
+package service
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"easy-stream/internal/config"
+	"easy-stream/internal/model"
+	"easy-stream/internal/repository"
+	"easy-stream/internal/storage"
+	"easy-stream/internal/zlm"
+)
+
+type RecordingService struct {
+	recordingRepo  *repository.RecordingRepository
+	streamRepo     *repository.StreamRepository
+	uploadSvc      *UploadService
+	auditSvc       *AuditService
+	statsSvc       *StatsService
+	storageManager *storage.Manager
+	zlmNodes       *zlm.Registry
+	downloadCfg    config.DownloadConfig
+}
+
+// NewRecordingService 创建录制文件服务
+func NewRecordingService(
+	recordingRepo *repository.RecordingRepository,
+	streamRepo *repository.StreamRepository,
+	uploadSvc *UploadService,
+	auditSvc *AuditService,
+	statsSvc *StatsService,
+	storageManager *storage.Manager,
+	zlmNodes *zlm.Registry,
+	downloadCfg config.DownloadConfig,
+) *RecordingService {
+	return &RecordingService{
+		recordingRepo:  recordingRepo,
+		streamRepo:     streamRepo,
+		uploadSvc:      uploadSvc,
+		auditSvc:       auditSvc,
+		statsSvc:       statsSvc,
+		storageManager: storageManager,
+		zlmNodes:       zlmNodes,
+		downloadCfg:    downloadCfg,
+	}
+}
+
+// OnRecordMP4 处理录制完成回调：登记录制分段并添加上传任务，校验和在后台计算
+func (s *RecordingService) OnRecordMP4(req *model.OnRecordMP4Request) error {
+	stream, err := s.streamRepo.GetByPlaybackID(req.Stream)
+	if err != nil || stream == nil {
+		return err
+	}
+
+	duration := time.Duration(req.TimeLen * float64(time.Second))
+	startTime := time.Unix(req.StartTime, 0)
+	if req.StartTime <= 0 {
+		startTime = time.Now().Add(-duration)
+	}
+	rec := &model.Recording{
+		StreamID:      stream.ID,
+		MediaServerID: req.MediaSrvID,
+		Format:        model.RecordingFormatMP4,
+		Status:        model.RecordingStatusCompleted,
+		FileName:      req.FileName,
+		LocalPath:     req.FilePath,
+		URL:           req.URL,
+		FileSize:      req.FileSize,
+		Duration:      req.TimeLen,
+		StartTime:     startTime,
+		EndTime:       startTime.Add(duration),
+	}
+	if err := s.recordingRepo.Create(rec); err != nil {
+		return err
+	}
+
+	// record_files 保留用于兼容旧接口
+	if err := s.streamRepo.AppendRecordFile(stream.StreamKey, req.FilePath); err != nil {
+		fmt.Printf("Failed to append record file for stream %s: %v\n", stream.StreamKey, err)
+	}
+	s.statsSvc.Incr(model.StatRecordFiles, 1)
+	s.statsSvc.Incr(model.StatRecordSeconds, int64(math.Round(req.TimeLen)))
+	s.statsSvc.Incr(model.StatRecordBytes, req.FileSize)
+
+	if err := s.uploadSvc.Enqueue(stream, rec); err != nil {
+		fmt.Printf("Failed to enqueue uploads for recording %d: %v\n", rec.ID, err)
+	}
+	go s.checksum(rec)
+	return nil
+}
+
+// hlsSegmentGap HLS 切片与上一条录制结束时间的最大间隔，超过时登记为新的录制
+// 推流结束后最后一个切片的回调可能晚于注销回调到达，断线后短时间内重新推流也继续使用同一条录制
+const hlsSegmentGap = 30 * time.Second
+
+// OnRecordTS 处理 HLS 切片录制完成回调：切片追加到直播当前的 HLS 录制，没有时登记一条新的录制
+func (s *RecordingService) OnRecordTS(req *model.OnRecordTSRequest) error {
+	stream, err := s.streamRepo.GetByPlaybackID(req.Stream)
+	if err != nil || stream == nil || !stream.RecordsHLS() {
+		return err
+	}
+
+	segStart := time.Unix(req.StartTime, 0)
+	if req.StartTime <= 0 {
+		segStart = time.Now().Add(-time.Duration(req.TimeLen * float64(time.Second)))
+	}
+
+	rec, err := s.recordingRepo.GetLatestHLS(stream.ID)
+	if err != nil {
+		return err
+	}
+	if rec != nil && segStart.Sub(rec.EndTime) <= hlsSegmentGap {
+		// 重新推流后继续录制到同一条录制
+		if rec.Status == model.RecordingStatusCompleted && stream.Status == model.StreamStatusPushing {
+			if err := s.recordingRepo.UpdateStatus(rec.ID, model.RecordingStatusRecording); err != nil {
+				return err
+			}
+		}
+	} else {
+		if rec != nil && rec.Status == model.RecordingStatusRecording {
+			if err := s.recordingRepo.UpdateStatus(rec.ID, model.RecordingStatusCompleted); err != nil {
+				return err
+			}
+			s.enqueueHLS(stream, rec.ID)
+		}
+		rec = &model.Recording{
+			StreamID:      stream.ID,
+			MediaServerID: req.MediaSrvID,
+			Format:        model.RecordingFormatHLS,
+			Status:        model.RecordingStatusRecording,
+			FileName:      path.Base(req.Folder),
+			LocalPath:     req.Folder,
+			URL:           path.Dir(req.URL),
+			StartTime:     segStart,
+			EndTime:       segStart,
+		}
+		if err := s.recordingRepo.Create(rec); err != nil {
+			return err
+		}
+		s.statsSvc.Incr(model.StatRecordFiles, 1)
+	}
+
+	seg := &model.RecordingSegment{
+		RecordingID: rec.ID,
+		FileName:    req.FileName,
+		LocalPath:   req.FilePath,
+		URL:         req.URL,
+		FileSize:    req.FileSize,
+		Duration:    req.TimeLen,
+		StartTime:   segStart,
+	}
+	if err := s.recordingRepo.AddSegment(seg); err != nil {
+		return err
+	}
+	s.statsSvc.Incr(model.StatRecordSeconds, int64(math.Round(req.TimeLen)))
+	s.statsSvc.Incr(model.StatRecordBytes, req.FileSize)
+	return nil
+}
+
+// CompleteHLS 直播停止录制时将录制中的 HLS 录制标记为已完成（之后可以点播），并添加上传任务
+func (s *RecordingService) CompleteHLS(stream *model.Stream) {
+	ids, err := s.recordingRepo.CompleteRecordings(stream.ID)
+	if err != nil {
+		fmt.Printf("Failed to complete hls recordings for stream %s: %v\n", stream.StreamKey, err)
+		return
+	}
+	for _, id := range ids {
+		s.enqueueHLS(stream, id)
+	}
+}
+
+// enqueueHLS 为已完成的 HLS 录制添加上传任务
+// 继续录制后再次完成时重新添加，上传任务重置为待上传，已上传的切片被覆盖
+func (s *RecordingService) enqueueHLS(stream *model.Stream, id int64) {
+	rec, err := s.recordingRepo.GetByID(id)
+	if err != nil || rec == nil {
+		fmt.Printf("Failed to get hls recording %d: %v\n", id, err)
+		return
+	}
+	if err := s.uploadSvc.Enqueue(stream, rec); err != nil {
+		fmt.Printf("Failed to enqueue uploads for recording %d: %v\n", rec.ID, err)
+	}
+}
+
+// CompletedHLS 获取直播已完成且本地切片未删除的 HLS 录制（最近 100 条）
+func (s *RecordingService) CompletedHLS(stream *model.Stream) ([]*model.Recording, error) {
+	req := &model.RecordingListRequest{
+		Format: model.RecordingFormatHLS,
+		Status: model.RecordingStatusCompleted,
+	}
+	recordings, _, err := s.recordingRepo.ListByStream(stream.ID, req, 0, 100)
+	if err != nil {
+		return nil, err
+	}
+
+	available := make([]*model.Recording, 0, len(recordings))
+	for _, rec := range recordings {
+		if rec.LocalDeletedAt == nil {
+			available = append(available, rec)
+		}
+	}
+	return available, nil
+}
+
+// HLSSegments 获取直播已完成且本地切片未删除的 HLS 录制及其切片
+func (s *RecordingService) HLSSegments(stream *model.Stream, id int64) (*model.Recording, []*model.RecordingSegment, error) {
+	rec, err := s.recordingRepo.GetByID(id)
+	if err != nil {
+		return nil, nil, err
+	}
+	if rec == nil || rec.StreamID != stream.ID || rec.Format != model.RecordingFormatHLS ||
+		rec.Status != model.RecordingStatusCompleted || rec.LocalDeletedAt != nil {
+		return nil, nil, ErrRecordingNotFound
+	}
+
+	segments, err := s.recordingRepo.ListSegments(rec.ID)
+	if err != nil {
+		return nil, nil, err
+	}
+	return rec, segments, nil
+}
+
+// checksum 计算录制文件校验和，在回调请求结束后执行
+func (s *RecordingService) checksum(rec *model.Recording) {
+	checksum, err := fileChecksum(rec.LocalPath)
+	if err != nil {
+		fmt.Printf("Failed to checksum recording %d: %v\n", rec.ID, err)
+		return
+	}
+	if err := s.recordingRepo.UpdateChecksum(rec.ID, checksum); err != nil {
+		fmt.Printf("Failed to save checksum for recording %d: %v\n", rec.ID, err)
+	}
+}
+
+// List 分页获取直播的录制文件（管理员）
+func (s *RecordingService) List(key string, req *model.RecordingListRequest) (*model.RecordingListResponse, error) {
+	stream, err := s.streamRepo.GetByKey(key)
+	if err != nil {
+		return nil, err
+	}
+	if stream == nil {
+		return nil, ErrStreamNotFound
+	}
+	if req.From != nil && req.To != nil && !req.From.Before(*req.To) {
+		return nil, ErrInvalidTimeRange
+	}
+
+	if req.Page < 1 {
+		req.Page = 1
+	}
+	if req.PageSize < 1 {
+		req.PageSize = 20
+	}
+	if req.PageSize > 100 {
+		req.PageSize = 100
+	}
+	offset := (req.Page - 1) * req.PageSize
+
+	recordings, total, err := s.recordingRepo.ListByStream(stream.ID, req, offset, req.PageSize)
+	if err != nil {
+		return nil, err
+	}
+	return &model.RecordingListResponse{Total: total, Recordings: recordings}, nil
+}
+
+// Get 获取录制文件详情（管理员）
+func (s *RecordingService) Get(key string, id int64) (*model.Recording, error) {
+	_, rec, err := s.get(key, id)
+	return rec, err
+}
+
+// Delete 删除录制文件（管理员）
+// 先删除已上传到各存储目标的副本和节点上的本地文件，再删除数据库记录；
+// 录制中或上传未完成时不能删除，副本或本地文件删除失败时保留记录，可稍后重试
+func (s *RecordingService) Delete(key string, id int64, userID int64) error {
+	stream, rec, err := s.get(key, id)
+	if err != nil {
+		return err
+	}
+	if rec.Status == model.RecordingStatusRecording {
+		return ErrRecordingInProgress
+	}
+	for _, u := range rec.Uploads {
+		if u.Status == model.UploadStatusPending || u.Status == model.UploadStatusUploading {
+			return ErrRecordingUploading
+		}
+	}
+
+	for _, u := range rec.Uploads {
+		if u.Status != model.UploadStatusSuccess {
+			continue
+		}
+		if err := deleteRemote(s.storageManager, s.recordingRepo, rec, u); err != nil {
+			return fmt.Errorf("delete recording %d from storage %s failed: %w", rec.ID, u.Storage, err)
+		}
+	}
+
+	files, err := localFiles(s.recordingRepo, rec)
+	if err != nil {
+		return err
+	}
+	if err := removeFiles(s.zlmNodes, rec.MediaServerID, files); err != nil {
+		return fmt.Errorf("delete local files of recording %d failed: %w", rec.ID, err)
+	}
+
+	if err := s.recordingRepo.Delete(rec.ID); err != nil {
+		return err
+	}
+	if rec.Format == model.RecordingFormatMP4 && rec.LocalDeletedAt == nil {
+		if err := s.streamRepo.RemoveRecordFile(stream.StreamKey, rec.LocalPath); err != nil {
+			fmt.Printf("Failed to remove record file for stream %s: %v\n", stream.StreamKey, err)
+		}
+	}
+
+	s.auditSvc.Record(userID, model.ActionRecordingDelete, model.TargetTypeRecording, strconv.FormatInt(rec.ID, 10), map[string]interface{}{
+		"stream_key": stream.StreamKey,
+		"before":     rec,
+	})
+	return nil
+}
+
+// Update 更新录制文件（管理员），目前只支持收藏/取消收藏
+func (s *RecordingService) Update(key string, id int64, req *model.UpdateRecordingRequest, userID int64) (*model.Recording, error) {
+	stream, rec, err := s.get(key, id)
+	if err != nil {
+		return nil, err
+	}
+
+	before := *rec
+	if err := s.recordingRepo.UpdateStarred(rec.ID, *req.Starred); err != nil {
+		return nil, err
+	}
+	rec.Starred = *req.Starred
+
+	s.auditSvc.Record(userID, model.ActionRecordingUpdate, model.TargetTypeRecording, strconv.FormatInt(rec.ID, 10), map[string]interface{}{
+		"stream_key": stream.StreamKey,
+		"before":     map[string]interface{}{"starred": before.Starred},
+		"after":      map[string]interface{}{"starred": rec.Starred},
+	})
+	return rec, nil
+}
+
+// AuthorizeStream 校验用户是否可以管理该直播的录制文件
+func (s *RecordingService) AuthorizeStream(key string, userID int64, role string) error {
+	stream, err := s.streamRepo.GetByKey(key)
+	if err != nil {
+		return err
+	}
+	if stream == nil {
+		return ErrStreamNotFound
+	}
+	if !model.CanManageStream(role, userID, stream.CreatedBy) {
+		return ErrForbidden
+	}
+	return nil
+}
+
+// get 获取属于指定直播的录制文件
+func (s *RecordingService) get(key string, id int64) (*model.Stream, *model.Recording, error) {
+	stream, err := s.streamRepo.GetByKey(key)
+	if err != nil {
+		return nil, nil, err
+	}
+	if stream == nil {
+		return nil, nil, ErrStreamNotFound
+	}
+
+	rec, err := s.recordingRepo.GetByID(id)
+	if err != nil {
+		return nil, nil, err
+	}
+	if rec == nil || rec.StreamID != stream.ID {
+		return nil, nil, ErrRecordingNotFound
+	}
+	return stream, rec, nil
+}
+
+// localFile ZLMediaKit 节点上的一个录制文件
+type localFile struct {
+	path string // 节点上的文件路径
+	url  string // HTTP 文件服务器上的相对路径：record/{app}/{stream}/{period}/{name}
+}
+
+// localFiles 录制文件在 ZLMediaKit 节点上的本地文件，本地文件已删除时为空
+// HLS 录制返回各切片文件，切片目录可能被其他录制共用，不能整体删除
+func localFiles(recordingRepo *repository.RecordingRepository, rec *model.Recording) ([]localFile, error) {
+	if rec.LocalDeletedAt != nil {
+		return nil, nil
+	}
+	if rec.Format != model.RecordingFormatHLS {
+		return []localFile{{path: rec.LocalPath, url: rec.URL}}, nil
+	}
+
+	segments, err := recordingRepo.ListSegments(rec.ID)
+	if err != nil {
+		return nil, err
+	}
+	files := make([]localFile, 0, len(segments))
+	for _, seg := range segments {
+		files = append(files, localFile{path: seg.LocalPath, url: seg.URL})
+	}
+	return files, nil
+}
+
+// removeFiles 删除录制文件所在节点上的本地文件，删除失败的文件输出日志并返回最后一个错误
+func removeFiles(zlmNodes *zlm.Registry, nodeID string, files []localFile) error {
+	var lastErr error
+	for _, file := range files {
+		if err := removeFile(zlmNodes, nodeID, file); err != nil {
+			fmt.Printf("Failed to delete recording file %s on node %s: %v\n", file.path, nodeID, err)
+			lastErr = err
+		}
+	}
+	return lastErr
+}
+
+// removeFile 删除节点上的一个录制文件
+// 本机能看到文件所在目录时（与节点共用录制目录）直接删除，文件不存在视为已删除；
+// 否则通过节点的 deleteRecordDirectory 接口删除，节点上文件不存在同样返回错误
+func removeFile(zlmNodes *zlm.Registry, nodeID string, file localFile) error {
+	if _, err := os.Stat(filepath.Dir(file.path)); err == nil {
+		if err := os.Remove(file.path); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+		return nil
+	}
+
+	if !zlmNodes.Has(nodeID) {
+		return fmt.Errorf("zlm node %s is not registered", nodeID)
+	}
+	parts := strings.Split(strings.TrimPrefix(file.url, "/"), "/")
+	if len(parts) < 5 || parts[0] != recordHTTPDir {
+		return fmt.Errorf("unexpected record url %q", file.url)
+	}
+	n := len(parts)
+	return zlmNodes.Client(nodeID).DeleteRecordFile(parts[n-4], parts[n-3], parts[n-2], parts[n-1])
+}
+
+// fileChecksum 计算文件的 SHA-256
+func fileChecksum(path string) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
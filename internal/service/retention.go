@@ -0,0 +1,226 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+
+	"easy-stream/internal/config"
+	"easy-stream/internal/model"
+	"easy-stream/internal/repository"
+	"easy-stream/internal/storage"
+	"easy-stream/internal/zlm"
+)
+
+// retentionBatchSize 清理任务每批检查的录制文件数
+const retentionBatchSize = 200
+
+// RetentionService 录制文件保留策略清理任务
+// 保留天数从录制结束时间开始计算，优先级：收藏（永久保留）> 直播 retention_days > 存储目标 retentionDays > 全局设置；
+// 本地文件和所有存储目标上的副本都删除后，录制文件记录一并删除
+type RetentionService struct {
+	recordingRepo  *repository.RecordingRepository
+	storageManager *storage.Manager
+	zlmNodes       *zlm.Registry
+	auditSvc       *AuditService
+	cfg            config.StorageConfig
+}
+
+// NewRetentionService 创建录制文件保留策略清理任务
+func NewRetentionService(recordingRepo *repository.RecordingRepository, storageManager *storage.Manager, zlmNodes *zlm.Registry, auditSvc *AuditService, cfg config.StorageConfig) *RetentionService {
+	return &RetentionService{
+		recordingRepo:  recordingRepo,
+		storageManager: storageManager,
+		zlmNodes:       zlmNodes,
+		auditSvc:       auditSvc,
+		cfg:            cfg,
+	}
+}
+
+// retentionResult 一次清理的删除数量
+type retentionResult struct {
+	local      int // 删除的本地文件（录制文件数）
+	remote     int // 删除的存储目标副本
+	recordings int // 删除的录制文件记录
+}
+
+// Run 执行一次清理，每个删除操作输出一条日志
+func (s *RetentionService) Run() error {
+	now := time.Now()
+	var total retentionResult
+	var afterID int64
+	for {
+		recordings, err := s.recordingRepo.ListForRetention(afterID, retentionBatchSize)
+		if err != nil {
+			return err
+		}
+		for _, rec := range recordings {
+			afterID = rec.ID
+			res := s.apply(rec, now)
+			total.local += res.local
+			total.remote += res.remote
+			total.recordings += res.recordings
+		}
+		if len(recordings) < retentionBatchSize {
+			break
+		}
+	}
+
+	fmt.Printf("Recording retention finished: %d local files, %d remote copies, %d recordings deleted\n",
+		total.local, total.remote, total.recordings)
+	return nil
+}
+
+// apply 对一个录制文件执行保留策略
+func (s *RetentionService) apply(rec *model.Recording, now time.Time) retentionResult {
+	var res retentionResult
+
+	// 上传未完成时本地文件仍需使用，下次再检查
+	for _, u := range rec.Uploads {
+		if u.Status == model.UploadStatusPending || u.Status == model.UploadStatusUploading {
+			return res
+		}
+	}
+
+	localDays := s.cfg.Retention.LocalDays
+	remoteDays := s.remoteDays
+	if days := rec.StreamRetentionDays; days != nil {
+		// 直播单独设置的保留天数同时适用于本地文件和所有存储目标，0 表示永久保留
+		localDays = *days
+		remoteDays = func(string) int { return *days }
+	}
+
+	// 删除到期的存储目标副本
+	remaining := 0
+	for _, u := range rec.Uploads {
+		if u.Status != model.UploadStatusSuccess {
+			continue
+		}
+		if !retentionExpired(rec, remoteDays(u.Storage), now) {
+			remaining++
+			continue
+		}
+		if err := deleteRemote(s.storageManager, s.recordingRepo, rec, u); err != nil {
+			fmt.Printf("Retention: failed to delete recording %d from storage %s: %v\n", rec.ID, u.Storage, err)
+			remaining++
+			continue
+		}
+		res.remote++
+		fmt.Printf("Retention: deleted recording %d (%s) from storage %s\n", rec.ID, u.RemotePath, u.Storage)
+	}
+
+	// 删除到期的本地文件，或已上传到所有存储目标的本地文件
+	if rec.LocalDeletedAt == nil &&
+		(retentionExpired(rec, localDays, now) || (s.cfg.Retention.DeleteLocalAfterUpload && allUploaded(rec))) {
+		if err := s.deleteLocal(rec); err != nil {
+			fmt.Printf("Retention: failed to delete local files of recording %d: %v\n", rec.ID, err)
+		} else {
+			res.local++
+			fmt.Printf("Retention: deleted local files of recording %d (%s) on node %s\n", rec.ID, rec.LocalPath, rec.MediaServerID)
+		}
+	}
+
+	// 没有任何副本时删除录制文件记录
+	if rec.LocalDeletedAt != nil && remaining == 0 {
+		if err := s.recordingRepo.Delete(rec.ID); err != nil {
+			fmt.Printf("Retention: failed to delete recording %d: %v\n", rec.ID, err)
+			return res
+		}
+		res.recordings++
+		fmt.Printf("Retention: deleted recording %d of stream %d\n", rec.ID, rec.StreamID)
+		s.auditSvc.Record(0, model.ActionRecordingExpire, model.TargetTypeRecording, strconv.FormatInt(rec.ID, 10), map[string]interface{}{
+			"stream_id": rec.StreamID,
+			"before":    rec,
+		})
+	}
+	return res
+}
+
+// deleteRemote 删除存储目标上的副本并标记为已删除，HLS 录制删除上传目录下的所有切片
+func deleteRemote(storageManager *storage.Manager, recordingRepo *repository.RecordingRepository, rec *model.Recording, u *model.RecordingUpload) error {
+	var target storage.Storage
+	if storageManager != nil {
+		target = storageManager.Get(u.Storage)
+	}
+	if target == nil {
+		return fmt.Errorf("storage %s is not enabled", u.Storage)
+	}
+
+	paths := []string{u.RemotePath}
+	if rec.Format == model.RecordingFormatHLS {
+		ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+		objects, err := target.List(ctx, u.RemotePath+"/")
+		cancel()
+		if err != nil {
+			return err
+		}
+		// 已上传成功的目录下查不到切片时说明路径有误，不能当作已删除
+		if len(objects) == 0 {
+			return fmt.Errorf("no objects found under %s", u.RemotePath)
+		}
+		paths = paths[:0]
+		for _, obj := range objects {
+			paths = append(paths, obj.Path)
+		}
+	}
+	for _, p := range paths {
+		ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+		err := storageManager.DeleteFrom(ctx, target, p)
+		cancel()
+		if err != nil {
+			return err
+		}
+	}
+	if err := recordingRepo.MarkUploadDeleted(u.ID); err != nil {
+		return err
+	}
+	u.Status = model.UploadStatusDeleted
+	return nil
+}
+
+// deleteLocal 删除 ZLMediaKit 节点上的本地文件，全部删除成功后记录删除时间
+func (s *RetentionService) deleteLocal(rec *model.Recording) error {
+	files, err := localFiles(s.recordingRepo, rec)
+	if err != nil {
+		return err
+	}
+	if err := removeFiles(s.zlmNodes, rec.MediaServerID, files); err != nil {
+		return err
+	}
+	return s.recordingRepo.MarkLocalDeleted(rec)
+}
+
+// remoteDays 存储目标上副本的保留天数，0 表示永久保留
+func (s *RetentionService) remoteDays(name string) int {
+	for _, target := range s.cfg.Targets {
+		if target.Name != name {
+			continue
+		}
+		if target.RetentionDays < 0 {
+			return 0
+		}
+		if target.RetentionDays > 0 {
+			return target.RetentionDays
+		}
+	}
+	return s.cfg.Retention.RemoteDays
+}
+
+// retentionExpired 录制结束后是否已超过保留天数，days 为 0 表示永久保留
+func retentionExpired(rec *model.Recording, days int, now time.Time) bool {
+	return days > 0 && now.Sub(rec.EndTime) > time.Duration(days)*24*time.Hour
+}
+
+// allUploaded 是否已上传到所有存储目标（至少一个）且副本都未删除
+func allUploaded(rec *model.Recording) bool {
+	if len(rec.Uploads) == 0 {
+		return false
+	}
+	for _, u := range rec.Uploads {
+		if u.Status != model.UploadStatusSuccess {
+			return false
+		}
+	}
+	return true
+}
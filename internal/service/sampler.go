@@ -0,0 +1,153 @@
+package service
+
+import (
+	"fmt"
+	"math"
+	"sync"
+	"time"
+
+	"easy-stream/internal/model"
+	"easy-stream/internal/repository"
+	"easy-stream/internal/zlm"
+)
+
+// StreamSampler 直播质量采样器
+// 定时查询所有 pushing 状态的直播在 ZLMediaKit 中的媒体信息，
+// 将码率、帧率、分辨率、编码写入直播记录，视频帧数增长时刷新 last_frame_at，
+// 同时将本次采样追加到健康指标时序表
+type StreamSampler struct {
+	streamSvc  *StreamService
+	metricRepo *repository.StreamMetricRepository
+
+	mu     sync.Mutex
+	frames map[string]int64 // stream_key -> 上次采样时的视频帧数
+}
+
+// NewStreamSampler 创建直播质量采样器
+func NewStreamSampler(streamSvc *StreamService, metricRepo *repository.StreamMetricRepository) *StreamSampler {
+	return &StreamSampler{
+		streamSvc:  streamSvc,
+		metricRepo: metricRepo,
+		frames:     make(map[string]int64),
+	}
+}
+
+// Sample 执行一次采样（定时任务）
+func (s *StreamSampler) Sample() error {
+	streams, err := s.streamSvc.streamRepo.GetPushingStreams()
+	if err != nil {
+		return err
+	}
+
+	medias, err := s.streamSvc.fetchMedias("live")
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	alive := make(map[string]bool, len(streams))
+	metrics := make([]*model.StreamMetric, 0, len(streams))
+	for _, stream := range streams {
+		list, ok := medias.lookup(stream)
+		if !ok {
+			continue
+		}
+		alive[stream.StreamKey] = true
+
+		quality := s.merge(stream.StreamKey, list, now)
+		if err := s.streamSvc.streamRepo.UpdateQuality(stream.StreamKey, quality); err != nil {
+			fmt.Printf("failed to update quality for stream %s: %v\n", stream.StreamKey, err)
+		}
+
+		metrics = append(metrics, &model.StreamMetric{
+			StreamID:    stream.ID,
+			SampledAt:   now,
+			Bitrate:     quality.Bitrate,
+			BitrateMin:  quality.Bitrate,
+			FPS:         quality.FPS,
+			FPSMin:      quality.FPS,
+			ReaderCount: readerCount(list),
+			BytesSpeed:  quality.BytesSpeed,
+		})
+	}
+
+	if err := s.metricRepo.CreateBatch(metrics); err != nil {
+		fmt.Printf("failed to write stream metrics: %v\n", err)
+	}
+
+	// 清理已不在推流的采样状态
+	for key := range s.frames {
+		if !alive[key] {
+			delete(s.frames, key)
+		}
+	}
+
+	return nil
+}
+
+// merge 合并同一个流在各协议下的媒体信息
+// 同一个流会以多种协议（rtmp/rtsp/hls...）出现，码率取最大值，轨道信息取帧数最多的一路
+func (s *StreamSampler) merge(streamKey string, medias []zlm.MediaInfo, now time.Time) *model.StreamQuality {
+	var video, audio *zlm.Track
+	bytesSpeed := 0
+	for i := range medias {
+		if medias[i].BytesSpeed > bytesSpeed {
+			bytesSpeed = medias[i].BytesSpeed
+		}
+		for j := range medias[i].Tracks {
+			t := &medias[i].Tracks[j]
+			switch t.CodecType {
+			case zlm.TrackTypeVideo:
+				if video == nil || t.Frames > video.Frames {
+					video = t
+				}
+			case zlm.TrackTypeAudio:
+				if audio == nil {
+					audio = t
+				}
+			}
+		}
+	}
+
+	quality := &model.StreamQuality{
+		Bitrate:    bytesSpeed * 8 / 1000,
+		BytesSpeed: bytesSpeed,
+	}
+	if video != nil {
+		quality.FPS = int(math.Round(video.FPS))
+		quality.Width = video.Width
+		quality.Height = video.Height
+		if video.CodecIDName != "" {
+			quality.VideoCodec = strPtr(video.CodecIDName)
+		}
+
+		// 帧数增长说明收到了新画面；部分 ZLM 版本不返回帧计数，此时以轨道就绪且有码率为准
+		if video.Frames > s.frames[streamKey] || (video.Frames == 0 && video.Ready && bytesSpeed > 0) {
+			quality.LastFrameAt = &now
+		}
+		s.frames[streamKey] = video.Frames
+	}
+	if audio != nil && audio.CodecIDName != "" {
+		quality.AudioCodec = strPtr(audio.CodecIDName)
+	}
+	return quality
+}
+
+// readerCount 统计流的观看人数
+// totalReaderCount 是各协议观看人数之和（每个协议都会返回同一个值），旧版本 ZLM 不返回时累加各协议的 readerCount
+func readerCount(medias []zlm.MediaInfo) int {
+	total, sum := 0, 0
+	for _, m := range medias {
+		if m.TotalReaderCount > total {
+			total = m.TotalReaderCount
+		}
+		sum += m.ReaderCount
+	}
+	if total > 0 {
+		return total
+	}
+	return sum
+}
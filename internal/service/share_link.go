@@ -3,6 +3,7 @@ package service
 import (
 	"crypto/rand"
 	"encoding/hex"
+	"strconv"
 	"time"
 
 	"easy-stream/internal/model"
@@ -13,17 +14,23 @@ type ShareLinkService struct {
 	shareLinkRepo *repository.ShareLinkRepository
 	streamRepo    *repository.StreamRepository
 	redisRepo     *repository.RedisClient
+	auditSvc      *AuditService
+	statsSvc      *StatsService
 }
 
 func NewShareLinkService(
 	shareLinkRepo *repository.ShareLinkRepository,
 	streamRepo *repository.StreamRepository,
 	redisRepo *repository.RedisClient,
+	auditSvc *AuditService,
+	statsSvc *StatsService,
 ) *ShareLinkService {
 	return &ShareLinkService{
 		shareLinkRepo: shareLinkRepo,
 		streamRepo:    streamRepo,
 		redisRepo:     redisRepo,
+		auditSvc:      auditSvc,
+		statsSvc:      statsSvc,
 	}
 }
 
@@ -59,6 +66,11 @@ func (s *ShareLinkService) Create(streamKey string, req *model.CreateShareLinkRe
 		return nil, err
 	}
 
+	s.auditSvc.Record(userID, model.ActionShareLinkCreate, model.TargetTypeShareLink, strconv.FormatInt(link.ID, 10), map[string]interface{}{
+		"stream_key": streamKey,
+		"max_uses":   link.MaxUses,
+	})
+
 	return &model.CreateShareLinkResponse{
 		ID:        link.ID,
 		Token:     token,
@@ -68,6 +80,33 @@ func (s *ShareLinkService) Create(streamKey string, req *model.CreateShareLinkRe
 	}, nil
 }
 
+// Authorize 校验用户是否可以管理该分享链接所属的直播（操作员只能管理自己创建的直播）
+func (s *ShareLinkService) Authorize(linkID int64, userID int64, role string) error {
+	link, err := s.shareLinkRepo.GetByID(linkID)
+	if err != nil {
+		return err
+	}
+	if link == nil {
+		return ErrShareLinkNotFound
+	}
+	return s.AuthorizeStream(link.StreamKey, userID, role)
+}
+
+// AuthorizeStream 校验用户是否可以管理该直播的分享链接
+func (s *ShareLinkService) AuthorizeStream(streamKey string, userID int64, role string) error {
+	stream, err := s.streamRepo.GetByKey(streamKey)
+	if err != nil {
+		return err
+	}
+	if stream == nil {
+		return ErrStreamNotFound
+	}
+	if !model.CanManageStream(role, userID, stream.CreatedBy) {
+		return ErrForbidden
+	}
+	return nil
+}
+
 // List 获取直播的所有分享链接（管理员）
 func (s *ShareLinkService) List(streamKey string) (*model.ShareLinkListResponse, error) {
 	stream, err := s.streamRepo.GetByKey(streamKey)
@@ -122,6 +161,7 @@ func (s *ShareLinkService) VerifyToken(token string) (*model.StreamAccessToken,
 	if err := s.shareLinkRepo.IncrementUsedCount(token); err != nil {
 		return nil, err
 	}
+	s.statsSvc.Incr(model.StatShareLinkUses, 1)
 
 	// 生成访问令牌（有效期2小时）
 	accessToken, err := s.generateToken()
@@ -142,7 +182,7 @@ func (s *ShareLinkService) VerifyToken(token string) (*model.StreamAccessToken,
 }
 
 // UpdateMaxUses 更新分享链接最大使用次数（管理员）
-func (s *ShareLinkService) UpdateMaxUses(linkID int64, maxUses int) (*model.ShareLink, error) {
+func (s *ShareLinkService) UpdateMaxUses(linkID int64, maxUses int, userID int64) (*model.ShareLink, error) {
 	link, err := s.shareLinkRepo.GetByID(linkID)
 	if err != nil {
 		return nil, err
@@ -155,11 +195,17 @@ func (s *ShareLinkService) UpdateMaxUses(linkID int64, maxUses int) (*model.Shar
 		return nil, err
 	}
 
-	return s.shareLinkRepo.GetByID(linkID)
+	updated, err := s.shareLinkRepo.GetByID(linkID)
+	if err != nil {
+		return nil, err
+	}
+
+	s.auditSvc.Record(userID, model.ActionShareLinkUpdate, model.TargetTypeShareLink, strconv.FormatInt(linkID, 10), auditChanges(link, updated))
+	return updated, nil
 }
 
 // Delete 删除分享链接（管理员）
-func (s *ShareLinkService) Delete(linkID int64) error {
+func (s *ShareLinkService) Delete(linkID int64, userID int64) error {
 	link, err := s.shareLinkRepo.GetByID(linkID)
 	if err != nil {
 		return err
@@ -168,7 +214,12 @@ func (s *ShareLinkService) Delete(linkID int64) error {
 		return ErrShareLinkNotFound
 	}
 
-	return s.shareLinkRepo.Delete(linkID)
+	if err := s.shareLinkRepo.Delete(linkID); err != nil {
+		return err
+	}
+
+	s.auditSvc.Record(userID, model.ActionShareLinkDelete, model.TargetTypeShareLink, strconv.FormatInt(linkID, 10), map[string]interface{}{"before": link})
+	return nil
 }
 
 // generateToken 生成 token
@@ -0,0 +1,184 @@
+package service
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"easy-stream/internal/model"
+	"easy-stream/internal/repository"
+	"easy-stream/internal/zlm"
+)
+
+const (
+	statDateLayout   = "2006-01-02"
+	defaultStatsDays = 7   // 未指定日期范围时默认查询最近 7 天
+	maxStatsDays     = 366 // 单次查询最多返回的天数
+)
+
+// StatsService 系统统计服务
+// 业务事件发生时累加当天计数，定时任务记录当天的并发峰值
+type StatsService struct {
+	dailyStatRepo *repository.DailyStatRepository
+	streamRepo    *repository.StreamRepository
+	zlmNodes      *zlm.Registry
+}
+
+func NewStatsService(dailyStatRepo *repository.DailyStatRepository, streamRepo *repository.StreamRepository, zlmNodes *zlm.Registry) *StatsService {
+	return &StatsService{
+		dailyStatRepo: dailyStatRepo,
+		streamRepo:    streamRepo,
+		zlmNodes:      zlmNodes,
+	}
+}
+
+// Incr 累加当天的计数字段
+// 写入失败只打印错误，不影响业务流程
+func (s *StatsService) Incr(field string, delta int64) {
+	if delta == 0 {
+		return
+	}
+	if err := s.dailyStatRepo.Increment(time.Now().Format(statDateLayout), field, delta); err != nil {
+		fmt.Printf("failed to increment daily stat %s: %v\n", field, err)
+	}
+}
+
+// RecordPeak 用当前观看人数和推流数刷新当天峰值（定时任务）
+func (s *StatsService) RecordPeak() error {
+	counts, viewers, err := s.streamRepo.CountStats()
+	if err != nil {
+		return err
+	}
+	return s.dailyStatRepo.UpdatePeak(time.Now().Format(statDateLayout), int(viewers.Current), int(counts.Pushing))
+}
+
+// Snapshot 获取系统实时统计
+func (s *StatsService) Snapshot() (*model.SystemStats, error) {
+	now := time.Now()
+	today := now.Format(statDateLayout)
+
+	counts, viewers, err := s.streamRepo.CountStats()
+	if err != nil {
+		return nil, err
+	}
+
+	// 顺便刷新今日峰值，保证返回的峰值不低于当前值
+	if err := s.dailyStatRepo.UpdatePeak(today, int(viewers.Current), int(counts.Pushing)); err != nil {
+		return nil, err
+	}
+	todayStat, err := s.dailyStatRepo.Get(today)
+	if err != nil {
+		return nil, err
+	}
+	if todayStat == nil {
+		todayStat = &model.DailyStat{Date: today}
+	}
+	viewers.PeakToday = todayStat.PeakViewers
+
+	recordings, shares, err := s.dailyStatRepo.Totals()
+	if err != nil {
+		return nil, err
+	}
+
+	return &model.SystemStats{
+		OnlineStreams: counts.Pushing,
+		TotalStreams:  counts.Total,
+		Streams:       counts,
+		Viewers:       viewers,
+		Recordings:    recordings,
+		Shares:        shares,
+		ZLM:           s.zlmStats(),
+		Today:         todayStat,
+		GeneratedAt:   now,
+	}, nil
+}
+
+// Daily 查询 [from, to] 日期范围内的每日统计，没有数据的日期补零
+// from/to 为空时默认最近 7 天
+func (s *StatsService) Daily(from, to *time.Time) (*model.DailyStatsResponse, error) {
+	end := time.Now()
+	if to != nil {
+		end = *to
+	}
+	end = time.Date(end.Year(), end.Month(), end.Day(), 0, 0, 0, 0, time.Local)
+	start := end.AddDate(0, 0, -(defaultStatsDays - 1))
+	if from != nil {
+		start = time.Date(from.Year(), from.Month(), from.Day(), 0, 0, 0, 0, time.Local)
+	}
+	if start.After(end) {
+		return nil, ErrInvalidTimeRange
+	}
+	if !start.AddDate(0, 0, maxStatsDays).After(end) {
+		return nil, ErrDateRangeTooLong
+	}
+
+	startDate, endDate := start.Format(statDateLayout), end.Format(statDateLayout)
+	stats, err := s.dailyStatRepo.ListRange(startDate, endDate)
+	if err != nil {
+		return nil, err
+	}
+	byDate := make(map[string]*model.DailyStat, len(stats))
+	for _, stat := range stats {
+		byDate[stat.Date] = stat
+	}
+
+	days := make([]*model.DailyStat, 0)
+	for d := start; !d.After(end); d = d.AddDate(0, 0, 1) {
+		date := d.Format(statDateLayout)
+		if stat, ok := byDate[date]; ok {
+			days = append(days, stat)
+		} else {
+			days = append(days, &model.DailyStat{Date: date})
+		}
+	}
+
+	return &model.DailyStatsResponse{
+		From: startDate,
+		To:   endDate,
+		Days: days,
+	}, nil
+}
+
+// zlmStats 从各 ZLMediaKit 节点的媒体列表汇总流数量、观看人数和数据速率
+// 同一个流会以多种协议出现，观看人数和数据速率按流合并后再累加
+func (s *StatsService) zlmStats() *model.ZLMStats {
+	nodes := s.zlmNodes.Nodes()
+	stats := &model.ZLMStats{Nodes: len(nodes)}
+
+	var failures []string
+	for _, node := range nodes {
+		resp, err := node.Client.GetMediaList("", "")
+		if err != nil {
+			failures = append(failures, fmt.Sprintf("%s: connection failed: %v", node.ID, err))
+			continue
+		}
+		if resp.Code != 0 {
+			failures = append(failures, fmt.Sprintf("%s: getMediaList returned code %d", node.ID, resp.Code))
+			continue
+		}
+		stats.NodesOnline++
+
+		medias := make(map[string][]zlm.MediaInfo)
+		for _, m := range resp.Data {
+			key := m.App + "/" + m.Stream
+			medias[key] = append(medias[key], m)
+		}
+
+		stats.Streams += len(medias)
+		stats.MediaSources += len(resp.Data)
+		for _, list := range medias {
+			stats.Readers += readerCount(list)
+			bytesSpeed := 0
+			for _, m := range list {
+				if m.BytesSpeed > bytesSpeed {
+					bytesSpeed = m.BytesSpeed
+				}
+			}
+			stats.BytesSpeed += bytesSpeed
+		}
+	}
+
+	stats.Available = stats.NodesOnline > 0
+	stats.Message = strings.Join(failures, "; ")
+	return stats
+}
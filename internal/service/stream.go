@@ -17,15 +17,34 @@ type StreamService struct {
 	streamRepo    *repository.StreamRepository
 	shareLinkRepo *repository.ShareLinkRepository
 	redisRepo     *repository.RedisClient
-	zlmClient     *zlm.Client
+	zlmNodes      *zlm.Registry
+	pushCfg       config.PushConfig
+	playCfg       config.PlayConfig
+	auditSvc      *AuditService
+	statsSvc      *StatsService
+	viewerSvc     *ViewerService
+	recordingSvc  *RecordingService
 }
 
-func NewStreamService(streamRepo *repository.StreamRepository, shareLinkRepo *repository.ShareLinkRepository, redisRepo *repository.RedisClient, zlmCfg config.ZLMediaKitConfig) *StreamService {
+func NewStreamService(streamRepo *repository.StreamRepository, shareLinkRepo *repository.ShareLinkRepository, redisRepo *repository.RedisClient, zlmNodes *zlm.Registry, pushCfg config.PushConfig, playCfg config.PlayConfig, auditSvc *AuditService, statsSvc *StatsService, viewerSvc *ViewerService, recordingSvc *RecordingService) *StreamService {
+	// 未配置推流地址时，使用默认 ZLMediaKit 节点的地址
+	if pushCfg.Host == "" {
+		pushCfg.Host = zlmNodes.Default().Host
+	}
+	if playCfg.Host == "" {
+		playCfg.Host = pushCfg.Host
+	}
 	return &StreamService{
 		streamRepo:    streamRepo,
 		shareLinkRepo: shareLinkRepo,
 		redisRepo:     redisRepo,
-		zlmClient:     zlm.NewClient(zlmCfg.Host, zlmCfg.Port, zlmCfg.Secret),
+		zlmNodes:      zlmNodes,
+		pushCfg:       pushCfg,
+		playCfg:       playCfg,
+		auditSvc:      auditSvc,
+		statsSvc:      statsSvc,
+		viewerSvc:     viewerSvc,
+		recordingSvc:  recordingSvc,
 	}
 }
 
@@ -36,6 +55,16 @@ func (s *StreamService) Create(req *model.CreateStreamRequest, userID int64) (*m
 		return nil, fmt.Errorf("scheduled end time must be after start time")
 	}
 
+	allowedCIDRs, err := normalizeCIDRs(req.AllowedCIDRs)
+	if err != nil {
+		return nil, err
+	}
+
+	recordFormat := req.RecordFormat
+	if recordFormat == "" {
+		recordFormat = model.RecordFormatMP4
+	}
+
 	// 设置默认超时时间（30分钟）
 	autoKickDelay := req.AutoKickDelay
 	if autoKickDelay == 0 {
@@ -44,6 +73,7 @@ func (s *StreamService) Create(req *model.CreateStreamRequest, userID int64) (*m
 
 	stream := &model.Stream{
 		StreamKey:          utils.GenerateStreamKey(),
+		PlaybackID:         utils.GeneratePlaybackID(),
 		Name:               req.Name,
 		Description:        strPtr(req.Description),
 		DeviceID:           strPtr(req.DeviceID),
@@ -51,11 +81,16 @@ func (s *StreamService) Create(req *model.CreateStreamRequest, userID int64) (*m
 		Visibility:         req.Visibility,
 		RecordEnabled:      req.RecordEnabled,
 		RecordFiles:        model.StringArray{},
+		RecordFormat:       recordFormat,
+		RetentionDays:      req.RetentionDays,
 		StreamerName:       strPtr(req.StreamerName),
 		StreamerContact:    strPtr(req.StreamerContact),
 		ScheduledStartTime: req.ScheduledStartTime,
 		ScheduledEndTime:   req.ScheduledEndTime,
 		AutoKickDelay:      autoKickDelay,
+		PushSecret:         strPtr(generatePushSecret()),
+		PushAuthEnabled:    req.PushAuthEnabled,
+		AllowedCIDRs:       allowedCIDRs,
 		CreatedBy:          userID,
 	}
 
@@ -73,6 +108,9 @@ func (s *StreamService) Create(req *model.CreateStreamRequest, userID int64) (*m
 	if err := s.streamRepo.Create(stream); err != nil {
 		return nil, err
 	}
+
+	s.auditSvc.Record(userID, model.ActionStreamCreate, model.TargetTypeStream, stream.StreamKey, map[string]interface{}{"after": stream})
+	s.statsSvc.Incr(model.StatStreamsCreated, 1)
 	return stream, nil
 }
 
@@ -107,8 +145,8 @@ func (s *StreamService) Get(key string, isLoggedIn bool, accessToken string) (*m
 	return nil, ErrPrivateStream
 }
 
-// List 获取推流列表（游客只能看公开且正在直播的，管理员能看所有）
-func (s *StreamService) List(req *model.StreamListRequest, isLoggedIn bool, accessToken string) (*model.StreamListResponse, error) {
+// List 获取推流列表（游客只能看公开且正在直播的，有查看直播权限的用户能看所有）
+func (s *StreamService) List(req *model.StreamListRequest, canViewAll bool, accessToken string) (*model.StreamListResponse, error) {
 	if req.Page < 1 {
 		req.Page = 1
 	}
@@ -117,8 +155,8 @@ func (s *StreamService) List(req *model.StreamListRequest, isLoggedIn bool, acce
 	}
 	offset := (req.Page - 1) * req.PageSize
 
-	// 未登录用户只能看公开且正在直播的
-	if !isLoggedIn {
+	// 游客只能看公开且正在直播的
+	if !canViewAll {
 		req.Visibility = model.StreamVisibilityPublic
 		req.Status = model.StreamStatusPushing
 		req.TimeRange = "" // 游客不能使用时间范围过滤
@@ -130,7 +168,7 @@ func (s *StreamService) List(req *model.StreamListRequest, isLoggedIn bool, acce
 	}
 
 	// 如果游客传入了 access_token，尝试获取对应的私有直播
-	if !isLoggedIn && accessToken != "" {
+	if !canViewAll && accessToken != "" {
 		fmt.Printf("[DEBUG] List: accessToken provided: %s\n", accessToken[:min(16, len(accessToken))]+"...")
 		streamKey, err := s.redisRepo.GetStreamKeyByAccessToken(accessToken)
 		fmt.Printf("[DEBUG] List: GetStreamKeyByAccessToken result: streamKey=%s, err=%v\n", streamKey, err)
@@ -179,13 +217,28 @@ func (s *StreamService) GetByID(id int64) (*model.Stream, error) {
 	return stream, nil
 }
 
+// Authorize 校验用户是否可以操作该直播（操作员只能操作自己创建的直播）
+func (s *StreamService) Authorize(key string, userID int64, role string) error {
+	stream, err := s.streamRepo.GetByKey(key)
+	if err != nil {
+		return err
+	}
+	if stream == nil {
+		return ErrStreamNotFound
+	}
+	if !model.CanManageStream(role, userID, stream.CreatedBy) {
+		return ErrForbidden
+	}
+	return nil
+}
+
 // VerifyAccessToken 验证访问令牌
 func (s *StreamService) VerifyAccessToken(streamKey, accessToken string) (bool, error) {
 	return s.redisRepo.VerifyStreamAccessToken(streamKey, accessToken)
 }
 
 // Update 更新推流信息（管理员）
-func (s *StreamService) Update(key string, req *model.UpdateStreamRequest) (*model.Stream, error) {
+func (s *StreamService) Update(key string, req *model.UpdateStreamRequest, userID int64) (*model.Stream, error) {
 	stream, err := s.streamRepo.GetByKey(key)
 	if err != nil {
 		return nil, err
@@ -193,6 +246,7 @@ func (s *StreamService) Update(key string, req *model.UpdateStreamRequest) (*mod
 	if stream == nil {
 		return nil, ErrStreamNotFound
 	}
+	before := *stream
 
 	// 更新字段
 	if req.Name != "" {
@@ -235,43 +289,85 @@ func (s *StreamService) Update(key string, req *model.UpdateStreamRequest) (*mod
 	if req.AutoKickDelay != nil {
 		stream.AutoKickDelay = *req.AutoKickDelay
 	}
+	if req.PushAuthEnabled != nil {
+		stream.PushAuthEnabled = *req.PushAuthEnabled
+		if stream.PushAuthEnabled && stream.PushSecret == nil {
+			stream.PushSecret = strPtr(generatePushSecret())
+		}
+	}
+	if req.AllowedCIDRs != nil {
+		allowedCIDRs, err := normalizeCIDRs(*req.AllowedCIDRs)
+		if err != nil {
+			return nil, err
+		}
+		stream.AllowedCIDRs = allowedCIDRs
+	}
 
-	// 处理动态录制开关
-	if req.RecordEnabled != nil {
-		oldRecordEnabled := stream.RecordEnabled
-		newRecordEnabled := *req.RecordEnabled
-
-		// 如果录制状态发生变化且正在推流
-		if oldRecordEnabled != newRecordEnabled && stream.Status == model.StreamStatusPushing {
-			if newRecordEnabled {
-				// 开启录制
-				if _, err := s.zlmClient.StartRecord("live", key, zlm.RecordTypeMP4, ""); err != nil {
-					// 记录错误但不阻止更新
-					fmt.Printf("failed to start record for stream %s: %v\n", key, err)
+	// 处理动态录制开关和录制格式
+	if req.RecordEnabled != nil || req.RecordFormat != "" {
+		oldTypes := recordTypes(stream)
+		if req.RecordEnabled != nil {
+			stream.RecordEnabled = *req.RecordEnabled
+		}
+		if req.RecordFormat != "" {
+			stream.RecordFormat = req.RecordFormat
+		}
+		newTypes := recordTypes(stream)
+
+		// 正在推流时，停止不再需要的录制类型，开始新增的录制类型（失败只记录错误，不阻止更新）
+		if stream.Status == model.StreamStatusPushing {
+			client := s.zlmFor(stream)
+			for _, t := range oldTypes {
+				if !containsRecordType(newTypes, t) {
+					s.stopRecord(client, stream, t)
 				}
-			} else {
-				// 关闭录制
-				if _, err := s.zlmClient.StopRecord("live", key, zlm.RecordTypeMP4); err != nil {
-					fmt.Printf("failed to stop record for stream %s: %v\n", key, err)
+			}
+			for _, t := range newTypes {
+				if !containsRecordType(oldTypes, t) {
+					s.startRecord(client, stream, t)
 				}
 			}
 		}
-		stream.RecordEnabled = newRecordEnabled
+	}
+
+	// 录制保留天数，负数表示恢复使用全局保留策略
+	if req.RetentionDays != nil {
+		if *req.RetentionDays < 0 {
+			stream.RetentionDays = nil
+		} else {
+			days := *req.RetentionDays
+			stream.RetentionDays = &days
+		}
 	}
 
 	if err := s.streamRepo.Update(stream); err != nil {
 		return nil, err
 	}
+
+	s.auditSvc.Record(userID, model.ActionStreamUpdate, model.TargetTypeStream, key, auditChanges(&before, stream))
 	return stream, nil
 }
 
 // Delete 删除推流码（管理员）
-func (s *StreamService) Delete(key string) error {
-	return s.streamRepo.Delete(key)
+func (s *StreamService) Delete(key string, userID int64) error {
+	stream, err := s.streamRepo.GetByKey(key)
+	if err != nil {
+		return err
+	}
+	if stream == nil {
+		return ErrStreamNotFound
+	}
+
+	if err := s.streamRepo.Delete(key); err != nil {
+		return err
+	}
+
+	s.auditSvc.Record(userID, model.ActionStreamDelete, model.TargetTypeStream, key, map[string]interface{}{"before": stream})
+	return nil
 }
 
 // Kick 强制断流（管理员）- 只断开推流，不结束直播
-func (s *StreamService) Kick(key string) error {
+func (s *StreamService) Kick(key string, userID int64) error {
 	stream, err := s.streamRepo.GetByKey(key)
 	if err != nil {
 		return err
@@ -280,21 +376,27 @@ func (s *StreamService) Kick(key string) error {
 		return ErrStreamNotFound
 	}
 
-	// 调用 ZLMediaKit 踢流
-	_, err = s.zlmClient.CloseStreams("live", key, true)
+	// 调用推流所在节点踢流
+	_, err = s.zlmFor(stream).CloseStreams("live", stream.PlaybackID, true)
 	if err != nil {
 		return err
 	}
 
 	// 状态改为 idle，记录断流时间（OnUnpublish 回调也会处理，这里是备份）
+	before := *stream
 	now := time.Now()
 	stream.LastUnpublishAt = &now
 	stream.Status = model.StreamStatusIdle
-	return s.streamRepo.Update(stream)
+	if err := s.streamRepo.Update(stream); err != nil {
+		return err
+	}
+
+	s.auditSvc.Record(userID, model.ActionStreamKick, model.TargetTypeStream, key, auditChanges(&before, stream))
+	return nil
 }
 
 // End 手动结束直播（管理员）- 断流并标记为结束
-func (s *StreamService) End(key string) error {
+func (s *StreamService) End(key string, userID int64) error {
 	stream, err := s.streamRepo.GetByKey(key)
 	if err != nil {
 		return err
@@ -305,16 +407,18 @@ func (s *StreamService) End(key string) error {
 
 	// 如果正在推流，先断流
 	if stream.Status == model.StreamStatusPushing {
-		_, _ = s.zlmClient.CloseStreams("live", key, true)
+		_, _ = s.zlmFor(stream).CloseStreams("live", stream.PlaybackID, true)
 	}
 
 	// 执行结束流程
-	return s.endStreamInternal(stream)
+	return s.endStreamInternal(stream, model.StreamEndReasonManual, userID)
 }
 
 // endStreamInternal 内部方法：执行结束直播的所有清理工作
-func (s *StreamService) endStreamInternal(stream *model.Stream) error {
+// reason: 结束原因，记录到 streams.end_reason；userID: 操作用户，0 表示系统自动结束
+func (s *StreamService) endStreamInternal(stream *model.Stream, reason string, userID int64) error {
 	streamKey := stream.StreamKey
+	before := *stream
 
 	// 清理 Redis 中的访问令牌（分享码和分享链接生成的令牌）
 	if err := s.redisRepo.DeleteStreamAccessTokens(streamKey); err != nil {
@@ -333,19 +437,24 @@ func (s *StreamService) endStreamInternal(stream *model.Stream) error {
 		fmt.Printf("failed to delete share links for stream %s: %v\n", streamKey, err)
 	}
 
-	// 重置当前观看人数
-	s.streamRepo.ResetCurrentViewers(streamKey)
+	// 结束观看会话，重置当前观看人数
+	s.viewerSvc.EndStream(stream)
 
 	// 更新状态为已结束
 	now := time.Now()
 	stream.ActualEndTime = &now
 	stream.Status = model.StreamStatusEnded
-	stream.CurrentViewers = 0
+	stream.EndReason = &reason
 	stream.ShareCode = nil
 	stream.ShareCodeMaxUses = 0
 	stream.ShareCodeUsedCount = 0
 
-	return s.streamRepo.Update(stream)
+	if err := s.streamRepo.Update(stream); err != nil {
+		return err
+	}
+
+	s.auditSvc.Record(userID, model.ActionStreamEnd, model.TargetTypeStream, streamKey, auditChanges(&before, stream))
+	return nil
 }
 
 // VerifyShareCode 验证分享码（游客）
@@ -372,6 +481,7 @@ func (s *StreamService) VerifyShareCode(shareCode string) (*model.StreamAccessTo
 	if err := s.streamRepo.IncrementShareCodeUsedCount(stream.StreamKey); err != nil {
 		return nil, err
 	}
+	s.statsSvc.Incr(model.StatShareCodeUses, 1)
 
 	// 生成访问令牌（有效期2小时）
 	token, err := s.generateAccessToken()
@@ -392,7 +502,7 @@ func (s *StreamService) VerifyShareCode(shareCode string) (*model.StreamAccessTo
 }
 
 // AddShareCode 为直播添加分享码（管理员）
-func (s *StreamService) AddShareCode(streamKey string, maxUses int) (*model.Stream, error) {
+func (s *StreamService) AddShareCode(streamKey string, maxUses int, userID int64) (*model.Stream, error) {
 	stream, err := s.streamRepo.GetByKey(streamKey)
 	if err != nil {
 		return nil, err
@@ -411,11 +521,11 @@ func (s *StreamService) AddShareCode(streamKey string, maxUses int) (*model.Stre
 		return nil, err
 	}
 
-	return s.streamRepo.GetByKey(streamKey)
+	return s.recordShareCodeChange(userID, model.ActionShareCodeAdd, stream)
 }
 
 // RegenerateShareCode 重新生成分享码（管理员）
-func (s *StreamService) RegenerateShareCode(streamKey string, req *model.RegenerateShareCodeRequest) (*model.Stream, error) {
+func (s *StreamService) RegenerateShareCode(streamKey string, req *model.RegenerateShareCodeRequest, userID int64) (*model.Stream, error) {
 	stream, err := s.streamRepo.GetByKey(streamKey)
 	if err != nil {
 		return nil, err
@@ -439,11 +549,11 @@ func (s *StreamService) RegenerateShareCode(streamKey string, req *model.Regener
 		return nil, err
 	}
 
-	return s.streamRepo.GetByKey(streamKey)
+	return s.recordShareCodeChange(userID, model.ActionShareCodeRegenerate, stream)
 }
 
 // UpdateShareCodeMaxUses 更新分享码最大使用次数（管理员）
-func (s *StreamService) UpdateShareCodeMaxUses(streamKey string, maxUses int) (*model.Stream, error) {
+func (s *StreamService) UpdateShareCodeMaxUses(streamKey string, maxUses int, userID int64) (*model.Stream, error) {
 	stream, err := s.streamRepo.GetByKey(streamKey)
 	if err != nil {
 		return nil, err
@@ -460,11 +570,11 @@ func (s *StreamService) UpdateShareCodeMaxUses(streamKey string, maxUses int) (*
 		return nil, err
 	}
 
-	return s.streamRepo.GetByKey(streamKey)
+	return s.recordShareCodeChange(userID, model.ActionShareCodeUpdate, stream)
 }
 
 // DeleteShareCode 删除分享码（管理员）
-func (s *StreamService) DeleteShareCode(streamKey string) error {
+func (s *StreamService) DeleteShareCode(streamKey string, userID int64) error {
 	stream, err := s.streamRepo.GetByKey(streamKey)
 	if err != nil {
 		return err
@@ -473,22 +583,50 @@ func (s *StreamService) DeleteShareCode(streamKey string) error {
 		return ErrStreamNotFound
 	}
 
-	return s.streamRepo.DeleteShareCode(streamKey)
+	if err := s.streamRepo.DeleteShareCode(streamKey); err != nil {
+		return err
+	}
+
+	_, err = s.recordShareCodeChange(userID, model.ActionShareCodeDelete, stream)
+	return err
+}
+
+// recordShareCodeChange 重新读取直播信息，并记录分享码变更前后的差异
+func (s *StreamService) recordShareCodeChange(userID int64, action string, before *model.Stream) (*model.Stream, error) {
+	after, err := s.streamRepo.GetByKey(before.StreamKey)
+	if err != nil {
+		return nil, err
+	}
+	s.auditSvc.Record(userID, action, model.TargetTypeStream, before.StreamKey, auditChanges(before, after))
+	return after, nil
 }
 
 // OnPublish 处理推流开始回调
-func (s *StreamService) OnPublish(req *model.OnPublishRequest) error {
-	stream, err := s.streamRepo.GetByKey(req.Stream)
+// 推流端使用 stream_key 推流，返回的直播的 playback_id 通过 stream_replace 作为 ZLMediaKit 中的流名称，
+// 之后的回调和 API 调用都使用 playback_id
+func (s *StreamService) OnPublish(req *model.OnPublishRequest) (*model.Stream, error) {
+	stream, err := s.getByPublishKey(req.Stream)
 	if err != nil {
-		return err
+		return nil, err
 	}
 	if stream == nil {
-		return ErrStreamNotFound
+		return nil, ErrStreamNotFound
 	}
 
 	// 检查流状态，已结束的流不允许再次推流
 	if stream.Status == model.StreamStatusEnded {
-		return ErrStreamExpired
+		return nil, ErrStreamExpired
+	}
+
+	// 校验推流端：设备绑定、IP 白名单、推流签名
+	if err := s.checkPublisher(stream, req); err != nil {
+		fmt.Printf("publish rejected for stream %s from %s (%s): %v\n", req.Stream, req.IP, req.Schema, err)
+		s.auditSvc.Record(0, model.ActionStreamPublishRejected, model.TargetTypeStream, req.Stream, map[string]interface{}{
+			"ip":     req.IP,
+			"schema": req.Schema,
+			"reason": err.Error(),
+		})
+		return nil, err
 	}
 
 	// 更新状态和实际开始时间
@@ -496,71 +634,220 @@ func (s *StreamService) OnPublish(req *model.OnPublishRequest) error {
 	stream.Status = model.StreamStatusPushing
 	stream.Protocol = strPtr(req.Schema)
 	stream.ActualStartTime = &now
+	if req.MediaSrvID != "" {
+		stream.MediaServerID = strPtr(req.MediaSrvID)
+	}
 
 	if err := s.streamRepo.Update(stream); err != nil {
-		return err
+		return nil, err
 	}
+	s.statsSvc.Incr(model.StatPublishes, 1)
 
-	// 如果开启了录制，自动开始录制
-	if stream.RecordEnabled {
+	// 如果开启了录制，按录制格式自动开始录制
+	if types := recordTypes(stream); len(types) > 0 {
 		go func() {
-			if _, err := s.zlmClient.StartRecord("live", req.Stream, zlm.RecordTypeMP4, ""); err != nil {
-				fmt.Printf("failed to start record for stream %s: %v\n", req.Stream, err)
+			client := s.zlmNodes.Client(req.MediaSrvID)
+			for _, t := range types {
+				s.startRecord(client, stream, t)
 			}
 		}()
 	}
 
-	return nil
+	return stream, nil
+}
+
+// getByPublishKey 根据推流使用的推流码获取直播，更换推流码的宽限期内旧推流码同样有效
+func (s *StreamService) getByPublishKey(key string) (*model.Stream, error) {
+	stream, err := s.streamRepo.GetByKey(key)
+	if err != nil || stream != nil {
+		return stream, err
+	}
+
+	newKey, err := s.redisRepo.GetStreamKeyGrace(key)
+	if err != nil || newKey == "" {
+		return nil, err
+	}
+	return s.streamRepo.GetByKey(newKey)
 }
 
 // OnUnpublish 处理推流结束回调
 func (s *StreamService) OnUnpublish(req *model.OnUnpublishRequest) error {
-	stream, err := s.streamRepo.GetByKey(req.Stream)
+	// 边缘节点上的拉流代理关闭不影响直播状态
+	if s.zlmNodes.Node(req.MediaSrvID).IsEdge() {
+		return nil
+	}
+
+	stream, err := s.streamRepo.GetByPlaybackID(req.Stream)
 	if err != nil {
 		return err
 	}
 	if stream == nil {
 		return nil
 	}
+	// 推流已迁移到其他节点时，旧节点的注销回调不再处理
+	if s.nodeFor(stream).ID != s.zlmNodes.Node(req.MediaSrvID).ID {
+		return nil
+	}
 
 	// 如果开启了录制，停止录制
-	if stream.RecordEnabled {
+	if types := recordTypes(stream); len(types) > 0 {
 		go func() {
-			if _, err := s.zlmClient.StopRecord("live", req.Stream, zlm.RecordTypeMP4); err != nil {
-				fmt.Printf("failed to stop record for stream %s: %v\n", req.Stream, err)
+			client := s.zlmNodes.Client(req.MediaSrvID)
+			for _, t := range types {
+				s.stopRecord(client, stream, t)
 			}
 		}()
 	}
 
-	// 记录断流时间，状态改为 idle（等待自动结束或重新推流）
+	// 已结束的直播（手动结束、空流检测等先踢流再结束）不再回退为 idle
+	if stream.Status == model.StreamStatusEnded {
+		s.recordingSvc.CompleteHLS(stream)
+		return nil
+	}
+
+	return s.markUnpublished(stream)
+}
+
+// markUnpublished 记录断流时间，状态改为 idle（等待自动结束或重新推流）
+func (s *StreamService) markUnpublished(stream *model.Stream) error {
+	s.viewerSvc.EndStream(stream)
+	s.recordingSvc.CompleteHLS(stream)
 	now := time.Now()
 	stream.LastUnpublishAt = &now
 	stream.Status = model.StreamStatusIdle
-	stream.CurrentViewers = 0
 
 	return s.streamRepo.Update(stream)
 }
 
+// OnStreamChanged 处理流注册/注销回调
+// ZLMediaKit 为每种协议分别回调注销，流在该节点上所有协议都注销后视为推流结束（等同推流结束回调），
+// 不依赖某一种协议（如关闭了 RTMP 转协议）；查询节点媒体列表失败时只处理 RTMP 协议的注销
+func (s *StreamService) OnStreamChanged(req *model.OnStreamChangedRequest) error {
+	if req.Regist || req.App != "live" {
+		return nil
+	}
+	if s.zlmNodes.Node(req.MediaSrvID).IsEdge() {
+		return nil
+	}
+
+	resp, err := s.zlmNodes.Client(req.MediaSrvID).GetMediaList(req.App, req.Stream)
+	if err != nil || resp.Code != 0 {
+		if req.Schema != "rtmp" {
+			return nil
+		}
+	} else {
+		for _, media := range resp.Data {
+			// 还有其他协议注册时推流仍在进行
+			if media.Schema != req.Schema {
+				return nil
+			}
+		}
+	}
+
+	// 多个协议同时注销时可能都判断为最后一个，已处理过的断流不再重复处理
+	stream, err := s.streamRepo.GetByPlaybackID(req.Stream)
+	if err != nil {
+		return err
+	}
+	if stream == nil || stream.Status == model.StreamStatusIdle {
+		return nil
+	}
+	return s.OnUnpublish(&model.OnUnpublishRequest{
+		App:        req.App,
+		Stream:     req.Stream,
+		Schema:     req.Schema,
+		MediaSrvID: req.MediaSrvID,
+	})
+}
+
+// OnStreamNotFound 处理播放的流不存在回调
+// 边缘节点上播放正在推流的直播时，通过拉流代理从源站拉流，ZLMediaKit 会等待流注册后继续播放
+func (s *StreamService) OnStreamNotFound(req *model.OnStreamNotFoundRequest) error {
+	if req.App != "live" || !s.zlmNodes.Has(req.MediaSrvID) {
+		return nil
+	}
+	edge := s.zlmNodes.Node(req.MediaSrvID)
+	if !edge.IsEdge() {
+		return nil
+	}
+
+	stream, err := s.streamRepo.GetByPlaybackID(req.Stream)
+	if err != nil {
+		return err
+	}
+	if stream == nil || stream.Status != model.StreamStatusPushing {
+		return nil
+	}
+	return s.pullToEdge(edge, stream)
+}
+
 // OnPlay 处理播放开始回调
+// 观众只能通过 playback_id 播放（使用 stream_key 播放视为流不存在）；
+// 私有直播校验播放参数中的访问令牌或播放签名，返回播放鉴权错误时拒绝播放
 func (s *StreamService) OnPlay(req *model.OnPlayRequest) error {
-	// 增加观看人数
-	return s.streamRepo.IncrementViewers(req.Stream)
+	stream, err := s.streamRepo.GetByPlaybackID(req.Stream)
+	if err != nil {
+		return err
+	}
+	if stream == nil {
+		return ErrStreamNotFound
+	}
+	if err := s.authorizePlay(stream, req); err != nil {
+		fmt.Printf("play rejected for stream %s from %s (%s): %v\n", stream.StreamKey, req.IP, req.Schema, err)
+		return err
+	}
+
+	// 边缘节点回源拉流不计入观看人数（观众在边缘节点上播放时单独回调）
+	if s.isEdgePull(stream, req.Params) {
+		return s.viewerSvc.OnEdgePull(stream, req)
+	}
+
+	// 记录观看会话
+	return s.viewerSvc.OnPlay(stream, req)
 }
 
 // OnPlayerDisconnect 处理播放器断开回调
 func (s *StreamService) OnPlayerDisconnect(req *model.OnPlayerDisconnectRequest) error {
-	// 减少观看人数
-	return s.streamRepo.DecrementViewers(req.Stream)
+	stream, err := s.streamRepo.GetByPlaybackID(req.Stream)
+	if err != nil || stream == nil {
+		return err
+	}
+
+	// 结束观看会话
+	return s.viewerSvc.OnPlayerDisconnect(stream, req)
 }
 
 // OnFlowReport 处理流量统计回调
 func (s *StreamService) OnFlowReport(req *model.OnFlowReportRequest) error {
-	stream, err := s.streamRepo.GetByKey(req.Stream)
+	stream, err := s.streamRepo.GetByPlaybackID(req.Stream)
 	if err != nil || stream == nil {
 		return err
 	}
-	// 可以在这里更新码率等信息
-	return nil
+
+	// 播放器会话结束时的流量统计即播放器断开通知
+	if req.Player {
+		return s.viewerSvc.OnPlayerDisconnect(stream, &model.OnPlayerDisconnectRequest{
+			App:        req.App,
+			Stream:     req.Stream,
+			Schema:     req.Schema,
+			MediaSrvID: req.MediaSrvID,
+			IP:         req.IP,
+			Port:       req.Port,
+			ID:         req.ID,
+		})
+	}
+
+	// 推流端会话结束时的流量统计，用整场会话的平均码率覆盖最后一次采样值
+	// 边缘节点拉流代理的流量统计不代表推流端
+	if req.Duration <= 0 || s.zlmNodes.Node(req.MediaSrvID).IsEdge() {
+		return nil
+	}
+	totalBytes := req.TotalBytesIn
+	if totalBytes == 0 {
+		totalBytes = req.TotalBytes
+	}
+	bitrate := int(totalBytes * 8 / int64(req.Duration) / 1000)
+	return s.streamRepo.UpdateBitrate(stream.StreamKey, bitrate)
 }
 
 // CheckExpiredStreams 检查并处理超时的直播（定时任务）
@@ -584,16 +871,65 @@ func (s *StreamService) CheckExpiredStreams() error {
 		// 如果已超时且没有在推流，自动结束直播
 		if now.After(autoEndTime) {
 			fmt.Printf("Auto ending stream %s (past scheduled end time + %d minutes without streaming)\n", stream.StreamKey, stream.AutoKickDelay)
-			s.endStreamInternal(stream)
+			s.endStreamInternal(stream, model.StreamEndReasonAutoTimeout, 0)
 		}
 	}
 
 	return nil
 }
 
-// AddRecordFile 添加录制文件路径
-func (s *StreamService) AddRecordFile(streamKey, filePath string) error {
-	return s.streamRepo.AppendRecordFile(streamKey, filePath)
+// nodeFor 获取直播所在的 ZLMediaKit 节点，未记录节点时使用默认节点
+func (s *StreamService) nodeFor(stream *model.Stream) *zlm.Node {
+	if stream.MediaServerID == nil {
+		return s.zlmNodes.Default()
+	}
+	return s.zlmNodes.Node(*stream.MediaServerID)
+}
+
+// recordTypes 直播需要录制的 ZLMediaKit 录制类型，未开启录制时为空
+func recordTypes(stream *model.Stream) []int {
+	if !stream.RecordEnabled {
+		return nil
+	}
+	var types []int
+	if stream.RecordsMP4() {
+		types = append(types, zlm.RecordTypeMP4)
+	}
+	if stream.RecordsHLS() {
+		types = append(types, zlm.RecordTypeHLS)
+	}
+	return types
+}
+
+func containsRecordType(types []int, t int) bool {
+	for _, v := range types {
+		if v == t {
+			return true
+		}
+	}
+	return false
+}
+
+// startRecord 开始录制，失败只记录错误
+func (s *StreamService) startRecord(client *zlm.Client, stream *model.Stream, recordType int) {
+	if _, err := client.StartRecord("live", stream.PlaybackID, recordType, ""); err != nil {
+		fmt.Printf("failed to start record (type %d) for stream %s: %v\n", recordType, stream.StreamKey, err)
+	}
+}
+
+// stopRecord 停止录制，停止 HLS 录制时将录制中的 HLS 录制标记为已完成
+func (s *StreamService) stopRecord(client *zlm.Client, stream *model.Stream, recordType int) {
+	if _, err := client.StopRecord("live", stream.PlaybackID, recordType); err != nil {
+		fmt.Printf("failed to stop record (type %d) for stream %s: %v\n", recordType, stream.StreamKey, err)
+	}
+	if recordType == zlm.RecordTypeHLS {
+		s.recordingSvc.CompleteHLS(stream)
+	}
+}
+
+// zlmFor 获取直播所在 ZLMediaKit 节点的客户端，未记录节点时使用默认节点
+func (s *StreamService) zlmFor(stream *model.Stream) *zlm.Client {
+	return s.nodeFor(stream).Client
 }
 
 // generateShareCode 生成6位分享码
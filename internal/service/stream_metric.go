@@ -0,0 +1,101 @@
+package service
+
+import (
+	"time"
+
+	"easy-stream/internal/config"
+	"easy-stream/internal/model"
+	"easy-stream/internal/repository"
+)
+
+const (
+	defaultMetricsRange  = time.Hour // 未指定时间范围时默认查询最近 1 小时
+	defaultMetricsPoints = 300       // 未指定 step 时按约 300 个数据点计算时间桶长度
+	minMetricsStep       = 10        // 自动计算的最小时间桶长度（秒）
+	maxMetricsPoints     = 5000      // 单次查询最多返回的数据点数
+)
+
+// StreamMetricService 直播健康指标服务
+type StreamMetricService struct {
+	metricRepo *repository.StreamMetricRepository
+	streamRepo *repository.StreamRepository
+	cfg        config.MetricsConfig
+}
+
+func NewStreamMetricService(metricRepo *repository.StreamMetricRepository, streamRepo *repository.StreamRepository, cfg config.MetricsConfig) *StreamMetricService {
+	return &StreamMetricService{
+		metricRepo: metricRepo,
+		streamRepo: streamRepo,
+		cfg:        cfg,
+	}
+}
+
+// Query 查询直播健康指标（管理员），操作员只能查询自己创建的直播
+// from/to 为空时默认最近 1 小时，step <= 0 时根据时间范围自动计算
+func (s *StreamMetricService) Query(streamID, userID int64, role string, from, to *time.Time, step int) (*model.StreamMetricsResponse, error) {
+	stream, err := s.streamRepo.GetByID(streamID)
+	if err != nil {
+		return nil, err
+	}
+	if stream == nil {
+		return nil, ErrStreamNotFound
+	}
+	if !model.CanManageStream(role, userID, stream.CreatedBy) {
+		return nil, ErrForbidden
+	}
+
+	end := time.Now()
+	if to != nil {
+		end = *to
+	}
+	start := end.Add(-defaultMetricsRange)
+	if from != nil {
+		start = *from
+	}
+	if !start.Before(end) {
+		return nil, ErrInvalidTimeRange
+	}
+
+	seconds := int(end.Sub(start).Seconds())
+	if step <= 0 {
+		step = (seconds + defaultMetricsPoints - 1) / defaultMetricsPoints
+		if step < minMetricsStep {
+			step = minMetricsStep
+		}
+	}
+	if seconds/step > maxMetricsPoints {
+		return nil, ErrTooManyPoints
+	}
+
+	points, err := s.metricRepo.Query(streamID, start, end, step)
+	if err != nil {
+		return nil, err
+	}
+	return &model.StreamMetricsResponse{
+		StreamID: streamID,
+		From:     start,
+		To:       end,
+		Step:     step,
+		Points:   points,
+	}, nil
+}
+
+// Cleanup 降采样过期的原始采样并删除超过保留时长的数据（定时任务）
+func (s *StreamMetricService) Cleanup() error {
+	now := time.Now()
+
+	if s.cfg.RawRetention > 0 && s.cfg.DownsampleStep > 0 {
+		before := now.Add(-time.Duration(s.cfg.RawRetention) * time.Hour)
+		if _, err := s.metricRepo.Downsample(before, s.cfg.DownsampleStep); err != nil {
+			return err
+		}
+	}
+
+	if s.cfg.Retention > 0 {
+		before := now.AddDate(0, 0, -s.cfg.Retention)
+		if _, err := s.metricRepo.DeleteBefore(before); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -6,9 +6,9 @@ import (
 	"fmt"
 	"net"
 	"net/http"
+	"strings"
 	"time"
 
-	"easy-stream/internal/config"
 	"easy-stream/internal/repository"
 	"easy-stream/internal/zlm"
 )
@@ -17,18 +17,16 @@ import (
 type SystemService struct {
 	db        *sql.DB
 	redis     *repository.RedisClient
-	zlmClient *zlm.Client
-	zlmConfig config.ZLMediaKitConfig
+	zlmNodes  *zlm.Registry
 	startTime time.Time
 }
 
 // NewSystemService 创建系统服务
-func NewSystemService(db *sql.DB, redis *repository.RedisClient, zlmConfig config.ZLMediaKitConfig) *SystemService {
+func NewSystemService(db *sql.DB, redis *repository.RedisClient, zlmNodes *zlm.Registry) *SystemService {
 	return &SystemService{
 		db:        db,
 		redis:     redis,
-		zlmClient: zlm.NewClient(zlmConfig.Host, zlmConfig.Port, zlmConfig.Secret),
-		zlmConfig: zlmConfig,
+		zlmNodes:  zlmNodes,
 		startTime: time.Now(),
 	}
 }
@@ -40,14 +38,16 @@ type HealthStatus struct {
 	Uptime    string                    `json:"uptime"`
 	Version   string                    `json:"version"`
 	Services  map[string]*ServiceHealth `json:"services"`
+	ZLMNodes  map[string]*ServiceHealth `json:"zlm_nodes"` // 各 ZLMediaKit 节点的健康状态，key 为 mediaServerId
 	Network   *NetworkHealth            `json:"network"`
 }
 
 // ServiceHealth 服务健康状态
 type ServiceHealth struct {
-	Status  string `json:"status"`            // up / down / auth_failed
-	Latency string `json:"latency"`           // 响应延迟
-	Message string `json:"message,omitempty"` // 错误信息
+	Status        string     `json:"status"`                   // up / down / auth_failed
+	Latency       string     `json:"latency"`                  // 响应延迟
+	Message       string     `json:"message,omitempty"`        // 错误信息
+	LastKeepalive *time.Time `json:"last_keepalive,omitempty"` // 最近一次心跳时间（仅 ZLMediaKit 节点）
 }
 
 // NetworkHealth 网络健康状态
@@ -64,6 +64,7 @@ func (s *SystemService) CheckHealth() *HealthStatus {
 		Uptime:    s.formatUptime(),
 		Version:   "2.0",
 		Services:  make(map[string]*ServiceHealth),
+		ZLMNodes:  make(map[string]*ServiceHealth),
 		Network:   &NetworkHealth{},
 	}
 
@@ -73,8 +74,15 @@ func (s *SystemService) CheckHealth() *HealthStatus {
 	// 检查 Redis
 	health.Services["redis"] = s.checkRedis()
 
-	// 检查 ZLMediaKit
-	health.Services["zlmediakit"] = s.checkZLMediaKit()
+	// 逐个检查 ZLMediaKit 节点，services.zlmediakit 为所有节点的汇总状态
+	for _, node := range s.zlmNodes.Nodes() {
+		nodeHealth := s.checkZLMediaKit(node.Client)
+		if t, ok := s.zlmNodes.LastKeepalive(node.ID); ok {
+			nodeHealth.LastKeepalive = &t
+		}
+		health.ZLMNodes[node.ID] = nodeHealth
+	}
+	health.Services["zlmediakit"] = s.summarizeZLMNodes(health.ZLMNodes)
 
 	// 检查网络
 	health.Network.DNS = s.checkDNS()
@@ -188,12 +196,12 @@ func (s *SystemService) checkRedis() *ServiceHealth {
 	}
 }
 
-// checkZLMediaKit 检查 ZLMediaKit 连接和 Secret 验证
-func (s *SystemService) checkZLMediaKit() *ServiceHealth {
+// checkZLMediaKit 检查单个 ZLMediaKit 节点的连接和 Secret 验证
+func (s *SystemService) checkZLMediaKit(client *zlm.Client) *ServiceHealth {
 	start := time.Now()
 
 	// 尝试获取服务器配置来验证连接和 Secret
-	resp, err := s.zlmClient.GetServerConfig()
+	resp, err := client.GetServerConfig()
 	latency := time.Since(start)
 
 	if err != nil {
@@ -230,6 +238,34 @@ func (s *SystemService) checkZLMediaKit() *ServiceHealth {
 	}
 }
 
+// summarizeZLMNodes 汇总各 ZLMediaKit 节点的健康状态
+// 所有节点正常时为 up，否则取第一个异常节点的状态，message 中列出所有异常节点
+func (s *SystemService) summarizeZLMNodes(nodes map[string]*ServiceHealth) *ServiceHealth {
+	summary := &ServiceHealth{Status: "up"}
+
+	var maxLatency time.Duration
+	var failures []string
+	for _, node := range s.zlmNodes.Nodes() {
+		h := nodes[node.ID]
+		if latency, err := time.ParseDuration(h.Latency); err == nil && latency > maxLatency {
+			maxLatency = latency
+		}
+		if h.Status == "up" {
+			continue
+		}
+		if summary.Status == "up" {
+			summary.Status = h.Status
+		}
+		failures = append(failures, fmt.Sprintf("%s: %s", node.ID, h.Message))
+	}
+
+	summary.Latency = maxLatency.String()
+	if len(failures) > 0 {
+		summary.Message = fmt.Sprintf("%d/%d nodes unavailable (%s)", len(failures), len(nodes), strings.Join(failures, "; "))
+	}
+	return summary
+}
+
 // checkDNS 检查 DNS 解析
 func (s *SystemService) checkDNS() *ServiceHealth {
 	start := time.Now()
@@ -0,0 +1,289 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path"
+	"strconv"
+	"strings"
+	"time"
+
+	"easy-stream/internal/config"
+	"easy-stream/internal/model"
+	"easy-stream/internal/repository"
+	"easy-stream/internal/storage"
+)
+
+// UploadService 录制文件上传队列
+// 上传任务保存在 recording_uploads 表中，由多个 worker 从数据库领取执行，失败后按指数退避重试；
+// 服务重启后未完成的任务（待上传、等待重试、租约过期的上传中）继续处理
+type UploadService struct {
+	recordingRepo  *repository.RecordingRepository
+	storageManager *storage.Manager
+	auditSvc       *AuditService
+	cfg            config.UploadConfig
+	wake           chan struct{}
+}
+
+// NewUploadService 创建上传队列服务，storageManager 为 nil 时不添加上传任务
+func NewUploadService(recordingRepo *repository.RecordingRepository, storageManager *storage.Manager, auditSvc *AuditService, cfg config.UploadConfig) *UploadService {
+	if cfg.Workers < 1 {
+		cfg.Workers = 1
+	}
+	if cfg.MaxAttempts < 1 {
+		cfg.MaxAttempts = 1
+	}
+	if cfg.PollInterval < 1 {
+		cfg.PollInterval = 5
+	}
+	return &UploadService{
+		recordingRepo:  recordingRepo,
+		storageManager: storageManager,
+		auditSvc:       auditSvc,
+		cfg:            cfg,
+		wake:           make(chan struct{}, cfg.Workers),
+	}
+}
+
+// Start 启动上传 worker
+func (s *UploadService) Start() {
+	for i := 0; i < s.cfg.Workers; i++ {
+		go s.work()
+	}
+}
+
+// Enqueue 为录制文件添加到所有启用存储的上传任务，远程路径按各存储目标的路径模板生成
+// HLS 录制在录制完成后添加，每条录制上传到单独的目录（模板中的 {file_name} 为 hls-{录制ID}），目录下为各切片文件
+func (s *UploadService) Enqueue(stream *model.Stream, rec *model.Recording) error {
+	if s.storageManager == nil || !s.storageManager.HasStorages() {
+		return nil
+	}
+	fileName := rec.FileName
+	if rec.Format == model.RecordingFormatHLS {
+		fileName = fmt.Sprintf("hls-%d", rec.ID)
+	}
+	for _, target := range s.storageManager.Storages() {
+		upload := &model.RecordingUpload{
+			RecordingID: rec.ID,
+			Storage:     target.Name(),
+			RemotePath:  s.storageManager.RemotePath(target, recordingPathVars(stream, fileName, rec.StartTime)),
+		}
+		if err := s.recordingRepo.EnqueueUpload(upload); err != nil {
+			return err
+		}
+	}
+	s.notify()
+	return nil
+}
+
+// recordingPathVars 录制文件的远程路径模板变量
+func recordingPathVars(stream *model.Stream, fileName string, startTime time.Time) *storage.PathVars {
+	return &storage.PathVars{
+		StreamKey:  stream.StreamKey,
+		StreamID:   stream.ID,
+		StreamName: stream.Name,
+		StartTime:  startTime,
+		FileName:   fileName,
+	}
+}
+
+// List 分页查询上传队列（管理员）
+func (s *UploadService) List(req *model.UploadListRequest) (*model.UploadListResponse, error) {
+	if req.Page < 1 {
+		req.Page = 1
+	}
+	if req.PageSize < 1 {
+		req.PageSize = 20
+	}
+	if req.PageSize > 100 {
+		req.PageSize = 100
+	}
+	offset := (req.Page - 1) * req.PageSize
+
+	uploads, total, err := s.recordingRepo.ListUploads(req, offset, req.PageSize)
+	if err != nil {
+		return nil, err
+	}
+	return &model.UploadListResponse{Total: total, Uploads: uploads}, nil
+}
+
+// Retry 重试失败的上传任务（管理员）
+func (s *UploadService) Retry(id int64, userID int64) (*model.RecordingUpload, error) {
+	upload, err := s.recordingRepo.GetUpload(id)
+	if err != nil {
+		return nil, err
+	}
+	if upload == nil {
+		return nil, ErrUploadNotFound
+	}
+	if upload.Status != model.UploadStatusFailed {
+		return nil, ErrUploadNotFailed
+	}
+
+	if err := s.recordingRepo.RetryUpload(id); err != nil {
+		return nil, err
+	}
+	s.notify()
+
+	s.auditSvc.Record(userID, model.ActionUploadRetry, model.TargetTypeUpload, strconv.FormatInt(id, 10), map[string]interface{}{
+		"recording_id": upload.RecordingID,
+		"storage":      upload.Storage,
+		"attempts":     upload.Attempts,
+		"error":        upload.Error,
+	})
+	return s.recordingRepo.GetUpload(id)
+}
+
+// notify 唤醒空闲的 worker
+func (s *UploadService) notify() {
+	select {
+	case s.wake <- struct{}{}:
+	default:
+	}
+}
+
+// work 持续领取并执行上传任务，没有任务时等待唤醒或轮询间隔
+func (s *UploadService) work() {
+	for {
+		for {
+			job, err := s.recordingRepo.ClaimUpload(s.lease())
+			if err != nil {
+				fmt.Printf("Failed to claim upload job: %v\n", err)
+				break
+			}
+			if job == nil {
+				break
+			}
+			s.upload(job)
+		}
+
+		select {
+		case <-s.wake:
+		case <-time.After(time.Duration(s.cfg.PollInterval) * time.Second):
+		}
+	}
+}
+
+// upload 执行一次上传并记录结果
+func (s *UploadService) upload(job *model.RecordingUpload) {
+	var target storage.Storage
+	if s.storageManager != nil {
+		target = s.storageManager.Get(job.Storage)
+	}
+	if target == nil {
+		s.fail(job, fmt.Errorf("storage %s is not enabled", job.Storage))
+		return
+	}
+
+	var url string
+	var err error
+	if job.Format == model.RecordingFormatHLS {
+		url, err = s.uploadHLS(target, job)
+	} else {
+		url, err = s.uploadFile(target, job.LocalPath, job.RemotePath)
+	}
+	if err != nil {
+		s.fail(job, err)
+		return
+	}
+	if err := s.recordingRepo.CompleteUpload(job.ID, url); err != nil {
+		fmt.Printf("Failed to save upload result %d: %v\n", job.ID, err)
+	}
+}
+
+// uploadHLS 逐个上传 HLS 录制的切片到任务目录，返回目录的访问地址
+func (s *UploadService) uploadHLS(target storage.Storage, job *model.RecordingUpload) (string, error) {
+	segments, err := s.recordingRepo.ListSegments(job.RecordingID)
+	if err != nil {
+		return "", err
+	}
+	if len(segments) == 0 {
+		return "", fmt.Errorf("hls recording has no segments")
+	}
+
+	var dirURL string
+	for _, seg := range segments {
+		name := path.Base(seg.FileName)
+		url, err := s.uploadFile(target, seg.LocalPath, path.Join(job.RemotePath, name))
+		if err != nil {
+			return "", fmt.Errorf("upload segment %s failed: %w", name, err)
+		}
+		dirURL = strings.TrimSuffix(url, "/"+name)
+
+		if err := s.recordingRepo.ExtendUploadLease(job.ID, s.lease()); err != nil {
+			fmt.Printf("Failed to extend upload lease %d: %v\n", job.ID, err)
+		}
+	}
+	return dirURL, nil
+}
+
+// uploadFile 上传单个文件并校验，单个文件超过 Timeout 秒视为失败
+func (s *UploadService) uploadFile(target storage.Storage, localPath, remotePath string) (string, error) {
+	ctx := context.Background()
+	if s.cfg.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, time.Duration(s.cfg.Timeout)*time.Second)
+		defer cancel()
+	}
+
+	url, err := s.storageManager.UploadTo(ctx, target, localPath, remotePath)
+	if err != nil {
+		return "", err
+	}
+	if err := s.verify(ctx, target, localPath, remotePath); err != nil {
+		return "", err
+	}
+	return url, nil
+}
+
+// lease 上传任务的租约时长，超过后视为 worker 已退出，任务可被重新领取
+func (s *UploadService) lease() time.Duration {
+	return time.Duration(s.cfg.Timeout)*time.Second + time.Minute
+}
+
+// verify 校验存储中的文件大小与本地文件一致
+func (s *UploadService) verify(ctx context.Context, target storage.Storage, localPath, remotePath string) error {
+	local, err := os.Stat(localPath)
+	if err != nil {
+		return fmt.Errorf("stat local file failed: %w", err)
+	}
+	remote, err := target.Stat(ctx, remotePath)
+	if err != nil {
+		return fmt.Errorf("verify upload failed: %w", err)
+	}
+	if remote.Size != local.Size() {
+		return fmt.Errorf("verify upload failed: size mismatch, local %d, remote %d", local.Size(), remote.Size)
+	}
+	return nil
+}
+
+// fail 记录上传失败，未达到最大尝试次数时按指数退避安排重试
+func (s *UploadService) fail(job *model.RecordingUpload, uploadErr error) {
+	var next *time.Time
+	if job.Attempts < s.cfg.MaxAttempts {
+		t := time.Now().Add(s.backoff(job.Attempts))
+		next = &t
+		fmt.Printf("Upload %d (%s -> %s) failed, attempt %d/%d, retry at %s: %v\n",
+			job.ID, job.LocalPath, job.Storage, job.Attempts, s.cfg.MaxAttempts, t.Format(time.RFC3339), uploadErr)
+	} else {
+		fmt.Printf("Upload %d (%s -> %s) failed after %d attempts: %v\n",
+			job.ID, job.LocalPath, job.Storage, job.Attempts, uploadErr)
+	}
+	if err := s.recordingRepo.FailUpload(job.ID, uploadErr.Error(), next); err != nil {
+		fmt.Printf("Failed to save upload result %d: %v\n", job.ID, err)
+	}
+}
+
+// backoff 第 attempts 次失败后的重试等待时间：RetryDelay * 2^(attempts-1)，不超过 MaxRetryDelay
+func (s *UploadService) backoff(attempts int) time.Duration {
+	delay := time.Duration(s.cfg.RetryDelay) * time.Second
+	maxDelay := time.Duration(s.cfg.MaxRetryDelay) * time.Second
+	for i := 1; i < attempts && (maxDelay <= 0 || delay < maxDelay); i++ {
+		delay *= 2
+	}
+	if maxDelay > 0 && delay > maxDelay {
+		delay = maxDelay
+	}
+	return delay
+}
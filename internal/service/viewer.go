@@ -0,0 +1,272 @@
+package service
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"easy-stream/internal/config"
+	"easy-stream/internal/model"
+	"easy-stream/internal/repository"
+	"easy-stream/internal/zlm"
+)
+
+// playerSchemas 查询播放器列表的媒体源协议（HTTP-FLV 读取 rtmp 源，WebRTC 读取 rtsp 源）
+var playerSchemas = []string{"rtsp", "rtmp", "ts", "fmp4"}
+
+// ViewerService 观看会话统计服务
+// 播放会话以 mediaServerId|播放器唯一标识 为键记录在 Redis 中：
+//   - 当前观看人数为会话数，峰值随之刷新
+//   - 累计观看人次按观众 IP 在去重窗口内只计一次
+//   - 定时从 ZLMediaKit 播放器列表刷新心跳，补记错过播放回调的会话，超时未刷新的会话视为已断开
+//   - 会话开始和结束写入 viewer_sessions 历史表
+type ViewerService struct {
+	streamRepo  *repository.StreamRepository
+	sessionRepo *repository.ViewerSessionRepository
+	redis       *repository.RedisClient
+	zlmNodes    *zlm.Registry
+	statsSvc    *StatsService
+	cfg         config.ViewerConfig
+}
+
+func NewViewerService(streamRepo *repository.StreamRepository, sessionRepo *repository.ViewerSessionRepository, redis *repository.RedisClient, zlmNodes *zlm.Registry, statsSvc *StatsService, cfg config.ViewerConfig) *ViewerService {
+	return &ViewerService{
+		streamRepo:  streamRepo,
+		sessionRepo: sessionRepo,
+		redis:       redis,
+		zlmNodes:    zlmNodes,
+		statsSvc:    statsSvc,
+		cfg:         cfg,
+	}
+}
+
+// OnPlay 记录播放会话开始，重复的回调不重复计数
+func (s *ViewerService) OnPlay(stream *model.Stream, req *model.OnPlayRequest) error {
+	if _, err := s.startSession(stream, req.MediaSrvID, req.ID, req.IP, req.Schema, time.Now()); err != nil {
+		return err
+	}
+	return s.syncCount(stream.StreamKey)
+}
+
+// OnEdgePull 记录边缘节点回源拉流的会话，不计入观看人数，刷新心跳时跳过该播放器
+func (s *ViewerService) OnEdgePull(stream *model.Stream, req *model.OnPlayRequest) error {
+	return s.redis.AddEdgePullSession(stream.StreamKey, viewerSessionID(req.MediaSrvID, req.ID))
+}
+
+// OnPlayerDisconnect 记录播放会话结束
+func (s *ViewerService) OnPlayerDisconnect(stream *model.Stream, req *model.OnPlayerDisconnectRequest) error {
+	sessionID := viewerSessionID(req.MediaSrvID, req.ID)
+	if err := s.redis.RemoveEdgePullSession(stream.StreamKey, sessionID); err != nil {
+		fmt.Printf("failed to remove edge pull session %s: %v\n", sessionID, err)
+	}
+
+	removed, err := s.redis.RemoveViewerSession(stream.StreamKey, sessionID)
+	if err != nil || !removed {
+		return err
+	}
+	if err := s.sessionRepo.End(sessionID, time.Now(), model.ViewerEndDisconnect); err != nil {
+		fmt.Printf("failed to end viewer session %s: %v\n", sessionID, err)
+	}
+	return s.syncCount(stream.StreamKey)
+}
+
+// EndStream 结束直播的所有观看会话（断流或直播结束时调用）
+func (s *ViewerService) EndStream(stream *model.Stream) {
+	if _, err := s.redis.ClearViewerSessions(stream.StreamKey); err != nil {
+		fmt.Printf("failed to clear viewer sessions for stream %s: %v\n", stream.StreamKey, err)
+	}
+	if err := s.redis.ClearEdgePullSessions(stream.StreamKey); err != nil {
+		fmt.Printf("failed to clear edge pull sessions for stream %s: %v\n", stream.StreamKey, err)
+	}
+	if err := s.streamRepo.ResetCurrentViewers(stream.StreamKey); err != nil {
+		fmt.Printf("failed to reset current viewers for stream %s: %v\n", stream.StreamKey, err)
+	}
+	stream.CurrentViewers = 0
+	if err := s.sessionRepo.EndByStream(stream.ID, time.Now(), model.ViewerEndStreamEnd); err != nil {
+		fmt.Printf("failed to end viewer sessions for stream %s: %v\n", stream.StreamKey, err)
+	}
+}
+
+// Flush 刷新会话心跳、清理超时会话并将观看人数写回数据库（定时任务）
+func (s *ViewerService) Flush() error {
+	streams, err := s.streamRepo.GetPushingStreams()
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	expireBefore := now.Add(-time.Duration(s.cfg.SessionTTL) * time.Second)
+	pushing := make(map[string]bool, len(streams))
+	for _, stream := range streams {
+		pushing[stream.StreamKey] = true
+		s.heartbeat(stream, now)
+
+		expired, err := s.redis.ExpireViewerSessions(stream.StreamKey, expireBefore)
+		if err != nil {
+			fmt.Printf("failed to expire viewer sessions for stream %s: %v\n", stream.StreamKey, err)
+		}
+		for _, sessionID := range expired {
+			if err := s.sessionRepo.End(sessionID, now, model.ViewerEndTimeout); err != nil {
+				fmt.Printf("failed to end viewer session %s: %v\n", sessionID, err)
+			}
+		}
+
+		if err := s.syncCount(stream.StreamKey); err != nil {
+			fmt.Printf("failed to flush viewers for stream %s: %v\n", stream.StreamKey, err)
+		}
+	}
+
+	// 不再推流的直播遗留的会话
+	keys, err := s.redis.ViewerSessionStreams()
+	if err != nil {
+		return err
+	}
+	for _, key := range keys {
+		if pushing[key] {
+			continue
+		}
+		stream, err := s.streamRepo.GetByKey(key)
+		if err != nil {
+			fmt.Printf("failed to get stream %s: %v\n", key, err)
+			continue
+		}
+		if stream == nil {
+			s.redis.ClearViewerSessions(key)
+			continue
+		}
+		if stream.Status != model.StreamStatusPushing {
+			s.EndStream(stream)
+		}
+	}
+	return nil
+}
+
+// heartbeat 按直播所在源站和各边缘节点的播放器列表刷新会话心跳
+// 节点不支持播放器列表接口或查询失败时，刷新该节点上的全部会话（只依赖断开回调和直播结束清理）
+func (s *ViewerService) heartbeat(stream *model.Stream, now time.Time) {
+	origin := s.zlmNodes.Default()
+	if stream.MediaServerID != nil {
+		origin = s.zlmNodes.Node(*stream.MediaServerID)
+	}
+	nodes := append([]*zlm.Node{origin}, s.zlmNodes.Edges()...)
+
+	// 边缘节点回源拉流的播放器不是观众
+	edgePulls := make(map[string]bool)
+	if ids, err := s.redis.ListEdgePullSessions(stream.StreamKey); err != nil {
+		fmt.Printf("failed to list edge pull sessions for stream %s: %v\n", stream.StreamKey, err)
+	} else {
+		for _, id := range ids {
+			edgePulls[id] = true
+		}
+	}
+
+	var sessions []string
+	for _, node := range nodes {
+		players, ok := s.players(node, stream.PlaybackID)
+		if !ok {
+			if sessions == nil {
+				sessions, _ = s.redis.ListViewerSessions(stream.StreamKey)
+			}
+			var touch []string
+			for _, sessionID := range sessions {
+				if strings.HasPrefix(sessionID, node.ID+"|") {
+					touch = append(touch, sessionID)
+				}
+			}
+			if err := s.redis.TouchViewerSessions(stream.StreamKey, touch, now); err != nil {
+				fmt.Printf("failed to touch viewer sessions for stream %s: %v\n", stream.StreamKey, err)
+			}
+			continue
+		}
+
+		var touch []string
+		for _, p := range players {
+			if edgePulls[viewerSessionID(node.ID, p.Identifier)] {
+				continue
+			}
+			// 错过播放回调的会话在此补记
+			added, err := s.startSession(stream, node.ID, p.Identifier, p.PeerIP, "", now)
+			if err != nil {
+				fmt.Printf("failed to add viewer session for stream %s: %v\n", stream.StreamKey, err)
+				continue
+			}
+			if !added {
+				touch = append(touch, viewerSessionID(node.ID, p.Identifier))
+			}
+		}
+		if err := s.redis.TouchViewerSessions(stream.StreamKey, touch, now); err != nil {
+			fmt.Printf("failed to touch viewer sessions for stream %s: %v\n", stream.StreamKey, err)
+		}
+	}
+}
+
+// players 获取节点上指定流（playback_id）各协议的播放器，节点查询失败或不支持该接口时 ok 为 false
+func (s *ViewerService) players(node *zlm.Node, playbackID string) ([]zlm.MediaPlayer, bool) {
+	var players []zlm.MediaPlayer
+	for _, schema := range playerSchemas {
+		resp, err := node.Client.GetMediaPlayerList(schema, "live", playbackID)
+		if err != nil {
+			return nil, false
+		}
+		if resp.Code == zlm.CodeNotFound {
+			continue
+		}
+		if resp.Code != 0 {
+			return nil, false
+		}
+		players = append(players, resp.Data...)
+	}
+	return players, true
+}
+
+// startSession 添加观看会话，会话已存在时返回 false
+// 新会话写入历史表，观众在去重窗口内首次出现时累加观看人次
+func (s *ViewerService) startSession(stream *model.Stream, mediaServerID, playerID, ip, protocol string, now time.Time) (bool, error) {
+	sessionID := viewerSessionID(mediaServerID, playerID)
+	added, err := s.redis.AddViewerSession(stream.StreamKey, sessionID, now)
+	if err != nil || !added {
+		return false, err
+	}
+
+	if err := s.sessionRepo.Start(&model.ViewerSession{
+		StreamID:      stream.ID,
+		SessionID:     sessionID,
+		MediaServerID: mediaServerID,
+		IP:            ip,
+		Protocol:      protocol,
+		StartedAt:     now,
+	}); err != nil {
+		fmt.Printf("failed to record viewer session %s: %v\n", sessionID, err)
+	}
+
+	identity := ip
+	if identity == "" {
+		identity = sessionID
+	}
+	first, err := s.redis.MarkViewerIdentity(stream.StreamKey, identity, time.Duration(s.cfg.DedupeWindow)*time.Second)
+	if err != nil {
+		fmt.Printf("failed to dedupe viewer %s for stream %s: %v\n", identity, stream.StreamKey, err)
+		return true, nil
+	}
+	if first {
+		if err := s.streamRepo.IncrementTotalViewers(stream.StreamKey); err != nil {
+			fmt.Printf("failed to increment total viewers for stream %s: %v\n", stream.StreamKey, err)
+		}
+		s.statsSvc.Incr(model.StatPlays, 1)
+	}
+	return true, nil
+}
+
+// syncCount 将会话数写回数据库的当前观看人数（同时刷新峰值）
+func (s *ViewerService) syncCount(streamKey string) error {
+	count, err := s.redis.CountViewerSessions(streamKey)
+	if err != nil {
+		return err
+	}
+	return s.streamRepo.SetCurrentViewers(streamKey, int(count))
+}
+
+// viewerSessionID 生成会话标识，播放器唯一标识只在单个节点内唯一
+func viewerSessionID(mediaServerID, playerID string) string {
+	return mediaServerID + "|" + playerID
+}
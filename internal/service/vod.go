@@ -0,0 +1,86 @@
+package service
+
+import (
+	"fmt"
+	"math"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"easy-stream/internal/model"
+)
+
+// VODRecordings 获取直播可以点播的录制（已完成的 HLS 录制）
+// 调用方需先确认请求方有权观看该直播
+func (s *StreamService) VODRecordings(stream *model.Stream) ([]*model.Recording, error) {
+	return s.recordingSvc.CompletedHLS(stream)
+}
+
+// VODPlaylist 生成已完成 HLS 录制的点播 m3u8
+// 切片地址指向录制所在节点的 HTTP 文件服务器，并携带切片路径中播放ID的播放签名，
+// 由 on_http_access 回调校验；调用方需先确认请求方有权观看该直播
+func (s *StreamService) VODPlaylist(stream *model.Stream, recordingID int64) (string, error) {
+	rec, segments, err := s.recordingSvc.HLSSegments(stream, recordingID)
+	if err != nil {
+		return "", err
+	}
+	baseURL := s.vodBaseURL(rec.MediaServerID)
+
+	targetDuration := 1.0
+	for _, seg := range segments {
+		targetDuration = math.Max(targetDuration, seg.Duration)
+	}
+
+	expire := strconv.FormatInt(time.Now().Add(time.Duration(s.playCfg.SignExpire)*time.Second).Unix(), 10)
+
+	var b strings.Builder
+	b.WriteString("#EXTM3U\n")
+	b.WriteString("#EXT-X-VERSION:3\n")
+	b.WriteString("#EXT-X-PLAYLIST-TYPE:VOD\n")
+	fmt.Fprintf(&b, "#EXT-X-TARGETDURATION:%d\n", int(math.Ceil(targetDuration)))
+	b.WriteString("#EXT-X-MEDIA-SEQUENCE:0\n")
+
+	var prevEnd time.Time
+	for i, seg := range segments {
+		// 断流重推后切片时间不连续，插入不连续标记
+		if i > 0 && seg.StartTime.Sub(prevEnd) > time.Second {
+			b.WriteString("#EXT-X-DISCONTINUITY\n")
+		}
+		prevEnd = seg.StartTime.Add(time.Duration(seg.Duration * float64(time.Second)))
+
+		fmt.Fprintf(&b, "#EXTINF:%.3f,\n", seg.Duration)
+		b.WriteString(s.segmentURL(baseURL, seg, expire) + "\n")
+	}
+	b.WriteString("#EXT-X-ENDLIST\n")
+	return b.String(), nil
+}
+
+// vodBaseURL 录制所在节点 HTTP 文件服务器的访问地址
+// 节点配置了 httpUrl 时使用该地址，默认源站（含未注册的节点）使用 play.host/httpPort，其他节点使用节点的 API 地址
+func (s *StreamService) vodBaseURL(mediaServerID string) string {
+	node := s.zlmNodes.Node(mediaServerID)
+	if node.HTTPURL != "" {
+		return node.HTTPURL
+	}
+	if node == s.zlmNodes.Default() {
+		return fmt.Sprintf("http://%s:%d", s.playCfg.Host, s.playCfg.HTTPPort)
+	}
+	return fmt.Sprintf("http://%s:%s", node.Host, node.Port)
+}
+
+// segmentURL 生成 HLS 切片的访问地址
+func (s *StreamService) segmentURL(baseURL string, seg *model.RecordingSegment, expire string) string {
+	segPath := strings.TrimPrefix(seg.URL, "/")
+
+	// 签名使用切片路径中的播放ID，播放ID更换后之前的录制仍可访问
+	name := ""
+	if parts := strings.Split(segPath, "/"); len(parts) >= 3 {
+		name = parts[2]
+	}
+	params := url.Values{
+		"expire": {expire},
+		"sign":   {signPlay(s.playCfg.SignSecret, name, expire)},
+	}
+	return fmt.Sprintf("%s/%s?%s", baseURL, segPath, params.Encode())
+}
@@ -1,5 +1,12 @@
 package service
 
+import (
+	"fmt"
+
+	"easy-stream/internal/model"
+	"easy-stream/internal/zlm"
+)
+
 // WebRTCPlayResponse WebRTC 播放响应
 type WebRTCPlayResponse struct {
 	Code int    `json:"code"`
@@ -7,11 +14,13 @@ type WebRTCPlayResponse struct {
 }
 
 // WebRTCPlay 发送 WebRTC 播放请求到 ZLMediaKit
-// streamKey: 流的 stream_key（推流码）
+// 配置了边缘节点时在负载最低的边缘节点上协商，否则在直播所在节点上协商
+// stream: 要播放的直播
 // offerSDP: 客户端的 SDP offer
 // 返回 ZLMediaKit 的 SDP answer
-func (s *StreamService) WebRTCPlay(streamKey, offerSDP string) (*WebRTCPlayResponse, error) {
-	resp, err := s.zlmClient.WebRTCPlay("live", streamKey, offerSDP)
+func (s *StreamService) WebRTCPlay(stream *model.Stream, offerSDP string) (*WebRTCPlayResponse, error) {
+	node := s.selectPlayNode(stream)
+	resp, err := node.Client.WebRTCPlay("live", stream.PlaybackID, offerSDP, s.playParams(stream))
 	if err != nil {
 		return nil, err
 	}
@@ -21,3 +30,65 @@ func (s *StreamService) WebRTCPlay(streamKey, offerSDP string) (*WebRTCPlayRespo
 		SDP:  resp.SDP,
 	}, nil
 }
+
+// selectPlayNode 选择播放节点
+// 按各边缘节点当前观看人数选择负载最低的可用节点；该节点上还没有这路流时通过拉流代理从源站拉流
+// 没有边缘节点、边缘节点全部不可用或拉流代理失败时回退到直播所在的源站
+func (s *StreamService) selectPlayNode(stream *model.Stream) *zlm.Node {
+	origin := s.nodeFor(stream)
+	edges := s.zlmNodes.Edges()
+	if len(edges) == 0 {
+		return origin
+	}
+
+	var best *zlm.Node
+	bestReaders := 0
+	bestHasStream := false
+	for _, edge := range edges {
+		resp, err := edge.Client.GetMediaList("", "")
+		if err == nil && resp.Code != 0 {
+			err = fmt.Errorf("getMediaList returned code %d", resp.Code)
+		}
+		if err != nil {
+			fmt.Printf("failed to get media list from zlm edge %s: %v\n", edge.ID, err)
+			continue
+		}
+
+		// 按 app/stream 分组统计观看人数，避免同一路流的多个协议重复计数
+		groups := make(map[string][]zlm.MediaInfo)
+		for _, m := range resp.Data {
+			groups[m.App+"/"+m.Stream] = append(groups[m.App+"/"+m.Stream], m)
+		}
+		readers := 0
+		for _, medias := range groups {
+			readers += readerCount(medias)
+		}
+		_, hasStream := groups["live/"+stream.PlaybackID]
+
+		if best == nil || readers < bestReaders {
+			best, bestReaders, bestHasStream = edge, readers, hasStream
+		}
+	}
+
+	if best == nil {
+		return origin
+	}
+	if bestHasStream {
+		return best
+	}
+
+	if err := s.pullToEdge(best, stream); err != nil {
+		fmt.Printf("failed to add stream proxy on zlm edge %s for stream %s: %v\n", best.ID, stream.StreamKey, err)
+		return origin
+	}
+	return best
+}
+
+// pullToEdge 在边缘节点上添加拉流代理，从直播所在源站拉流
+// 拉流地址携带回源拉流标识（私有直播同时携带播放签名），源站按播放请求校验，不计入观看人数
+func (s *StreamService) pullToEdge(edge *zlm.Node, stream *model.Stream) error {
+	origin := s.nodeFor(stream)
+	pullURL := fmt.Sprintf("rtmp://%s:%d/live/%s?%s", origin.Host, origin.RTMPPort, stream.PlaybackID, s.edgePullParams(stream, edge).Encode())
+	_, err := edge.Client.AddStreamProxy("live", stream.PlaybackID, pullURL)
+	return err
+}
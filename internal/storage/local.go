@@ -4,8 +4,12 @@ import (
 	"context"
 	"fmt"
 	"io"
+	"io/fs"
 	"os"
+	"path"
 	"path/filepath"
+	"strings"
+	"time"
 
 	"easy-stream/internal/config"
 )
@@ -40,7 +44,7 @@ func (s *LocalStorage) Name() string {
 
 // Upload 上传文件到本地存储（实际是复制文件）
 func (s *LocalStorage) Upload(ctx context.Context, localPath, remotePath string) (string, error) {
-	destPath := filepath.Join(s.baseDir, remotePath)
+	destPath := s.fullPath(remotePath)
 
 	// 确保目标目录存在
 	destDir := filepath.Dir(destPath)
@@ -56,6 +60,103 @@ func (s *LocalStorage) Upload(ctx context.Context, localPath, remotePath string)
 	return destPath, nil
 }
 
+// Stat 获取本地存储中的文件信息
+func (s *LocalStorage) Stat(ctx context.Context, remotePath string) (*ObjectInfo, error) {
+	fi, err := os.Stat(s.fullPath(remotePath))
+	if os.IsNotExist(err) || (err == nil && fi.IsDir()) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("stat file failed: %w", err)
+	}
+	return &ObjectInfo{
+		Path:    s.relPath(remotePath),
+		Size:    fi.Size(),
+		ModTime: fi.ModTime(),
+	}, nil
+}
+
+// Open 打开本地存储中的文件，返回的 *os.File 支持 Seek（用于 HTTP Range 请求）
+func (s *LocalStorage) Open(ctx context.Context, remotePath string) (io.ReadCloser, error) {
+	f, err := os.Open(s.fullPath(remotePath))
+	if os.IsNotExist(err) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("open file failed: %w", err)
+	}
+	return f, nil
+}
+
+// List 列出本地存储中路径以 prefix 开头的文件
+func (s *LocalStorage) List(ctx context.Context, prefix string) ([]*ObjectInfo, error) {
+	prefix = strings.TrimPrefix(prefix, "/")
+
+	// 只遍历 prefix 中目录部分对应的子目录
+	root := s.baseDir
+	if i := strings.LastIndex(prefix, "/"); i >= 0 {
+		root = s.fullPath(prefix[:i])
+	}
+
+	objects := make([]*ObjectInfo, 0)
+	err := filepath.WalkDir(root, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			if os.IsNotExist(err) && p == root {
+				return filepath.SkipDir
+			}
+			return err
+		}
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if d.IsDir() {
+			return nil
+		}
+
+		rel, err := filepath.Rel(s.baseDir, p)
+		if err != nil {
+			return err
+		}
+		rel = filepath.ToSlash(rel)
+		if !strings.HasPrefix(rel, prefix) {
+			return nil
+		}
+		fi, err := d.Info()
+		if err != nil {
+			return err
+		}
+		objects = append(objects, &ObjectInfo{Path: rel, Size: fi.Size(), ModTime: fi.ModTime()})
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("list files failed: %w", err)
+	}
+	return objects, nil
+}
+
+// Delete 删除本地存储中的文件
+func (s *LocalStorage) Delete(ctx context.Context, remotePath string) error {
+	if err := os.Remove(s.fullPath(remotePath)); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("delete file failed: %w", err)
+	}
+	return nil
+}
+
+// PresignURL 本地存储没有对外的访问地址，需由服务端读取文件提供下载
+func (s *LocalStorage) PresignURL(ctx context.Context, remotePath string, expire time.Duration) (string, error) {
+	return "", ErrPresignNotSupported
+}
+
+// relPath 规范化相对路径，去掉开头的 / 和越出根目录的 ..
+func (s *LocalStorage) relPath(remotePath string) string {
+	return strings.TrimPrefix(path.Clean("/"+filepath.ToSlash(remotePath)), "/")
+}
+
+// fullPath 相对路径对应的本地文件路径，不会越出存储目录
+func (s *LocalStorage) fullPath(remotePath string) string {
+	return filepath.Join(s.baseDir, filepath.FromSlash(s.relPath(remotePath)))
+}
+
 // copyFile 复制文件
 func copyFile(src, dst string) error {
 	srcFile, err := os.Open(src)
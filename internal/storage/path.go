@@ -0,0 +1,103 @@
+package storage
+
+import (
+	"fmt"
+	"path"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// DefaultPathTemplate 存储目标未配置 pathTemplate 时使用的远程路径模板
+const DefaultPathTemplate = "{stream_id}/{date}/{file_name}"
+
+// PathVars 远程路径模板变量
+type PathVars struct {
+	StreamKey  string
+	StreamID   int64
+	StreamName string
+	StartTime  time.Time // 录制分段开始时间
+	FileName   string    // ZLMediaKit 生成的原始文件名
+}
+
+var placeholderRe = regexp.MustCompile(`\{[^{}]*\}`)
+
+// pathPlaceholders 支持的占位符
+var pathPlaceholders = map[string]bool{
+	"{stream_key}":  true,
+	"{stream_id}":   true,
+	"{stream_slug}": true,
+	"{yyyy}":        true,
+	"{mm}":          true,
+	"{dd}":          true,
+	"{hh}":          true,
+	"{date}":        true,
+	"{start_time}":  true,
+	"{file_name}":   true,
+}
+
+// ValidatePathTemplate 检查远程路径模板
+// 为保证不同直播、不同日期的录制不会互相覆盖，模板必须包含直播标识（{stream_id} 或 {stream_key}）、
+// {file_name}，以及日期（{date}、{start_time} 或同时包含 {yyyy}/{mm}/{dd}）
+func ValidatePathTemplate(tpl string) error {
+	for _, p := range placeholderRe.FindAllString(tpl, -1) {
+		if !pathPlaceholders[p] {
+			return fmt.Errorf("unknown placeholder %s", p)
+		}
+	}
+
+	has := func(p string) bool { return strings.Contains(tpl, p) }
+	if !has("{stream_id}") && !has("{stream_key}") {
+		return fmt.Errorf("template must contain {stream_id} or {stream_key}")
+	}
+	if !has("{file_name}") {
+		return fmt.Errorf("template must contain {file_name}")
+	}
+	if !has("{date}") && !has("{start_time}") && !(has("{yyyy}") && has("{mm}") && has("{dd}")) {
+		return fmt.Errorf("template must contain {date}, {start_time} or {yyyy}, {mm} and {dd}")
+	}
+	return nil
+}
+
+// RenderPath 按模板生成远程路径，日期使用服务器本地时区
+func RenderPath(tpl string, v *PathVars) string {
+	t := v.StartTime.Local()
+	r := strings.NewReplacer(
+		"{stream_key}", v.StreamKey,
+		"{stream_id}", strconv.FormatInt(v.StreamID, 10),
+		"{stream_slug}", slugify(v.StreamName, v.StreamID),
+		"{yyyy}", t.Format("2006"),
+		"{mm}", t.Format("01"),
+		"{dd}", t.Format("02"),
+		"{hh}", t.Format("15"),
+		"{date}", t.Format("2006-01-02"),
+		"{start_time}", t.Format("20060102-150405"),
+		"{file_name}", path.Base(v.FileName),
+	)
+	return strings.TrimPrefix(path.Clean("/"+r.Replace(tpl)), "/")
+}
+
+// slugify 将直播名称转换为小写字母、数字和 - 组成的路径片段，
+// 名称中没有可用字符（如全中文）时使用 stream-{id}
+func slugify(name string, streamID int64) string {
+	var b strings.Builder
+	dash := false
+	for _, r := range strings.ToLower(name) {
+		if (r >= 'a' && r <= 'z') || (r >= '0' && r <= '9') {
+			b.WriteRune(r)
+			dash = false
+		} else if b.Len() > 0 && !dash {
+			b.WriteByte('-')
+			dash = true
+		}
+	}
+	slug := strings.TrimSuffix(b.String(), "-")
+	if len(slug) > 64 {
+		slug = strings.TrimSuffix(slug[:64], "-")
+	}
+	if slug == "" {
+		return "stream-" + strconv.FormatInt(streamID, 10)
+	}
+	return slug
+}
@@ -2,9 +2,13 @@ package storage
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"io"
 	"os"
-	"path/filepath"
+	"path"
+	"strings"
+	"time"
 
 	"easy-stream/internal/config"
 
@@ -12,6 +16,7 @@ import (
 	awsconfig "github.com/aws/aws-sdk-go-v2/config"
 	"github.com/aws/aws-sdk-go-v2/credentials"
 	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/aws-sdk-go-v2/service/s3/types"
 )
 
 // S3Storage S3兼容存储（支持AWS S3/腾讯COS/阿里OSS）
@@ -19,7 +24,7 @@ type S3Storage struct {
 	name         string
 	client       *s3.Client
 	bucket       string
-	pathPrefix   string
+	pathPrefix   string // 对象键前缀，已去掉首尾的 /
 	customDomain string
 	endpoint     string
 }
@@ -62,7 +67,7 @@ func NewS3Storage(cfg config.StorageTarget) (*S3Storage, error) {
 		name:         cfg.Name,
 		client:       client,
 		bucket:       cfg.Bucket,
-		pathPrefix:   cfg.PathPrefix,
+		pathPrefix:   strings.Trim(cfg.PathPrefix, "/"),
 		customDomain: cfg.CustomDomain,
 		endpoint:     cfg.Endpoint,
 	}, nil
@@ -81,11 +86,7 @@ func (s *S3Storage) Upload(ctx context.Context, localPath, remotePath string) (s
 	}
 	defer file.Close()
 
-	// 构建对象键
-	key := remotePath
-	if s.pathPrefix != "" {
-		key = filepath.Join(s.pathPrefix, remotePath)
-	}
+	key := s.objectKey(remotePath)
 
 	// 上传文件
 	_, err = s.client.PutObject(ctx, &s3.PutObjectInput{
@@ -109,3 +110,108 @@ func (s *S3Storage) Upload(ctx context.Context, localPath, remotePath string) (s
 
 	return url, nil
 }
+
+// Stat 获取S3对象信息
+func (s *S3Storage) Stat(ctx context.Context, remotePath string) (*ObjectInfo, error) {
+	out, err := s.client.HeadObject(ctx, &s3.HeadObjectInput{
+		Bucket: aws.String(s.bucket),
+		Key:    aws.String(s.objectKey(remotePath)),
+	})
+	if err != nil {
+		if isNotFound(err) {
+			return nil, ErrNotFound
+		}
+		return nil, fmt.Errorf("stat s3 object failed: %w", err)
+	}
+	return &ObjectInfo{
+		Path:    remotePath,
+		Size:    aws.ToInt64(out.ContentLength),
+		ModTime: aws.ToTime(out.LastModified),
+		ETag:    strings.Trim(aws.ToString(out.ETag), `"`),
+	}, nil
+}
+
+// Open 读取S3对象内容，返回的 Body 不支持 Seek
+func (s *S3Storage) Open(ctx context.Context, remotePath string) (io.ReadCloser, error) {
+	out, err := s.client.GetObject(ctx, &s3.GetObjectInput{
+		Bucket: aws.String(s.bucket),
+		Key:    aws.String(s.objectKey(remotePath)),
+	})
+	if err != nil {
+		if isNotFound(err) {
+			return nil, ErrNotFound
+		}
+		return nil, fmt.Errorf("get s3 object failed: %w", err)
+	}
+	return out.Body, nil
+}
+
+// List 列出键以 prefix 开头的对象，返回路径已去掉 pathPrefix
+func (s *S3Storage) List(ctx context.Context, prefix string) ([]*ObjectInfo, error) {
+	root := s.keyPrefix()
+	paginator := s3.NewListObjectsV2Paginator(s.client, &s3.ListObjectsV2Input{
+		Bucket: aws.String(s.bucket),
+		Prefix: aws.String(root + strings.TrimPrefix(prefix, "/")),
+	})
+
+	objects := make([]*ObjectInfo, 0)
+	for paginator.HasMorePages() {
+		page, err := paginator.NextPage(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("list s3 objects failed: %w", err)
+		}
+		for _, obj := range page.Contents {
+			objects = append(objects, &ObjectInfo{
+				Path:    strings.TrimPrefix(aws.ToString(obj.Key), root),
+				Size:    aws.ToInt64(obj.Size),
+				ModTime: aws.ToTime(obj.LastModified),
+				ETag:    strings.Trim(aws.ToString(obj.ETag), `"`),
+			})
+		}
+	}
+	return objects, nil
+}
+
+// PresignURL 生成带签名的临时下载地址，私有 bucket 无需公开读权限即可访问
+func (s *S3Storage) PresignURL(ctx context.Context, remotePath string, expire time.Duration) (string, error) {
+	req, err := s3.NewPresignClient(s.client).PresignGetObject(ctx, &s3.GetObjectInput{
+		Bucket: aws.String(s.bucket),
+		Key:    aws.String(s.objectKey(remotePath)),
+	}, s3.WithPresignExpires(expire))
+	if err != nil {
+		return "", fmt.Errorf("presign s3 object failed: %w", err)
+	}
+	return req.URL, nil
+}
+
+// Delete 删除S3中的对象（对象不存在时 S3 同样返回成功）
+func (s *S3Storage) Delete(ctx context.Context, remotePath string) error {
+	_, err := s.client.DeleteObject(ctx, &s3.DeleteObjectInput{
+		Bucket: aws.String(s.bucket),
+		Key:    aws.String(s.objectKey(remotePath)),
+	})
+	if err != nil {
+		return fmt.Errorf("delete from s3 failed: %w", err)
+	}
+	return nil
+}
+
+// objectKey 构建对象键（以 / 分隔，不以 / 开头），与 keyPrefix 使用同一个前缀
+func (s *S3Storage) objectKey(remotePath string) string {
+	return path.Join(s.pathPrefix, strings.TrimPrefix(remotePath, "/"))
+}
+
+// keyPrefix 对象键的公共前缀（以 / 结尾），未配置 pathPrefix 时为空
+func (s *S3Storage) keyPrefix() string {
+	if s.pathPrefix == "" {
+		return ""
+	}
+	return s.pathPrefix + "/"
+}
+
+// isNotFound 判断是否为对象不存在错误（HeadObject 返回 NotFound，GetObject 返回 NoSuchKey）
+func isNotFound(err error) bool {
+	var notFound *types.NotFound
+	var noSuchKey *types.NoSuchKey
+	return errors.As(err, &notFound) || errors.As(err, &noSuchKey)
+}
@@ -2,25 +2,54 @@ package storage
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"io"
+	"time"
 
 	"easy-stream/internal/config"
+	"easy-stream/internal/metrics"
+)
+
+var (
+	ErrNotFound            = errors.New("file not found in storage")
+	ErrPresignNotSupported = errors.New("storage does not support presigned urls")
 )
 
 // Storage 存储接口
+// remotePath 均为相对于存储根目录（本地目录或 pathPrefix）的路径
 type Storage interface {
 	Upload(ctx context.Context, localPath, remotePath string) (url string, err error)
+	// Stat 获取文件信息，文件不存在时返回 ErrNotFound
+	Stat(ctx context.Context, remotePath string) (*ObjectInfo, error)
+	// Open 打开文件读取，调用方负责关闭；文件不存在时返回 ErrNotFound
+	Open(ctx context.Context, remotePath string) (io.ReadCloser, error)
+	// List 列出路径以 prefix 开头的所有文件
+	List(ctx context.Context, prefix string) ([]*ObjectInfo, error)
+	// Delete 删除存储中的文件，文件不存在时不返回错误
+	Delete(ctx context.Context, remotePath string) error
+	// PresignURL 生成有效期为 expire 的临时访问地址，不支持时返回 ErrPresignNotSupported
+	PresignURL(ctx context.Context, remotePath string, expire time.Duration) (string, error)
 	Name() string
 }
 
+// ObjectInfo 存储中的文件信息
+type ObjectInfo struct {
+	Path    string    // 相对路径（与 Upload 的 remotePath 一致）
+	Size    int64     // 文件大小（字节）
+	ModTime time.Time // 最后修改时间
+	ETag    string    // S3 ETag，本地存储为空
+}
+
 // Manager 存储管理器
 type Manager struct {
-	storages []Storage
+	storages  []Storage
+	templates map[string]string // 存储名称 -> 远程路径模板
 }
 
 // NewManager 创建存储管理器
 func NewManager(cfg config.StorageConfig) (*Manager, error) {
-	m := &Manager{storages: make([]Storage, 0)}
+	m := &Manager{storages: make([]Storage, 0), templates: make(map[string]string)}
 
 	for _, target := range cfg.Targets {
 		if !target.Enabled {
@@ -42,17 +71,31 @@ func NewManager(cfg config.StorageConfig) (*Manager, error) {
 		if err != nil {
 			return nil, fmt.Errorf("init storage %s failed: %w", target.Name, err)
 		}
+
+		tpl := target.PathTemplate
+		if tpl == "" {
+			tpl = DefaultPathTemplate
+		}
+		if err := ValidatePathTemplate(tpl); err != nil {
+			return nil, fmt.Errorf("invalid pathTemplate for storage %s: %w", target.Name, err)
+		}
 		m.storages = append(m.storages, s)
+		m.templates[s.Name()] = tpl
 	}
 
 	return m, nil
 }
 
-// UploadToAll 上传到所有启用的存储
-func (m *Manager) UploadToAll(ctx context.Context, localPath, remotePath string) map[string]string {
+// RemotePath 按存储目标的路径模板生成远程路径
+func (m *Manager) RemotePath(s Storage, vars *PathVars) string {
+	return RenderPath(m.templates[s.Name()], vars)
+}
+
+// UploadToAll 上传到所有启用的存储，远程路径按各存储目标的路径模板生成
+func (m *Manager) UploadToAll(ctx context.Context, localPath string, vars *PathVars) map[string]string {
 	results := make(map[string]string)
 	for _, s := range m.storages {
-		url, err := s.Upload(ctx, localPath, remotePath)
+		url, err := m.UploadTo(ctx, s, localPath, m.RemotePath(s, vars))
 		if err != nil {
 			results[s.Name()] = fmt.Sprintf("error: %v", err)
 		} else {
@@ -62,6 +105,42 @@ func (m *Manager) UploadToAll(ctx context.Context, localPath, remotePath string)
 	return results
 }
 
+// UploadTo 上传到指定存储，并记录上传结果监控指标
+func (m *Manager) UploadTo(ctx context.Context, s Storage, localPath, remotePath string) (string, error) {
+	url, err := s.Upload(ctx, localPath, remotePath)
+	if err != nil {
+		metrics.StorageUploads.Inc(s.Name(), metrics.ResultFailure)
+		return "", err
+	}
+	metrics.StorageUploads.Inc(s.Name(), metrics.ResultSuccess)
+	return url, nil
+}
+
+// DeleteFrom 删除指定存储中的文件，并记录删除结果监控指标
+func (m *Manager) DeleteFrom(ctx context.Context, s Storage, remotePath string) error {
+	if err := s.Delete(ctx, remotePath); err != nil {
+		metrics.StorageDeletes.Inc(s.Name(), metrics.ResultFailure)
+		return err
+	}
+	metrics.StorageDeletes.Inc(s.Name(), metrics.ResultSuccess)
+	return nil
+}
+
+// Storages 返回所有启用的存储
+func (m *Manager) Storages() []Storage {
+	return m.storages
+}
+
+// Get 根据名称获取启用的存储，不存在时返回 nil
+func (m *Manager) Get(name string) Storage {
+	for _, s := range m.storages {
+		if s.Name() == name {
+			return s
+		}
+	}
+	return nil
+}
+
 // HasStorages 是否有启用的存储
 func (m *Manager) HasStorages() bool {
 	return len(m.storages) > 0
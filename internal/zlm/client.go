@@ -6,7 +6,10 @@ import (
 	"io"
 	"net/http"
 	"net/url"
+	"strings"
 	"time"
+
+	"easy-stream/internal/metrics"
 )
 
 // Client ZLMediaKit API 客户端
@@ -168,9 +171,14 @@ func (c *Client) IsRecording(app, stream string, recordType int) (*RecordStatusR
 	return &result, nil
 }
 
-func (c *Client) get(path string, params url.Values) ([]byte, error) {
+func (c *Client) get(path string, params url.Values) (body []byte, err error) {
 	reqURL := fmt.Sprintf("%s%s?%s", c.baseURL, path, params.Encode())
 
+	start := time.Now()
+	defer func() {
+		observeRequest(strings.TrimPrefix(path, "/index/api/"), start, err)
+	}()
+
 	resp, err := c.httpClient.Get(reqURL)
 	if err != nil {
 		return nil, err
@@ -180,6 +188,15 @@ func (c *Client) get(path string, params url.Values) ([]byte, error) {
 	return io.ReadAll(resp.Body)
 }
 
+// observeRequest 记录 API 调用耗时监控指标
+func observeRequest(api string, start time.Time, err error) {
+	result := metrics.ResultOK
+	if err != nil {
+		result = metrics.ResultError
+	}
+	metrics.ZLMRequestDuration.ObserveDuration(start, api, result)
+}
+
 // MediaListResponse 流列表响应
 type MediaListResponse struct {
 	Code int         `json:"code"`
@@ -201,12 +218,31 @@ type MediaInfo struct {
 
 // Track 轨道信息
 type Track struct {
-	CodecID   int    `json:"codec_id"`
-	CodecType int    `json:"codec_type"`
-	Ready     bool   `json:"ready"`
-	FPS       int    `json:"fps"`
-	Width     int    `json:"width"`
-	Height    int    `json:"height"`
+	CodecID     int     `json:"codec_id"`
+	CodecIDName string  `json:"codec_id_name"`
+	CodecType   int     `json:"codec_type"` // 0 为视频，1 为音频
+	Ready       bool    `json:"ready"`
+	FPS         float64 `json:"fps"` // ZLM 返回浮点数，如 29.97
+	Width       int     `json:"width"`
+	Height      int     `json:"height"`
+	Frames      int64   `json:"frames"`     // 累计帧数
+	KeyFrames   int64   `json:"key_frames"` // 累计关键帧数
+}
+
+// 轨道类型常量
+const (
+	TrackTypeVideo = 0
+	TrackTypeAudio = 1
+)
+
+// VideoTrack 返回第一个视频轨道，没有视频轨道时返回 nil
+func (m *MediaInfo) VideoTrack() *Track {
+	for i := range m.Tracks {
+		if m.Tracks[i].CodecType == TrackTypeVideo {
+			return &m.Tracks[i]
+		}
+	}
+	return nil
 }
 
 // CommonResponse 通用响应
@@ -265,14 +301,28 @@ func (c *Client) SetServerConfig(configs map[string]string) (*CommonResponse, er
 
 // ConfigureHooks 配置所有 hook URL
 // hookBaseURL 应该是 Easy-Stream 的回调地址，如 "http://easy-stream:8080/api/v1/hooks"
-func (c *Client) ConfigureHooks(hookBaseURL string) error {
+// token 不为空时以 ?token= 附加到每个回调地址，用于 Hook 接口认证
+// ZLMediaKit 没有单独的推流结束和播放器断开 Hook：推流结束通过 on_stream_changed（regist=false）通知，
+// 播放器断开通过 on_flow_report（player=true）通知
+func (c *Client) ConfigureHooks(hookBaseURL, token string) error {
+	query := ""
+	if token != "" {
+		query = "?token=" + url.QueryEscape(token)
+	}
 	configs := map[string]string{
-		"hook.enable":                 "1",
-		"hook.on_publish":             hookBaseURL + "/on_publish",
-		"hook.on_play":                hookBaseURL + "/on_play",
-		"hook.on_flow_report":         hookBaseURL + "/on_flow_report",
-		"hook.on_stream_none_reader":  hookBaseURL + "/on_stream_none_reader",
-		"hook.on_record_mp4":          hookBaseURL + "/on_record_mp4",
+		"hook.enable":                "1",
+		"hook.on_publish":            hookBaseURL + "/on_publish" + query,
+		"hook.on_play":               hookBaseURL + "/on_play" + query,
+		"hook.on_flow_report":        hookBaseURL + "/on_flow_report" + query,
+		"hook.on_stream_changed":     hookBaseURL + "/on_stream_changed" + query,
+		"hook.on_stream_none_reader": hookBaseURL + "/on_stream_none_reader" + query,
+		"hook.on_stream_not_found":   hookBaseURL + "/on_stream_not_found" + query,
+		"hook.on_record_mp4":         hookBaseURL + "/on_record_mp4" + query,
+		"hook.on_record_ts":          hookBaseURL + "/on_record_ts" + query,
+		"hook.on_http_access":        hookBaseURL + "/on_http_access" + query,
+		"hook.on_server_started":     hookBaseURL + "/on_server_started" + query,
+		"hook.on_server_keepalive":   hookBaseURL + "/on_server_keepalive" + query,
+		"hook.on_rtp_server_timeout": hookBaseURL + "/on_rtp_server_timeout" + query,
 	}
 
 	resp, err := c.SetServerConfig(configs)
@@ -286,3 +336,46 @@ func (c *Client) ConfigureHooks(hookBaseURL string) error {
 
 	return nil
 }
+
+// MediaPlayer 播放器信息
+type MediaPlayer struct {
+	Identifier string `json:"identifier"` // 播放器唯一标识（与 on_play 回调中的 id 一致）
+	LocalIP    string `json:"local_ip"`
+	LocalPort  int    `json:"local_port"`
+	PeerIP     string `json:"peer_ip"`
+	PeerPort   int    `json:"peer_port"`
+	TypeID     string `json:"typeid"`
+}
+
+// MediaPlayerListResponse 播放器列表响应
+type MediaPlayerListResponse struct {
+	Code int           `json:"code"`
+	Msg  string        `json:"msg"`
+	Data []MediaPlayer `json:"data"`
+}
+
+// CodeNotFound API 返回码：流不存在
+const CodeNotFound = -500
+
+// GetMediaPlayerList 获取指定协议媒体源的播放器列表
+// 流不存在时 ZLMediaKit 返回非 0 code；较早的版本不支持该接口
+func (c *Client) GetMediaPlayerList(schema, app, stream string) (*MediaPlayerListResponse, error) {
+	params := url.Values{}
+	params.Set("secret", c.secret)
+	params.Set("schema", schema)
+	params.Set("vhost", "__defaultVhost__")
+	params.Set("app", app)
+	params.Set("stream", stream)
+
+	resp, err := c.get("/index/api/getMediaPlayerList", params)
+	if err != nil {
+		return nil, err
+	}
+
+	var result MediaPlayerListResponse
+	if err := json.Unmarshal(resp, &result); err != nil {
+		return nil, err
+	}
+
+	return &result, nil
+}
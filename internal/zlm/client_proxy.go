@@ -0,0 +1,68 @@
+package zlm
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+// StreamProxyResponse 拉流代理响应
+type StreamProxyResponse struct {
+	Code int    `json:"code"`
+	Msg  string `json:"msg"`
+	Data struct {
+		Key string `json:"key"` // 拉流代理的唯一标识，用于 delStreamProxy
+	} `json:"data"`
+}
+
+// AddStreamProxy 添加拉流代理（边缘节点从源站拉流）
+// app/stream: 本节点上的应用名和流名称
+// pullURL: 源站拉流地址，如 rtmp://origin:1935/live/{stream_key}
+// 开启 auto_close，无人观看时 ZLMediaKit 自动关闭代理
+// 同名流已存在时视为成功
+func (c *Client) AddStreamProxy(app, stream, pullURL string) (*StreamProxyResponse, error) {
+	params := url.Values{}
+	params.Set("secret", c.secret)
+	params.Set("vhost", "__defaultVhost__")
+	params.Set("app", app)
+	params.Set("stream", stream)
+	params.Set("url", pullURL)
+	params.Set("auto_close", "1")
+
+	resp, err := c.get("/index/api/addStreamProxy", params)
+	if err != nil {
+		return nil, err
+	}
+
+	var result StreamProxyResponse
+	if err := json.Unmarshal(resp, &result); err != nil {
+		return nil, err
+	}
+
+	if result.Code != 0 && !strings.Contains(strings.ToLower(result.Msg), "already exists") {
+		return &result, fmt.Errorf("addStreamProxy failed: code=%d, msg=%s", result.Code, result.Msg)
+	}
+
+	return &result, nil
+}
+
+// DelStreamProxy 删除拉流代理
+// key: addStreamProxy 返回的代理标识
+func (c *Client) DelStreamProxy(key string) (*CommonResponse, error) {
+	params := url.Values{}
+	params.Set("secret", c.secret)
+	params.Set("key", key)
+
+	resp, err := c.get("/index/api/delStreamProxy", params)
+	if err != nil {
+		return nil, err
+	}
+
+	var result CommonResponse
+	if err := json.Unmarshal(resp, &result); err != nil {
+		return nil, err
+	}
+
+	return &result, nil
+}
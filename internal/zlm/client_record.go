@@ -0,0 +1,35 @@
+package zlm
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+)
+
+// DeleteRecordFile 删除节点上的录制文件
+// app/stream/period: 录制文件所在目录 {录制根目录}/{app}/{stream}/{period}，period 为录制日期，如 2024-01-01
+// name: 目录下的文件名；文件不存在或正在录制时 ZLMediaKit 返回失败
+func (c *Client) DeleteRecordFile(app, stream, period, name string) error {
+	params := url.Values{}
+	params.Set("secret", c.secret)
+	params.Set("vhost", "__defaultVhost__")
+	params.Set("app", app)
+	params.Set("stream", stream)
+	params.Set("period", period)
+	params.Set("name", name)
+
+	resp, err := c.get("/index/api/deleteRecordDirectory", params)
+	if err != nil {
+		return err
+	}
+
+	var result CommonResponse
+	if err := json.Unmarshal(resp, &result); err != nil {
+		return err
+	}
+
+	if result.Code != 0 {
+		return fmt.Errorf("deleteRecordDirectory failed: code=%d, msg=%s", result.Code, result.Msg)
+	}
+	return nil
+}
@@ -7,6 +7,7 @@ import (
 	"net/http"
 	"net/url"
 	"strings"
+	"time"
 )
 
 // WebRTCPlayResponse WebRTC 播放响应
@@ -19,9 +20,18 @@ type WebRTCPlayResponse struct {
 // app: 应用名，如 "live"
 // stream: 流名称（stream_key）
 // offerSDP: 客户端的 SDP offer
+// extra: 附加的 url 参数（如播放签名），会透传到 on_play 回调的 params 中
 // 返回 ZLMediaKit 的 SDP answer
-func (c *Client) WebRTCPlay(app, stream string, offerSDP string) (*WebRTCPlayResponse, error) {
+func (c *Client) WebRTCPlay(app, stream string, offerSDP string, extra url.Values) (_ *WebRTCPlayResponse, err error) {
+	start := time.Now()
+	defer func() {
+		observeRequest("webrtc", start, err)
+	}()
+
 	params := url.Values{}
+	for k, v := range extra {
+		params[k] = v
+	}
 	if app != "" {
 		params.Set("app", app)
 	}
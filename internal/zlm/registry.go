@@ -0,0 +1,160 @@
+package zlm
+
+import (
+	"strings"
+	"sync"
+	"time"
+
+	"easy-stream/internal/config"
+)
+
+// 节点角色常量
+const (
+	NodeRoleOrigin = "origin" // 源站：接收推流
+	NodeRoleEdge   = "edge"   // 边缘节点：只用于播放，从源站拉流
+)
+
+const defaultRTMPPort = 1935
+
+// Node ZLMediaKit 集群节点
+type Node struct {
+	ID       string  // mediaServerId
+	Role     string  // 节点角色：origin / edge
+	Host     string  // API 地址
+	Port     string  // API 端口（同时为 HTTP 文件服务器端口）
+	RTMPPort int     // RTMP 端口（边缘节点从源站拉流时使用）
+	HTTPURL  string  // 观众访问 HTTP 文件服务器的地址，未配置时为空
+	Client   *Client // API 客户端
+}
+
+// IsEdge 判断是否为边缘节点
+func (n *Node) IsEdge() bool {
+	return n.Role == NodeRoleEdge
+}
+
+// Registry ZLMediaKit 节点注册表，按 mediaServerId 索引
+// 未配置 zlmediakit.nodes 时，注册表只包含由 zlmediakit.host/port/secret 构成的单个默认节点
+type Registry struct {
+	nodes     map[string]*Node
+	order     []*Node
+	defaultID string
+
+	mu        sync.RWMutex
+	keepalive map[string]time.Time // 各节点最近一次心跳时间
+}
+
+// NewRegistry 根据配置创建节点注册表
+func NewRegistry(cfg config.ZLMediaKitConfig) *Registry {
+	r := &Registry{nodes: make(map[string]*Node), keepalive: make(map[string]time.Time)}
+
+	if len(cfg.Nodes) == 0 {
+		r.add(config.ZLMNodeConfig{ID: cfg.ServerID, Host: cfg.Host, Port: cfg.Port, Secret: cfg.Secret})
+		r.defaultID = cfg.ServerID
+		return r
+	}
+
+	for _, n := range cfg.Nodes {
+		if n.Port == "" {
+			n.Port = cfg.Port
+		}
+		if n.Secret == "" {
+			n.Secret = cfg.Secret
+		}
+		node := r.add(n)
+		if n.Default && !node.IsEdge() && r.defaultID == "" {
+			r.defaultID = n.ID
+		}
+	}
+	if r.defaultID == "" {
+		r.defaultID = r.order[0].ID
+		for _, node := range r.order {
+			if !node.IsEdge() {
+				r.defaultID = node.ID
+				break
+			}
+		}
+	}
+	return r
+}
+
+// add 注册节点，重复的 mediaServerId 以第一个为准
+func (r *Registry) add(n config.ZLMNodeConfig) *Node {
+	if node, ok := r.nodes[n.ID]; ok {
+		return node
+	}
+	role := n.Role
+	if role != NodeRoleEdge {
+		role = NodeRoleOrigin
+	}
+	rtmpPort := n.RTMPPort
+	if rtmpPort == 0 {
+		rtmpPort = defaultRTMPPort
+	}
+	node := &Node{
+		ID:       n.ID,
+		Role:     role,
+		Host:     n.Host,
+		Port:     n.Port,
+		RTMPPort: rtmpPort,
+		HTTPURL:  strings.TrimRight(n.HTTPURL, "/"),
+		Client:   NewClient(n.Host, n.Port, n.Secret),
+	}
+	r.nodes[n.ID] = node
+	r.order = append(r.order, node)
+	return node
+}
+
+// Has 判断 mediaServerId 是否为已注册的节点
+func (r *Registry) Has(id string) bool {
+	_, ok := r.nodes[id]
+	return ok
+}
+
+// Node 获取指定 mediaServerId 的节点，未注册或为空时返回默认节点
+func (r *Registry) Node(id string) *Node {
+	if node, ok := r.nodes[id]; ok {
+		return node
+	}
+	return r.nodes[r.defaultID]
+}
+
+// Client 获取指定 mediaServerId 节点的 API 客户端，未注册或为空时返回默认节点
+func (r *Registry) Client(id string) *Client {
+	return r.Node(id).Client
+}
+
+// Default 获取默认节点
+func (r *Registry) Default() *Node {
+	return r.nodes[r.defaultID]
+}
+
+// Nodes 获取所有节点（按配置顺序）
+func (r *Registry) Nodes() []*Node {
+	return r.order
+}
+
+// Edges 获取所有边缘节点（按配置顺序）
+func (r *Registry) Edges() []*Node {
+	var edges []*Node
+	for _, node := range r.order {
+		if node.IsEdge() {
+			edges = append(edges, node)
+		}
+	}
+	return edges
+}
+
+// Touch 记录节点心跳时间（on_server_keepalive 回调）
+func (r *Registry) Touch(id string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.keepalive[id] = time.Now()
+}
+
+// LastKeepalive 获取节点最近一次心跳时间，未收到心跳时返回 false
+func (r *Registry) LastKeepalive(id string) (time.Time, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	t, ok := r.keepalive[id]
+	return t, ok
+}
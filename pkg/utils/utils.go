@@ -15,6 +15,13 @@ func GenerateStreamKey() string {
 	return fmt.Sprintf("stream_%d_%s", timestamp, hex.EncodeToString(random))
 }
 
+// GeneratePlaybackID 生成播放ID（观众播放使用，不包含推流密钥信息）
+func GeneratePlaybackID() string {
+	random := make([]byte, 12)
+	rand.Read(random)
+	return "play_" + hex.EncodeToString(random)
+}
+
 // GenerateToken 生成随机 Token
 func GenerateToken(length int) string {
 	bytes := make([]byte, length)
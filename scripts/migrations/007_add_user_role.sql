@@ -0,0 +1,12 @@
+-- 迁移脚本: 添加用户角色字段
+-- 用于基于角色的权限控制（admin / operator / viewer_manager / viewer）
+
+ALTER TABLE users ADD COLUMN IF NOT EXISTS role VARCHAR(32) DEFAULT 'viewer';
+
+-- 升级前所有用户都拥有全部权限：admin 账号保留管理员身份，其余已有账号设为操作员
+UPDATE users SET role = 'admin' WHERE username = 'admin';
+UPDATE users SET role = 'operator' WHERE username <> 'admin' AND (role IS NULL OR role = 'viewer');
+
+CREATE INDEX IF NOT EXISTS idx_users_role ON users(role);
+
+COMMENT ON COLUMN users.role IS '角色：admin/operator/viewer_manager/viewer';
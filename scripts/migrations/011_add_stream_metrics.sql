@@ -0,0 +1,29 @@
+-- 迁移脚本: 添加直播健康指标时序表
+-- 采样任务定时写入原始数据，超过保留时长的原始数据降采样为按分钟聚合的数据
+
+CREATE TABLE IF NOT EXISTS stream_metrics (
+    id              BIGSERIAL PRIMARY KEY,
+    stream_id       INTEGER NOT NULL REFERENCES streams(id) ON DELETE CASCADE,
+    sampled_at      TIMESTAMP NOT NULL,
+    granularity     INTEGER DEFAULT 0,
+    bitrate         INTEGER DEFAULT 0,
+    bitrate_min     INTEGER DEFAULT 0,
+    fps             INTEGER DEFAULT 0,
+    fps_min         INTEGER DEFAULT 0,
+    reader_count    INTEGER DEFAULT 0,
+    bytes_speed     INTEGER DEFAULT 0
+);
+
+CREATE INDEX IF NOT EXISTS idx_stream_metrics_stream_time ON stream_metrics(stream_id, sampled_at);
+CREATE INDEX IF NOT EXISTS idx_stream_metrics_granularity_time ON stream_metrics(granularity, sampled_at);
+
+COMMENT ON TABLE stream_metrics IS '直播健康指标时序表';
+COMMENT ON COLUMN stream_metrics.stream_id IS '关联的直播ID';
+COMMENT ON COLUMN stream_metrics.sampled_at IS '采样时间（降采样数据为时间桶起点）';
+COMMENT ON COLUMN stream_metrics.granularity IS '数据粒度（秒），0 表示原始采样';
+COMMENT ON COLUMN stream_metrics.bitrate IS '码率（kbps，降采样数据为平均值）';
+COMMENT ON COLUMN stream_metrics.bitrate_min IS '最低码率（kbps）';
+COMMENT ON COLUMN stream_metrics.fps IS '帧率（降采样数据为平均值）';
+COMMENT ON COLUMN stream_metrics.fps_min IS '最低帧率';
+COMMENT ON COLUMN stream_metrics.reader_count IS '观看人数（降采样数据为最大值）';
+COMMENT ON COLUMN stream_metrics.bytes_speed IS '数据速率（字节/秒）';
@@ -0,0 +1,30 @@
+-- 迁移脚本: 添加每日统计表
+-- 业务事件发生时累加当天计数，定时任务记录当天并发峰值
+
+CREATE TABLE IF NOT EXISTS daily_stats (
+    stat_date        DATE PRIMARY KEY,
+    streams_created  INTEGER DEFAULT 0,
+    publishes        INTEGER DEFAULT 0,
+    plays            INTEGER DEFAULT 0,
+    peak_viewers     INTEGER DEFAULT 0,
+    peak_streams     INTEGER DEFAULT 0,
+    record_files     INTEGER DEFAULT 0,
+    record_seconds   BIGINT DEFAULT 0,
+    record_bytes     BIGINT DEFAULT 0,
+    share_code_uses  INTEGER DEFAULT 0,
+    share_link_uses  INTEGER DEFAULT 0,
+    updated_at       TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+COMMENT ON TABLE daily_stats IS '每日统计表';
+COMMENT ON COLUMN daily_stats.stat_date IS '统计日期';
+COMMENT ON COLUMN daily_stats.streams_created IS '新建直播数';
+COMMENT ON COLUMN daily_stats.publishes IS '推流次数';
+COMMENT ON COLUMN daily_stats.plays IS '观看人次';
+COMMENT ON COLUMN daily_stats.peak_viewers IS '全站并发观看人数峰值';
+COMMENT ON COLUMN daily_stats.peak_streams IS '同时推流数峰值';
+COMMENT ON COLUMN daily_stats.record_files IS '录制文件数';
+COMMENT ON COLUMN daily_stats.record_seconds IS '录制时长（秒）';
+COMMENT ON COLUMN daily_stats.record_bytes IS '录制文件大小（字节）';
+COMMENT ON COLUMN daily_stats.share_code_uses IS '分享码兑换次数';
+COMMENT ON COLUMN daily_stats.share_link_uses IS '分享链接兑换次数';
@@ -0,0 +1,6 @@
+-- 迁移脚本: 添加直播所在 ZLMediaKit 节点字段
+-- on_publish 回调时记录推流所在节点的 mediaServerId，踢流、录制、WebRTC 播放按该字段路由到对应节点
+
+ALTER TABLE streams ADD COLUMN IF NOT EXISTS media_server_id VARCHAR(64);
+
+COMMENT ON COLUMN streams.media_server_id IS '推流所在 ZLMediaKit 节点的 mediaServerId';
@@ -0,0 +1,6 @@
+-- 迁移脚本: 分享链接外键随 stream_key 级联更新
+-- 更换推流码时直接更新 streams.stream_key，share_links.stream_key 同步更新
+
+ALTER TABLE share_links DROP CONSTRAINT IF EXISTS share_links_stream_key_fkey;
+ALTER TABLE share_links ADD CONSTRAINT share_links_stream_key_fkey
+    FOREIGN KEY (stream_key) REFERENCES streams(stream_key) ON DELETE CASCADE ON UPDATE CASCADE;
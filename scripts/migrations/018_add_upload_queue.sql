@@ -0,0 +1,13 @@
+-- 迁移脚本: recording_uploads 作为持久化上传队列
+-- 新增 uploading 状态、尝试次数、下次尝试时间和领取租约，上传失败按指数退避重试，服务重启后继续处理
+
+ALTER TABLE recording_uploads ADD COLUMN IF NOT EXISTS attempts INTEGER DEFAULT 0;
+ALTER TABLE recording_uploads ADD COLUMN IF NOT EXISTS next_attempt_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP;
+ALTER TABLE recording_uploads ADD COLUMN IF NOT EXISTS locked_until TIMESTAMP;
+
+CREATE INDEX IF NOT EXISTS idx_recording_uploads_queue ON recording_uploads(status, next_attempt_at);
+
+COMMENT ON COLUMN recording_uploads.status IS '上传状态: pending / uploading / success / failed';
+COMMENT ON COLUMN recording_uploads.attempts IS '已尝试次数';
+COMMENT ON COLUMN recording_uploads.next_attempt_at IS '下次尝试时间';
+COMMENT ON COLUMN recording_uploads.locked_until IS '上传中任务的租约到期时间，过期后可被重新领取';